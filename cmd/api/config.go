@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"os"
+	"text/tabwriter"
+)
+
+// runConfig implements the config command, printing the effective
+// configuration with secrets redacted
+func runConfig(cfg *config.Config, args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "config: takes no arguments")
+		return 2
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, setting := range cfg.Settings() {
+		fmt.Fprintf(w, "%s\t%s\n", setting.Name, setting.Value)
+	}
+	w.Flush()
+
+	return 0
+}
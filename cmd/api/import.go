@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/importer"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+)
+
+// runImport implements the import command. It prints the import report as
+// JSON and returns a non-zero exit code if any row failed.
+func runImport(cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	file := flags.String("file", "", "CSV or NDJSON file to import, - for stdin")
+	format := flags.String("format", "", "csv or ndjson (default: from the file extension)")
+	mode := flags.String("mode", models.ImportModeAllOrNothing, "all_or_nothing or best_effort")
+	dryRun := flags.Bool("dry-run", false, "validate and roll back without saving")
+	batchSize := flags.Int("batch-size", importer.DefaultBatchSize, "rows per transaction in best_effort mode")
+	source := flags.String("source", "", "external ID source for rows that don't name one")
+	status := flags.String("status", models.MovieStatusPublished, "workflow status of the movies created: draft, in_review, published or archived")
+	editor := flags.Int64("editor", 0, "user ID recorded as the editor in movie revisions (default: none)")
+	flags.Parse(args)
+
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "import: -file is required")
+		flags.Usage()
+		return 2
+	}
+
+	opts := models.MovieImportOptions{
+		Format:    *format,
+		Mode:      *mode,
+		DryRun:    *dryRun,
+		BatchSize: *batchSize,
+		Source:    *source,
+		Status:    *status,
+	}
+	if opts.Format == "" {
+		switch strings.ToLower(filepath.Ext(*file)) {
+		case ".csv":
+			opts.Format = models.ImportFormatCSV
+		case ".ndjson", ".jsonl":
+			opts.Format = models.ImportFormatNDJSON
+		}
+	}
+	if err := importer.ValidateOptions(&opts); err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 2
+	}
+
+	var src io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "import:", err)
+			return 1
+		}
+		defer f.Close()
+		src = f
+	}
+
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *editor > 0 {
+		ctx = models.WithEditor(ctx, *editor)
+	}
+
+	result, err := importer.NewImporter(repository.NewMovieRepository(db)).Run(ctx, src, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+
+	if result.Failed > 0 {
+		return 1
+	}
+	return 0
+}
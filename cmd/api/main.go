@@ -1,20 +1,42 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/marchelhutagalung/go-service/internal/auth"
 	"github.com/marchelhutagalung/go-service/internal/config"
-	"github.com/marchelhutagalung/go-service/internal/database"
 	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/health"
+	"github.com/marchelhutagalung/go-service/internal/idempotency"
+	"github.com/marchelhutagalung/go-service/internal/jobs"
 	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/metrics"
 	"github.com/marchelhutagalung/go-service/internal/middleware"
-	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/ratelimit"
 	"github.com/marchelhutagalung/go-service/internal/router"
 	"github.com/marchelhutagalung/go-service/internal/server"
+	"github.com/marchelhutagalung/go-service/internal/storage"
+	"github.com/marchelhutagalung/go-service/internal/tracing"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
+const usage = `Usage: go-service [command] [flags]
+
+Commands:
+  serve    run the API server (default)
+  import   bulk import movies from a CSV or NDJSON file
+  migrate  apply or revert database migrations
+  user     create admins, reset passwords and revoke sessions
+  seed     load demo genres, people and movies
+  config   print the effective configuration with secrets redacted
+
+Run "go-service <command> -h" for the flags of a command.
+`
+
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
@@ -22,35 +44,133 @@ func main() {
 	}
 
 	logger.Init(cfg.Server.Env)
+
+	command, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
+	}
+
+	switch command {
+	case "serve":
+		serve(cfg)
+	case "import":
+		os.Exit(runImport(cfg, args))
+	case "migrate":
+		os.Exit(runMigrate(cfg, args))
+	case "user":
+		os.Exit(runUser(cfg, args))
+	case "seed":
+		os.Exit(runSeed(cfg, args))
+	case "config":
+		os.Exit(runConfig(cfg, args))
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
+
+// serve runs the API server until it is shut down
+func serve(cfg *config.Config) {
 	logger.Info("Starting application", logger.Field("env", cfg.Server.Env))
 
-	db, err := database.NewPostgresDB(&cfg.Database)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownTracing, err := tracing.Init(ctx, &cfg.Tracing)
 	if err != nil {
-		logger.Fatal("Failed to connect to PostgreSQL", logger.Field("error", err))
+		logger.Fatal("Failed to set up tracing", logger.Field("error", err))
 	}
-	defer db.Close()
-	logger.Info("Connected to PostgreSQL")
+	defer func() {
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("Failed to flush traces", logger.Field("error", err))
+		}
+	}()
+	logger.Info("Tracing ready", logger.Field("exporter", cfg.Tracing.Exporter))
+
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
 
-	redisClient, err := database.NewRedisClient(&cfg.Redis)
+	store, err := openBackend(ctx, cfg, checker)
 	if err != nil {
-		logger.Fatal("Failed to connect to Redis", logger.Field("error", err))
+		logger.Fatal("Failed to set up storage", logger.Field("error", err))
 	}
-	defer redisClient.Close()
-	logger.Info("Connected to Redis")
+	defer store.close()
+	logger.Info("Storage ready", logger.Field("driver", cfg.Storage.Driver))
 
-	userRepo := repository.NewUserRepository(db)
-	movieRepo := repository.NewMovieRepository(db)
+	blobStore, err := storage.NewBlobStore(ctx, &cfg.BlobStore)
+	if err != nil {
+		logger.Fatal("Failed to set up blob storage", logger.Field("error", err))
+	}
+	logger.Info("Blob storage ready", logger.Field("driver", cfg.BlobStore.Driver))
+
+	// A local store serves its own files
+	var mediaHandler http.Handler
+	if localStore, ok := blobStore.(*storage.LocalStore); ok {
+		mediaHandler = localStore
+	}
 
-	jwtService := auth.NewJWTService(&cfg.JWT, redisClient)
+	jwtService := auth.NewJWTService(&cfg.JWT, store.tokens)
 	authMiddleware := middleware.NewMiddleware(jwtService)
-	authHandler := handlers.NewAuthHandler(userRepo, jwtService)
-	userHandler := handlers.NewUserHandler(userRepo)
-	movieHandler := handlers.NewMovieHandler(movieRepo)
 
-	r := router.SetupRouter(authHandler, userHandler, movieHandler, authMiddleware)
+	// Limits are shared through Redis when there is one, and kept per
+	// instance while it is down or in memory mode
+	var rateLimiter *middleware.RateLimiter
+	if cfg.RateLimit.Enabled {
+		var limiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+		if store.redis != nil {
+			limiter = ratelimit.NewFallbackLimiter(ratelimit.NewRedisLimiter(store.redis.Client, "ratelimit:"), limiter)
+		}
+		rateLimiter = middleware.NewRateLimiter(limiter, &cfg.RateLimit, authMiddleware)
+	}
+
+	var idempotencyStore idempotency.Store = idempotency.NewMemoryStore()
+	if store.redis != nil {
+		idempotencyStore = idempotency.NewRedisStore(store.redis.Client, "idempotency:")
+	}
+	idempotencyMiddleware := middleware.NewIdempotency(idempotencyStore, &cfg.Idempotency)
+	authHandler := handlers.NewAuthHandler(store.users, jwtService)
+	userHandler := handlers.NewUserHandler(store.users)
+	movieHandler := handlers.NewMovieHandler(store.movies, store.savedMovies, &cfg.Movies)
+	movieImageHandler := handlers.NewMovieImageHandler(store.movies, blobStore, &cfg.Images)
+	healthHandler := handlers.NewHealthHandler(checker)
+
+	// The remaining features need Postgres
+	var (
+		genreHandler       *handlers.GenreHandler
+		personHandler      *handlers.PersonHandler
+		reviewHandler      *handlers.ReviewHandler
+		savedMovieHandler  *handlers.SavedMovieHandler
+		movieListHandler   *handlers.MovieListHandler
+		movieImportHandler *handlers.MovieImportHandler
+	)
+	if store.genres != nil {
+		genreHandler = handlers.NewGenreHandler(store.genres)
+		personHandler = handlers.NewPersonHandler(store.people)
+		reviewHandler = handlers.NewReviewHandler(store.reviews)
+		savedMovieHandler = handlers.NewSavedMovieHandler(store.savedMovies)
+		movieListHandler = handlers.NewMovieListHandler(store.movieLists)
+		movieImportHandler = handlers.NewMovieImportHandler(store.importer)
+	}
+
+	if cfg.Metrics.Enabled {
+		go func() {
+			if err := metrics.Serve(ctx, &cfg.Metrics); err != nil {
+				logger.Error("Metrics server failed", logger.Field("error", err))
+			}
+		}()
+	}
+
+	trashPurger := jobs.NewTrashPurger(store.movies, &cfg.Trash)
+	go trashPurger.Run(ctx)
+
+	r := router.SetupRouter(&cfg.Server, authHandler, userHandler, movieHandler, genreHandler, personHandler, reviewHandler, savedMovieHandler, movieListHandler, movieImportHandler, movieImageHandler, healthHandler, mediaHandler, authMiddleware, rateLimiter, idempotencyMiddleware)
 	logger.Info("Router configured")
 
 	srv := server.NewServer(&cfg.Server, r)
+	srv.OnShutdown(checker.SetShuttingDown)
 
 	// Capture shutdown signals
 	stopChan := make(chan os.Signal, 1)
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/migrate"
+	"github.com/marchelhutagalung/go-service/migrations"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `Usage: go-service migrate <command> [flags]
+
+Commands:
+  up       apply pending migrations (-steps limits how many)
+  down     revert the newest migration (-steps N reverts N, -all reverts every one)
+  status   list migrations and whether they are applied
+  goto V   migrate up or down to version V
+`
+
+// runMigrate implements the migrate command
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("migrate "+command, flag.ExitOnError)
+	steps := flags.Int("steps", 0, "number of migrations to run")
+	all := flags.Bool("all", false, "revert every migration (down only)")
+	flags.Parse(args[1:])
+
+	var target int64
+	switch command {
+	case "up", "status":
+	case "down":
+		if *all && *steps != 0 {
+			fmt.Fprintln(os.Stderr, "migrate: -steps and -all cannot be combined")
+			return 2
+		}
+		if !*all && *steps == 0 {
+			*steps = 1
+		}
+	case "goto":
+		version, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+		if flags.NArg() != 1 || err != nil {
+			fmt.Fprintln(os.Stderr, "migrate: goto needs a single version number")
+			return 2
+		}
+		target = version
+	case "help", "-h", "--help":
+		fmt.Print(migrateUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown migrate command %q\n\n%s", command, migrateUsage)
+		return 2
+	}
+
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db.DB, migrations.FS)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var count int
+	switch command {
+	case "up":
+		count, err = migrator.Up(ctx, *steps)
+	case "down":
+		count, err = migrator.Down(ctx, *steps)
+	case "goto":
+		count, err = migrator.Goto(ctx, target)
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+	}
+
+	if count > 0 || err == nil {
+		fmt.Printf("%d migration(s) run\n", count)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrate.Migrator) int {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state, appliedAt = "applied", status.AppliedAt.Format(time.RFC3339)
+		}
+		if status.Missing {
+			state = "applied, file missing"
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	w.Flush()
+
+	return 0
+}
+
+// migrateOnStartup applies pending migrations before the server starts
+func migrateOnStartup(ctx context.Context, db *database.PostgresDB) error {
+	migrator, err := migrate.New(db.DB, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Up(ctx, 0)
+	return err
+}
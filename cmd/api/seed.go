@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// seedSource is the external ID source of the demo movies, which makes
+// seeding repeatable
+const seedSource = "demo"
+
+type seedCredit struct {
+	Person    string
+	Role      string
+	Character string
+}
+
+type seedMovie struct {
+	ID          string
+	Title       string
+	Description string
+	ReleaseDate string
+	Rating      float64
+	Duration    int
+	Genres      []string
+	Credits     []seedCredit
+}
+
+var seedGenres = []string{"Drama", "Science Fiction", "Crime", "Thriller", "Animation", "Comedy"}
+
+var seedMovies = []seedMovie{
+	{
+		ID: "inception", Title: "Inception", ReleaseDate: "2010-07-16", Rating: 8.8, Duration: 148,
+		Description: "A thief who steals secrets through dream-sharing is offered a chance to have his record erased.",
+		Genres:      []string{"science-fiction", "thriller"},
+		Credits: []seedCredit{
+			{Person: "Christopher Nolan", Role: models.RoleDirector},
+			{Person: "Leonardo DiCaprio", Role: models.RoleActor, Character: "Cobb"},
+			{Person: "Elliot Page", Role: models.RoleActor, Character: "Ariadne"},
+		},
+	},
+	{
+		ID: "the-dark-knight", Title: "The Dark Knight", ReleaseDate: "2008-07-18", Rating: 9.0, Duration: 152,
+		Description: "Batman faces the Joker, who wants to plunge Gotham City into anarchy.",
+		Genres:      []string{"crime", "drama", "thriller"},
+		Credits: []seedCredit{
+			{Person: "Christopher Nolan", Role: models.RoleDirector},
+			{Person: "Christian Bale", Role: models.RoleActor, Character: "Bruce Wayne"},
+			{Person: "Heath Ledger", Role: models.RoleActor, Character: "Joker"},
+		},
+	},
+	{
+		ID: "pulp-fiction", Title: "Pulp Fiction", ReleaseDate: "1994-10-14", Rating: 8.9, Duration: 154,
+		Description: "The lives of two mob hitmen, a boxer and a pair of diner bandits intertwine.",
+		Genres:      []string{"crime", "drama"},
+		Credits: []seedCredit{
+			{Person: "Quentin Tarantino", Role: models.RoleDirector},
+			{Person: "Quentin Tarantino", Role: models.RoleWriter},
+			{Person: "John Travolta", Role: models.RoleActor, Character: "Vincent Vega"},
+			{Person: "Uma Thurman", Role: models.RoleActor, Character: "Mia Wallace"},
+		},
+	},
+	{
+		ID: "spirited-away", Title: "Spirited Away", ReleaseDate: "2001-07-20", Rating: 8.6, Duration: 125,
+		Description: "A girl wanders into a world ruled by gods and spirits, where humans are changed into beasts.",
+		Genres:      []string{"animation", "drama"},
+		Credits: []seedCredit{
+			{Person: "Hayao Miyazaki", Role: models.RoleDirector},
+			{Person: "Hayao Miyazaki", Role: models.RoleWriter},
+		},
+	},
+	{
+		ID: "the-grand-budapest-hotel", Title: "The Grand Budapest Hotel", ReleaseDate: "2014-03-28", Rating: 8.1, Duration: 99,
+		Description: "A concierge and his lobby boy are caught up in the theft of a priceless painting.",
+		Genres:      []string{"comedy", "crime"},
+		Credits: []seedCredit{
+			{Person: "Wes Anderson", Role: models.RoleDirector},
+			{Person: "Ralph Fiennes", Role: models.RoleActor, Character: "M. Gustave"},
+		},
+	},
+}
+
+// runSeed implements the seed command. Running it again updates the demo
+// movies in place rather than duplicating them.
+func runSeed(cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	draft := flags.Bool("draft", false, "leave newly created demo movies as drafts instead of publishing them")
+	flags.Parse(args)
+
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "seed:", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	seeder := &seeder{
+		genres: repository.NewGenreRepository(db),
+		people: repository.NewPersonRepository(db),
+		movies: repository.NewMovieRepository(db),
+	}
+	if err := seeder.run(ctx, !*draft); err != nil {
+		fmt.Fprintln(os.Stderr, "seed:", err)
+		return 1
+	}
+	return 0
+}
+
+type seeder struct {
+	genres *repository.GenreRepository
+	people *repository.PersonRepository
+	movies *repository.MovieRepository
+}
+
+func (s *seeder) run(ctx context.Context, publish bool) error {
+	if err := s.seedGenres(ctx); err != nil {
+		return err
+	}
+
+	personIDs := map[string]int64{}
+	for _, movie := range seedMovies {
+		input := &models.ReplaceMovieInput{
+			Title:       &movie.Title,
+			Description: &movie.Description,
+			Rating:      &movie.Rating,
+			Duration:    &movie.Duration,
+			Genres:      movie.Genres,
+		}
+
+		releaseDate, err := time.Parse("2006-01-02", movie.ReleaseDate)
+		if err != nil {
+			return err
+		}
+		input.ReleaseDate = &releaseDate
+
+		for i, credit := range movie.Credits {
+			id, ok := personIDs[credit.Person]
+			if !ok {
+				if id, err = s.personID(ctx, credit.Person); err != nil {
+					return err
+				}
+				personIDs[credit.Person] = id
+			}
+			input.Credits = append(input.Credits, models.CreditInput{
+				PersonID: id, Role: credit.Role, Character: credit.Character, BillingOrder: i,
+			})
+		}
+
+		saved, created, err := s.movies.UpsertByExternalID(ctx, seedSource, movie.ID, input, nil)
+		if err != nil {
+			return fmt.Errorf("%s: %w", movie.Title, err)
+		}
+
+		if created && publish {
+			for _, name := range []string{"submit", "publish"} {
+				saved, err = s.movies.Transition(ctx, saved.ID, models.MovieTransitions[name], nil, nil)
+				if err != nil {
+					return fmt.Errorf("%s: %w", movie.Title, err)
+				}
+			}
+		}
+
+		action := "Updated"
+		if created {
+			action = "Created"
+		}
+		fmt.Printf("%s movie %q (id %d, %s)\n", action, saved.Title, saved.ID, saved.Status)
+	}
+
+	return nil
+}
+
+// seedGenres creates the demo genres that don't exist yet
+func (s *seeder) seedGenres(ctx context.Context) error {
+	genres, err := s.genres.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := map[string]bool{}
+	for _, genre := range genres {
+		existing[genre.Slug] = true
+	}
+
+	for _, name := range seedGenres {
+		if existing[models.Slugify(name)] {
+			continue
+		}
+		if _, err := s.genres.Create(ctx, &models.CreateGenreInput{Name: name}); err != nil {
+			return fmt.Errorf("genre %s: %w", name, err)
+		}
+		fmt.Printf("Created genre %q\n", name)
+	}
+
+	return nil
+}
+
+// personID returns the person with exactly the given name, creating them if
+// there is none
+func (s *seeder) personID(ctx context.Context, name string) (int64, error) {
+	people, _, err := s.people.List(ctx, &models.PersonQuery{Name: name, PageSize: 100})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, person := range people {
+		if strings.EqualFold(person.Name, name) {
+			return person.ID, nil
+		}
+	}
+
+	person, err := s.people.Create(ctx, &models.CreatePersonInput{Name: name})
+	if err != nil {
+		return 0, fmt.Errorf("person %s: %w", name, err)
+	}
+	fmt.Printf("Created person %q\n", name)
+
+	return person.ID, nil
+}
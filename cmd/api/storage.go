@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/auth"
+	"github.com/marchelhutagalung/go-service/internal/cache"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/health"
+	"github.com/marchelhutagalung/go-service/internal/importer"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/metrics"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/repository/memory"
+)
+
+// backend holds the stores the server runs on. Features that only the
+// Postgres backend provides are left nil in memory mode and the router leaves
+// their routes out.
+type backend struct {
+	users  repository.UserStore
+	movies repository.MovieStore
+	tokens auth.TokenStore
+	redis  *database.RedisClient // nil when the backend does not use Redis
+
+	genres      *repository.GenreRepository
+	people      *repository.PersonRepository
+	reviews     *repository.ReviewRepository
+	savedMovies *repository.SavedMovieRepository
+	movieLists  *repository.MovieListRepository
+	importer    *importer.Importer
+
+	close func()
+}
+
+// openBackend sets up the stores selected by the storage driver and
+// registers the readiness checks of the services they connect to
+func openBackend(ctx context.Context, cfg *config.Config, checker *health.Checker) (*backend, error) {
+	switch cfg.Storage.Driver {
+	case "postgres":
+		return openPostgresBackend(ctx, cfg, checker)
+	case "memory":
+		return newMemoryBackend(), nil
+	}
+
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+}
+
+// openPostgresBackend connects to Postgres and Redis
+func openPostgresBackend(ctx context.Context, cfg *config.Config, checker *health.Checker) (*backend, error) {
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("connect to PostgreSQL: %w", err)
+	}
+	logger.Info("Connected to PostgreSQL")
+
+	if cfg.Database.AutoMigrate {
+		if err := migrateOnStartup(ctx, db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("run migrations: %w", err)
+		}
+		logger.Info("Database migrations up to date")
+	}
+
+	redisClient, err := database.NewRedisClient(&cfg.Redis)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to Redis: %w", err)
+	}
+	logger.Info("Connected to Redis")
+
+	if err := metrics.RegisterDB(db.DB); err != nil {
+		logger.Warn("Failed to export database pool metrics", logger.Field("error", err))
+	}
+	if err := metrics.RegisterRedis(redisClient.Client); err != nil {
+		logger.Warn("Failed to export Redis pool metrics", logger.Field("error", err))
+	}
+
+	// Without Postgres nothing works. Without Redis logins and authenticated
+	// requests fail, but public reads still do, so it only degrades the service.
+	checker.Register("postgres", health.DatabaseCheck(db.DB), true)
+	checker.Register("redis", health.RedisCheck(redisClient.Client), false)
+
+	movieRepo := repository.NewMovieRepository(db)
+	if cfg.Cache.Enabled {
+		movieRepo.UseCache(cache.New(redisClient, "cache:"), &cfg.Cache)
+	}
+	reviewRepo := repository.NewReviewRepository(db)
+	reviewRepo.UseMovieCache(movieRepo)
+	genreRepo := repository.NewGenreRepository(db)
+	genreRepo.UseMovieCache(movieRepo)
+	personRepo := repository.NewPersonRepository(db)
+	personRepo.UseMovieCache(movieRepo)
+
+	return &backend{
+		users:       repository.NewTracedUserStore(repository.NewUserRepository(db)),
+		movies:      repository.NewTracedMovieStore(movieRepo),
+		tokens:      redisClient,
+		redis:       redisClient,
+		genres:      genreRepo,
+		people:      personRepo,
+		reviews:     reviewRepo,
+		savedMovies: repository.NewSavedMovieRepository(db),
+		movieLists:  repository.NewMovieListRepository(db),
+		importer:    importer.NewImporter(movieRepo),
+		close: func() {
+			redisClient.Close()
+			db.Close()
+		},
+	}, nil
+}
+
+// newMemoryBackend keeps users, movies and tokens in memory. Movies can use
+// the demo genres; credits cannot be added as there are no people.
+func newMemoryBackend() *backend {
+	movies := memory.NewMovieStore()
+	for _, name := range seedGenres {
+		movies.AddGenre(name)
+	}
+
+	logger.Warn("Using in-memory storage; data is lost when the server stops")
+
+	return &backend{
+		users:  memory.NewUserStore(),
+		movies: movies,
+		tokens: memory.NewTokenStore(),
+		close:  func() {},
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/auth"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"net/mail"
+	"os"
+	"os/signal"
+	"strings"
+	"unicode/utf8"
+)
+
+const userUsage = `Usage: go-service user <command> [flags]
+
+Commands:
+  create-admin      create an admin user, or promote an existing one with -promote
+  reset-password    set a new password and sign the user out everywhere
+  revoke-sessions   sign a user out everywhere
+
+Passwords are read from stdin with -password-stdin. Otherwise a random
+password is generated and printed once.
+`
+
+// runUser implements the user command
+func runUser(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, userUsage)
+		return 2
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("user "+command, flag.ExitOnError)
+	email := flags.String("email", "", "email address of the user")
+
+	var run func(ctx context.Context, users *repository.UserRepository) error
+	switch command {
+	case "create-admin":
+		firstName := flags.String("first-name", "", "first name")
+		lastName := flags.String("last-name", "", "last name")
+		promote := flags.Bool("promote", false, "make an existing user an admin instead of failing")
+		passwordStdin := flags.Bool("password-stdin", false, "read the password from stdin")
+		run = func(ctx context.Context, users *repository.UserRepository) error {
+			return createAdmin(ctx, users, *email, *firstName, *lastName, *promote, *passwordStdin)
+		}
+	case "reset-password":
+		passwordStdin := flags.Bool("password-stdin", false, "read the password from stdin")
+		run = func(ctx context.Context, users *repository.UserRepository) error {
+			return resetPassword(ctx, cfg, users, *email, *passwordStdin)
+		}
+	case "revoke-sessions":
+		run = func(ctx context.Context, users *repository.UserRepository) error {
+			user, err := users.GetByEmail(ctx, *email)
+			if err != nil {
+				return err
+			}
+			if err := revokeSessions(cfg, user.ID); err != nil {
+				return err
+			}
+			fmt.Printf("Revoked sessions for %s\n", user.Email)
+			return nil
+		}
+	case "help", "-h", "--help":
+		fmt.Print(userUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown user command %q\n\n%s", command, userUsage)
+		return 2
+	}
+
+	flags.Parse(args[1:])
+
+	*email = strings.TrimSpace(*email)
+	if *email == "" {
+		fmt.Fprintln(os.Stderr, "user: -email is required")
+		flags.Usage()
+		return 2
+	}
+
+	db, err := database.NewPostgresDB(&cfg.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "user:", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, repository.NewUserRepository(db)); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			fmt.Fprintf(os.Stderr, "user: no user with email %s\n", *email)
+		} else {
+			fmt.Fprintln(os.Stderr, "user:", err)
+		}
+		return 1
+	}
+	return 0
+}
+
+func createAdmin(ctx context.Context, users *repository.UserRepository, email, firstName, lastName string, promote, passwordStdin bool) error {
+	existing, err := users.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if !promote {
+			return fmt.Errorf("%s already exists; use -promote to make it an admin", email)
+		}
+		if _, err := users.SetRole(ctx, existing.ID, models.UserRoleAdmin); err != nil {
+			return err
+		}
+		fmt.Printf("Promoted %s (id %d) to admin\n", existing.Email, existing.ID)
+		return nil
+	case !errors.Is(err, repository.ErrUserNotFound):
+		return err
+	}
+
+	password, generated, err := readPassword(passwordStdin)
+	if err != nil {
+		return err
+	}
+
+	input := &models.CreateUserInput{
+		Email: email, Password: password, FirstName: firstName, LastName: lastName, Role: models.UserRoleAdmin,
+	}
+	if err := validateUserInput(input); err != nil {
+		return err
+	}
+
+	passwordHash, err := models.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user, err := users.Create(ctx, input, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created admin %s (id %d)\n", user.Email, user.ID)
+	if generated {
+		fmt.Printf("Password: %s\n", password)
+	}
+	return nil
+}
+
+// Password length limits for users created from the command line. bcrypt
+// ignores everything past 72 bytes, so longer passwords are rejected rather
+// than silently truncated.
+const (
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+)
+
+// validateUserInput checks the email address and password of a user created
+// from the command line
+func validateUserInput(input *models.CreateUserInput) error {
+	var problems []string
+	if addr, err := mail.ParseAddress(input.Email); err != nil || addr.Address != input.Email {
+		problems = append(problems, "email must be a valid email address")
+	}
+	if utf8.RuneCountInString(input.Password) < minPasswordLength {
+		problems = append(problems, fmt.Sprintf("password must be at least %d characters", minPasswordLength))
+	} else if len(input.Password) > maxPasswordBytes {
+		problems = append(problems, fmt.Sprintf("password must be at most %d bytes", maxPasswordBytes))
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func resetPassword(ctx context.Context, cfg *config.Config, users *repository.UserRepository, email string, passwordStdin bool) error {
+	user, err := users.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	password, generated, err := readPassword(passwordStdin)
+	if err != nil {
+		return err
+	}
+
+	if err := validateUserInput(&models.CreateUserInput{Email: user.Email, Password: password}); err != nil {
+		return err
+	}
+
+	passwordHash, err := models.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := users.UpdatePassword(ctx, user.ID, passwordHash); err != nil {
+		return err
+	}
+
+	fmt.Printf("Reset password for %s\n", user.Email)
+	if generated {
+		fmt.Printf("Password: %s\n", password)
+	}
+
+	// Tokens issued with the old password must stop working
+	if err := revokeSessions(cfg, user.ID); err != nil {
+		return fmt.Errorf("password changed but sessions were not revoked: %w", err)
+	}
+	return nil
+}
+
+// revokeSessions invalidates the user's token so every client has to log in again
+func revokeSessions(cfg *config.Config, userID int64) error {
+	redisClient, err := database.NewRedisClient(&cfg.Redis)
+	if err != nil {
+		return err
+	}
+	defer redisClient.Close()
+
+	return auth.NewJWTService(&cfg.JWT, redisClient).InvalidateToken(context.Background(), userID)
+}
+
+// readPassword reads a password from the first line of stdin, or generates
+// one when fromStdin is false
+func readPassword(fromStdin bool) (password string, generated bool, err error) {
+	if !fromStdin {
+		buf := make([]byte, 18)
+		if _, err := rand.Read(buf); err != nil {
+			return "", false, err
+		}
+		return base64.RawURLEncoding.EncodeToString(buf), true, nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		if err != nil {
+			return "", false, fmt.Errorf("reading password from stdin: %w", err)
+		}
+		return "", false, errors.New("password from stdin is empty")
+	}
+
+	return password, false, nil
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/marchelhutagalung/go-service/internal/config"
-	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/tracing"
 	"strconv"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Common errors
@@ -18,29 +21,31 @@ var (
 )
 
 type Claims struct {
-	UserID int64 `json:"user_id"`
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
 type JWTService struct {
-	config      *config.JWTConfig
-	redisClient *database.RedisClient
+	config *config.JWTConfig
+	tokens TokenStore
 }
 
-func NewJWTService(config *config.JWTConfig, redisClient *database.RedisClient) *JWTService {
+func NewJWTService(config *config.JWTConfig, tokens TokenStore) *JWTService {
 	return &JWTService{
-		config:      config,
-		redisClient: redisClient,
+		config: config,
+		tokens: tokens,
 	}
 }
 
 // GenerateToken creates a new JWT token for a user
-func (s *JWTService) GenerateToken(userID int64) (string, error) {
+func (s *JWTService) GenerateToken(ctx context.Context, userID int64, role string) (string, error) {
 	now := time.Now()
 	expirationTime := now.Add(s.config.Expiration)
 
 	claims := &Claims{
 		UserID: userID,
+		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -56,9 +61,9 @@ func (s *JWTService) GenerateToken(userID int64) (string, error) {
 		return "", err
 	}
 
-	tokenKey := fmt.Sprintf("token:valid:%d", userID)
-	ctx := context.Background()
-	err = s.redisClient.Set(ctx, tokenKey, tokenString, s.config.Expiration)
+	ctx, span := startTokenStoreSpan(ctx, "TokenStore.Set", userID)
+	err = s.tokens.Set(ctx, tokenKey(userID), tokenString, s.config.Expiration)
+	tracing.End(span, err)
 	if err != nil {
 		return "", err
 	}
@@ -67,7 +72,7 @@ func (s *JWTService) GenerateToken(userID int64) (string, error) {
 }
 
 // ValidateToken validates the JWT token
-func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+func (s *JWTService) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -88,9 +93,9 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
-	ctx := context.Background()
-	tokenKey := fmt.Sprintf("token:valid:%d", claims.UserID)
-	storedToken, err := s.redisClient.Get(ctx, tokenKey)
+	ctx, span := startTokenStoreSpan(ctx, "TokenStore.Get", claims.UserID)
+	storedToken, err := s.tokens.Get(ctx, tokenKey(claims.UserID))
+	span.End() // a missing token is an expected outcome, not an error
 	if err != nil || storedToken != tokenString {
 		return nil, ErrInvalidToken
 	}
@@ -98,9 +103,23 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-func (s *JWTService) InvalidateToken(userID int64) error {
-	ctx := context.Background()
-	tokenKey := fmt.Sprintf("token:valid:%d", userID)
+func (s *JWTService) InvalidateToken(ctx context.Context, userID int64) error {
+	ctx, span := startTokenStoreSpan(ctx, "TokenStore.Delete", userID)
+	err := s.tokens.Delete(ctx, tokenKey(userID))
+	tracing.End(span, err)
+
+	return err
+}
+
+// tokenKey is the key the valid token of a user is stored under
+func tokenKey(userID int64) string {
+	return fmt.Sprintf("token:valid:%d", userID)
+}
 
-	return s.redisClient.Delete(ctx, tokenKey)
+// startTokenStoreSpan starts a client span around a token store call
+func startTokenStoreSpan(ctx context.Context, name string, userID int64) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("user.id", userID)),
+	)
 }
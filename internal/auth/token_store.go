@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate go run github.com/golang/mock/mockgen -source=token_store.go -destination=mocks/token_store.go -package=mocks
+
+// TokenStore keeps the currently valid token of each user so tokens can be
+// revoked before they expire. *database.RedisClient implements it in Redis
+// and memory.TokenStore in memory.
+type TokenStore interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	// Get returns an error if there is no value under key
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+}
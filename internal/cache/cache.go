@@ -0,0 +1,117 @@
+// Package cache is a read-through JSON cache in Redis. Entries are never
+// deleted; instead their keys include tag generations, and bumping a tag's
+// generation makes every entry built from the old one unreachable until it
+// expires.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache stores JSON values in Redis under a key prefix
+type Cache struct {
+	client *database.RedisClient
+	prefix string
+	group  singleflight.Group
+}
+
+// New creates a cache whose keys all start with prefix
+func New(client *database.RedisClient, prefix string) *Cache {
+	return &Cache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// Generations returns the current generation of each tag, in order. A tag
+// that has never been bumped is at generation 0.
+func (c *Cache) Generations(ctx context.Context, tags ...string) ([]int64, error) {
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = c.generationKey(tag)
+	}
+
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	generations := make([]int64, len(tags))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			generations[i], _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+
+	return generations, nil
+}
+
+// Bump moves each tag to a new generation, invalidating the entries keyed
+// with the old one
+func (c *Cache) Bump(ctx context.Context, tags ...string) error {
+	pipe := c.client.Pipeline()
+	for _, tag := range tags {
+		pipe.Incr(ctx, c.generationKey(tag))
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Fetch decodes the entry at key into dest. On a miss load is called, once
+// per key however many callers are waiting on it, and its result is stored
+// for ttl. Redis errors are logged and treated as misses so that reads keep
+// working while Redis is down.
+func (c *Cache) Fetch(ctx context.Context, key string, ttl time.Duration, dest interface{}, load func(ctx context.Context) (interface{}, error)) error {
+	key = c.prefix + key
+
+	data, err := c.client.Client.Get(ctx, key).Bytes()
+	if err == nil {
+		if err := json.Unmarshal(data, dest); err == nil {
+			logger.DebugContext(ctx, "Cache lookup", logger.Field("cache", "hit"), logger.Field("cache_key", key))
+			return nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		logger.WarnContext(ctx, "Cache read failed", logger.Field("error", err), logger.Field("cache_key", key))
+	}
+
+	logger.DebugContext(ctx, "Cache lookup", logger.Field("cache", "miss"), logger.Field("cache_key", key))
+
+	// The load runs on behalf of every waiting caller, so it must not be
+	// cancelled when the first one goes away
+	result, err, _ := c.group.Do(key, func() (interface{}, error) {
+		value, err := load(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.client.Client.Set(context.WithoutCancel(ctx), key, data, ttl).Err(); err != nil {
+			logger.WarnContext(ctx, "Cache write failed", logger.Field("error", err), logger.Field("cache_key", key))
+		}
+
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(result.([]byte), dest)
+}
+
+func (c *Cache) generationKey(tag string) string {
+	return c.prefix + "gen:" + tag
+}
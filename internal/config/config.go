@@ -2,46 +2,152 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	JWT         JWTConfig
+	Trash       TrashConfig
+	Movies      MoviesConfig
+	BlobStore   BlobStoreConfig
+	Images      ImageConfig
+	Cache       CacheConfig
+	Storage     StorageConfig
+	Metrics     MetricsConfig
+	Tracing     TracingConfig
+	Health      HealthConfig
+	RateLimit   RateLimitConfig
+	Idempotency IdempotencyConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port          string
+	Env           string
+	ShutdownDelay time.Duration // how long readiness fails before connections are closed on shutdown
+	// RequestTimeout bounds ordinary requests; movie imports and exports get
+	// BulkRequestTimeout instead
+	RequestTimeout     time.Duration
+	BulkRequestTimeout time.Duration
+	// TrustedProxies are the networks whose X-Forwarded-For and X-Real-IP
+	// headers are believed; other peers are identified by their own address
+	TrustedProxies []netip.Prefix
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration // per readiness check
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host        string
+	Port        string
+	User        string
+	Password    string `secret:"true"`
+	DBName      string
+	SSLMode     string
+	AutoMigrate bool // apply pending migrations when the server starts
 }
 
 type RedisConfig struct {
 	Host     string
 	Port     string
-	Password string
+	Password string `secret:"true"`
 	DB       int
 }
 
 type JWTConfig struct {
-	Secret     string
+	Secret     string `secret:"true"`
 	Expiration time.Duration
 }
 
+type TrashConfig struct {
+	Retention     time.Duration // how long soft-deleted movies are kept; 0 disables purging
+	PurgeInterval time.Duration
+}
+
+type MoviesConfig struct {
+	RequireIfMatch bool // reject movie writes that don't send an If-Match header
+}
+
+type BlobStoreConfig struct {
+	Driver      string // "local" or "s3"
+	LocalDir    string
+	PublicURL   string // base URL blobs are served from; derived from the driver when empty
+	S3Endpoint  string
+	S3AccessKey string `secret:"true"`
+	S3SecretKey string `secret:"true"`
+	S3Bucket    string
+	S3Region    string
+	S3UseSSL    bool
+}
+
+type ImageConfig struct {
+	MaxUploadSize int64 // in bytes
+}
+
+type CacheConfig struct {
+	Enabled  bool          // cache movie reads in Redis
+	MovieTTL time.Duration // single movies
+	ListTTL  time.Duration // movie list pages; also bounds how late a scheduled publish shows up
+}
+
+type StorageConfig struct {
+	Driver string // "postgres", or "memory" to run without Postgres and Redis
+}
+
+type TracingConfig struct {
+	Exporter     string // "none", "stdout" or "otlp"
+	ServiceName  string
+	OTLPEndpoint string // host:port of the OTLP/HTTP collector
+	OTLPInsecure bool   // send to the collector over plain HTTP
+	SampleRatio  float64
+}
+
+type RateLimitConfig struct {
+	Enabled bool
+	// APIKeys are the keys clients may send in X-API-Key to be limited on
+	// their own rather than by IP; other keys are ignored
+	APIKeys []string `secret:"true"`
+
+	// Limits of the route groups; each group counts separately
+	Auth   RateLimit
+	Users  RateLimit
+	Movies RateLimit
+	Genres RateLimit
+	Lists  RateLimit
+	People RateLimit
+}
+
+// RateLimit allows Requests per Period, written as "10/1m"
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l RateLimit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+type IdempotencyConfig struct {
+	TTL         time.Duration // how long responses are kept for replay
+	LockTimeout time.Duration // how long a key stays locked by a request that never finishes
+	WaitTimeout time.Duration // how long a duplicate waits for the request in flight
+	MaxBodySize int64         // largest request body read for fingerprinting, in bytes
+}
+
+type MetricsConfig struct {
+	Enabled bool
+	Port    string // admin port /metrics is served on, apart from the API
+}
+
 // Load returns a new Config struct populated with values from environment variables
 func Load() (*Config, error) {
 	err := godotenv.Load()
@@ -56,18 +162,153 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid JWT_EXPIRATION format: %w", err)
 	}
 
+	trashRetention, err := time.ParseDuration(getEnv("TRASH_RETENTION", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRASH_RETENTION format: %w", err)
+	}
+
+	trashPurgeInterval, err := time.ParseDuration(getEnv("TRASH_PURGE_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRASH_PURGE_INTERVAL format: %w", err)
+	}
+
+	autoMigrate, err := strconv.ParseBool(getEnv("DB_AUTO_MIGRATE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_AUTO_MIGRATE format: %w", err)
+	}
+
+	requireIfMatch, err := strconv.ParseBool(getEnv("MOVIES_REQUIRE_IF_MATCH", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MOVIES_REQUIRE_IF_MATCH format: %w", err)
+	}
+
+	s3UseSSL, err := strconv.ParseBool(getEnv("S3_USE_SSL", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3_USE_SSL format: %w", err)
+	}
+
+	imageMaxUploadSize, err := strconv.ParseInt(getEnv("IMAGE_MAX_UPLOAD_SIZE", "10485760"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IMAGE_MAX_UPLOAD_SIZE format: %w", err)
+	}
+
+	cacheEnabled, err := strconv.ParseBool(getEnv("CACHE_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_ENABLED format: %w", err)
+	}
+
+	cacheMovieTTL, err := time.ParseDuration(getEnv("CACHE_MOVIE_TTL", "5m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_MOVIE_TTL format: %w", err)
+	}
+
+	cacheListTTL, err := time.ParseDuration(getEnv("CACHE_LIST_TTL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_LIST_TTL format: %w", err)
+	}
+
+	metricsEnabled, err := strconv.ParseBool(getEnv("METRICS_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid METRICS_ENABLED format: %w", err)
+	}
+
+	otlpInsecure, err := strconv.ParseBool(getEnv("TRACING_OTLP_INSECURE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRACING_OTLP_INSECURE format: %w", err)
+	}
+
+	tracingSampleRatio, err := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRACING_SAMPLE_RATIO format: %w", err)
+	}
+
+	shutdownDelay, err := time.ParseDuration(getEnv("SHUTDOWN_DELAY", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHUTDOWN_DELAY format: %w", err)
+	}
+
+	requestTimeout, err := time.ParseDuration(getEnv("REQUEST_TIMEOUT", "60s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT format: %w", err)
+	}
+
+	bulkRequestTimeout, err := time.ParseDuration(getEnv("BULK_REQUEST_TIMEOUT", "30m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BULK_REQUEST_TIMEOUT format: %w", err)
+	}
+
+	trustedProxies := []netip.Prefix{}
+	for _, cidr := range splitList(getEnv("TRUSTED_PROXIES", "")) {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES format: %w", err)
+		}
+		trustedProxies = append(trustedProxies, prefix.Masked())
+	}
+
+	healthCheckTimeout, err := time.ParseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEALTH_CHECK_TIMEOUT format: %w", err)
+	}
+
+	rateLimitEnabled, err := strconv.ParseBool(getEnv("RATE_LIMIT_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_ENABLED format: %w", err)
+	}
+
+	rateLimits := map[string]*RateLimit{}
+	for _, group := range []struct{ env, fallback string }{
+		{"RATE_LIMIT_AUTH", "10/1m"},
+		{"RATE_LIMIT_USERS", "120/1m"},
+		{"RATE_LIMIT_MOVIES", "300/1m"},
+		{"RATE_LIMIT_GENRES", "300/1m"},
+		{"RATE_LIMIT_LISTS", "300/1m"},
+		{"RATE_LIMIT_PEOPLE", "300/1m"},
+	} {
+		limit, err := parseRateLimit(getEnv(group.env, group.fallback))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s format: %w", group.env, err)
+		}
+		rateLimits[group.env] = limit
+	}
+
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_TTL format: %w", err)
+	}
+
+	idempotencyLockTimeout, err := time.ParseDuration(getEnv("IDEMPOTENCY_LOCK_TIMEOUT", "90s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_LOCK_TIMEOUT format: %w", err)
+	}
+
+	idempotencyWaitTimeout, err := time.ParseDuration(getEnv("IDEMPOTENCY_WAIT_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_WAIT_TIMEOUT format: %w", err)
+	}
+
+	idempotencyMaxBodySize, err := strconv.ParseInt(getEnv("IDEMPOTENCY_MAX_BODY_SIZE", "1048576"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_MAX_BODY_SIZE format: %w", err)
+	}
+
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:               getEnv("PORT", "8080"),
+			Env:                getEnv("ENV", "development"),
+			ShutdownDelay:      shutdownDelay,
+			RequestTimeout:     requestTimeout,
+			BulkRequestTimeout: bulkRequestTimeout,
+			TrustedProxies:     trustedProxies,
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "go_api_db"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:        getEnv("DB_HOST", "localhost"),
+			Port:        getEnv("DB_PORT", "5432"),
+			User:        getEnv("DB_USER", "postgres"),
+			Password:    getEnv("DB_PASSWORD", "postgres"),
+			DBName:      getEnv("DB_NAME", "go_api_db"),
+			SSLMode:     getEnv("DB_SSLMODE", "disable"),
+			AutoMigrate: autoMigrate,
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
@@ -79,6 +320,65 @@ func Load() (*Config, error) {
 			Secret:     getEnv("JWT_SECRET", "default_secret_key"),
 			Expiration: jwtExp,
 		},
+		Trash: TrashConfig{
+			Retention:     trashRetention,
+			PurgeInterval: trashPurgeInterval,
+		},
+		Movies: MoviesConfig{
+			RequireIfMatch: requireIfMatch,
+		},
+		BlobStore: BlobStoreConfig{
+			Driver:      getEnv("BLOB_STORE_DRIVER", "local"),
+			LocalDir:    getEnv("BLOB_STORE_LOCAL_DIR", "./uploads"),
+			PublicURL:   getEnv("BLOB_STORE_PUBLIC_URL", ""),
+			S3Endpoint:  getEnv("S3_ENDPOINT", "localhost:9000"),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3Bucket:    getEnv("S3_BUCKET", "go-service"),
+			S3Region:    getEnv("S3_REGION", ""),
+			S3UseSSL:    s3UseSSL,
+		},
+		Images: ImageConfig{
+			MaxUploadSize: imageMaxUploadSize,
+		},
+		Cache: CacheConfig{
+			Enabled:  cacheEnabled,
+			MovieTTL: cacheMovieTTL,
+			ListTTL:  cacheListTTL,
+		},
+		Storage: StorageConfig{
+			Driver: getEnv("STORAGE", "postgres"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: metricsEnabled,
+			Port:    getEnv("METRICS_PORT", "9090"),
+		},
+		Tracing: TracingConfig{
+			Exporter:     getEnv("TRACING_EXPORTER", "none"),
+			ServiceName:  getEnv("TRACING_SERVICE_NAME", "go-service"),
+			OTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", "localhost:4318"),
+			OTLPInsecure: otlpInsecure,
+			SampleRatio:  tracingSampleRatio,
+		},
+		Health: HealthConfig{
+			CheckTimeout: healthCheckTimeout,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: rateLimitEnabled,
+			APIKeys: splitList(getEnv("RATE_LIMIT_API_KEYS", "")),
+			Auth:    *rateLimits["RATE_LIMIT_AUTH"],
+			Users:   *rateLimits["RATE_LIMIT_USERS"],
+			Movies:  *rateLimits["RATE_LIMIT_MOVIES"],
+			Genres:  *rateLimits["RATE_LIMIT_GENRES"],
+			Lists:   *rateLimits["RATE_LIMIT_LISTS"],
+			People:  *rateLimits["RATE_LIMIT_PEOPLE"],
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:         idempotencyTTL,
+			LockTimeout: idempotencyLockTimeout,
+			WaitTimeout: idempotencyWaitTimeout,
+			MaxBodySize: idempotencyMaxBodySize,
+		},
 	}, nil
 }
 
@@ -100,3 +400,33 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// parseRateLimit parses a limit written as "<requests>/<period>", like "10/1m"
+func parseRateLimit(value string) (*RateLimit, error) {
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return nil, fmt.Errorf("%q is not <requests>/<period>", value)
+	}
+
+	limit := &RateLimit{}
+	var err error
+	if limit.Requests, err = strconv.Atoi(requests); err != nil || limit.Requests <= 0 {
+		return nil, fmt.Errorf("%q does not allow a positive number of requests", value)
+	}
+	if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 {
+		return nil, fmt.Errorf("%q does not have a positive period", value)
+	}
+
+	return limit, nil
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// redacted replaces the value of secret settings
+const redacted = "[REDACTED]"
+
+// Setting is one effective configuration value
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// Settings lists every configuration value as "Section.Field", in
+// declaration order. Fields tagged secret:"true" are redacted when set.
+func (c *Config) Settings() []Setting {
+	settings := []Setting{}
+
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		sectionName := sections.Type().Field(i).Name
+
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			value := fmt.Sprint(section.Field(j).Interface())
+			if field.Tag.Get("secret") == "true" && value != "" {
+				value = redacted
+			}
+			settings = append(settings, Setting{Name: sectionName + "." + field.Name, Value: value})
+		}
+	}
+
+	return settings
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"hash/crc32"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// movieETag returns the strong entity tag for a movie's current version
+func movieETag(movie *models.Movie) string {
+	return fmt.Sprintf(`"%d"`, movie.Version)
+}
+
+// readMovieETag returns the entity tag for a movie as it is read. The version
+// only covers the editorial fields, so the review aggregates and the caller's
+// user status are mixed in after it. The tag changes when any of them does
+// and still works as an If-Match value.
+func readMovieETag(movie *models.Movie) string {
+	extra, _ := json.Marshal(struct {
+		AverageRating float64                 `json:"average_rating"`
+		RatingCount   int                     `json:"rating_count"`
+		UserStatus    *models.MovieUserStatus `json:"user_status"`
+	}{movie.AverageRating, movie.RatingCount, movie.UserStatus})
+	return fmt.Sprintf(`"%d.%08x"`, movie.Version, crc32.ChecksumIEEE(extra))
+}
+
+// setMovieETag writes the ETag header for a movie response
+func setMovieETag(w http.ResponseWriter, movie *models.Movie) {
+	w.Header().Set("ETag", movieETag(movie))
+}
+
+// parseIfMatch reads the If-Match header into the list of acceptable movie
+// versions. A missing header or "*" accepts any version, unless required is
+// set, in which case a missing header gets a 428 response. A header that
+// lists no usable strong tag can never match and gets a 412 response.
+func parseIfMatch(w http.ResponseWriter, r *http.Request, required bool) ([]int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		if required {
+			response.ErrorResponse(w, http.StatusPreconditionRequired, "If-Match header required")
+			return nil, false
+		}
+		return nil, true
+	}
+
+	if header == "*" {
+		return nil, true
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		// Weak tags never match under the strong comparison If-Match requires
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+			continue
+		}
+		// Only the version counts, not what readMovieETag mixes in
+		value, _, _ := strings.Cut(tag[1:len(tag)-1], ".")
+		version, err := strconv.Atoi(value)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	if len(versions) == 0 {
+		response.ErrorResponse(w, http.StatusPreconditionFailed, "Movie has been modified")
+		return nil, false
+	}
+
+	return versions, true
+}
+
+// containsVersion reports whether version is one of the If-Match versions
+func containsVersion(versions []int, version int) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches etag, using
+// the weak comparison GET requests call for
+func ifNoneMatch(r *http.Request, etag string) bool {
+	header := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if header == "" {
+		return false
+	}
+
+	if header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type GenreHandler struct {
+	genreRepo *repository.GenreRepository
+}
+
+func NewGenreHandler(genreRepo *repository.GenreRepository) *GenreHandler {
+	return &GenreHandler{
+		genreRepo: genreRepo,
+	}
+}
+
+func (h *GenreHandler) CreateGenre(w http.ResponseWriter, r *http.Request) {
+	var input models.CreateGenreInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"name": "is required",
+		})
+		return
+	}
+
+	slug := input.Slug
+	if slug == "" {
+		slug = input.Name
+	}
+	if models.Slugify(slug) == "" {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"slug": "must contain letters or digits",
+		})
+		return
+	}
+
+	genre, err := h.genreRepo.Create(r.Context(), &input)
+	if err != nil {
+		if errors.Is(err, repository.ErrGenreSlugExists) {
+			logger.ErrorContext(r.Context(), "Genre slug already exists", logger.Field("name", input.Name))
+			response.ErrorResponse(w, http.StatusConflict, "Genre already exists")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error creating genre", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error creating genre")
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Genre created", logger.Field("genre_id", genre.ID), logger.Field("slug", genre.Slug))
+	response.SuccessResponse(w, http.StatusCreated, "Genre created successfully", genre)
+}
+
+func (h *GenreHandler) GetGenre(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid genre ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid genre ID")
+		return
+	}
+
+	genre, err := h.genreRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrGenreNotFound) {
+			logger.ErrorContext(r.Context(), "Genre not found", logger.Field("genre_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Genre not found")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error getting genre", logger.Field("error", err), logger.Field("genre_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error getting genre")
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Genre retrieved successfully", genre)
+}
+
+func (h *GenreHandler) UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid genre ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid genre ID")
+		return
+	}
+
+	var input models.UpdateGenreInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Name != nil && strings.TrimSpace(*input.Name) == "" {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"name": "must not be empty",
+		})
+		return
+	}
+
+	if input.Slug != nil && models.Slugify(*input.Slug) == "" {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"slug": "must contain letters or digits",
+		})
+		return
+	}
+
+	genre, err := h.genreRepo.Update(r.Context(), id, &input)
+	if err != nil {
+		if errors.Is(err, repository.ErrGenreNotFound) {
+			logger.ErrorContext(r.Context(), "Genre not found", logger.Field("genre_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Genre not found")
+			return
+		}
+		if errors.Is(err, repository.ErrGenreSlugExists) {
+			logger.ErrorContext(r.Context(), "Genre slug already exists", logger.Field("genre_id", id))
+			response.ErrorResponse(w, http.StatusConflict, "Genre already exists")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error updating genre", logger.Field("error", err), logger.Field("genre_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error updating genre")
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Genre updated", logger.Field("genre_id", genre.ID), logger.Field("slug", genre.Slug))
+	response.SuccessResponse(w, http.StatusOK, "Genre updated successfully", genre)
+}
+
+func (h *GenreHandler) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid genre ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid genre ID")
+		return
+	}
+
+	err = h.genreRepo.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrGenreNotFound) {
+			logger.ErrorContext(r.Context(), "Genre not found", logger.Field("genre_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Genre not found")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error deleting genre", logger.Field("error", err), logger.Field("genre_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error deleting genre")
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Genre deleted", logger.Field("genre_id", id))
+	response.SuccessResponse(w, http.StatusOK, "Genre deleted successfully", nil)
+}
+
+func (h *GenreHandler) ListGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := h.genreRepo.List(r.Context())
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Error listing genres", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error listing genres")
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Genres retrieved successfully", genres)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/marchelhutagalung/go-service/internal/health"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Livez reports that the process is up and serving. It checks no
+// dependencies, so an outage of one does not get every instance restarted.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	response.SuccessResponse(w, http.StatusOK, "Service is alive", nil)
+}
+
+// Readyz runs the dependency checks and answers 503 while the service should
+// not get traffic: a critical dependency is down or it is shutting down
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Check(r.Context())
+
+	switch {
+	case report.ShuttingDown:
+		response.ErrorDataResponse(w, http.StatusServiceUnavailable, "Service is shutting down", report)
+	case report.Status == health.StatusDown:
+		logger.WarnContext(r.Context(), "Readiness check failed", logger.Field("checks", report.Checks))
+		response.ErrorDataResponse(w, http.StatusServiceUnavailable, "Service is not ready", report)
+	case report.Status == health.StatusDegraded:
+		logger.WarnContext(r.Context(), "Service is degraded", logger.Field("checks", report.Checks))
+		response.SuccessResponse(w, http.StatusOK, "Service is degraded", report)
+	default:
+		response.SuccessResponse(w, http.StatusOK, "Service is ready", report)
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+	"strconv"
+)
+
+type PaginatedDuplicateResponse struct {
+	Duplicates []*models.DuplicateCandidate `json:"duplicates"`
+	TotalCount int                          `json:"total_count"`
+	Page       int                          `json:"page"`
+	PageSize   int                          `json:"page_size"`
+	TotalPages int                          `json:"total_pages"`
+}
+
+// ListDuplicates reports pairs of movies that are likely duplicates. The
+// min_similarity parameter sets the title similarity threshold.
+func (h *MovieHandler) ListDuplicates(w http.ResponseWriter, r *http.Request) {
+	minSimilarity := models.DefaultDuplicateSimilarity
+	if v := r.URL.Query().Get("min_similarity"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < models.MinDuplicateSimilarity || parsed > 1 {
+			response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+				"min_similarity": fmt.Sprintf("must be between %g and 1", models.MinDuplicateSimilarity),
+			})
+			return
+		}
+		minSimilarity = parsed
+	}
+
+	page, pageSize := parsePagination(r)
+
+	duplicates, totalCount, err := h.movieRepo.FindDuplicates(r.Context(), minSimilarity, page, pageSize)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Error finding duplicate movies", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error finding duplicate movies")
+		return
+	}
+
+	responseData := PaginatedDuplicateResponse{
+		Duplicates: duplicates,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages(totalCount, pageSize),
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Duplicate candidates retrieved successfully", responseData)
+}
+
+// MergeMovie merges the movie named by duplicate_id into the movie in the URL,
+// which survives
+func (h *MovieHandler) MergeMovie(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.MergeMovieInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.DuplicateID <= 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"duplicate_id": "is required",
+		})
+		return
+	}
+
+	result, err := h.movieRepo.Merge(r.Context(), id, input.DuplicateID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMergeSameMovie):
+			response.ErrorResponse(w, http.StatusBadRequest, "Cannot merge a movie into itself")
+		case errors.Is(err, repository.ErrMovieNotFound):
+			response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+		default:
+			logger.ErrorContext(r.Context(), "Error merging movies", logger.Field("error", err), logger.Field("movie_id", id), logger.Field("duplicate_id", input.DuplicateID))
+			response.ErrorResponse(w, http.StatusInternalServerError, "Error merging movies")
+		}
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Movies merged",
+		logger.Field("movie_id", id),
+		logger.Field("duplicate_id", input.DuplicateID),
+		logger.Field("moved_reviews", result.MovedReviews),
+		logger.Field("moved_list_items", result.MovedListItems),
+		logger.Field("moved_credits", result.MovedCredits),
+	)
+	setMovieETag(w, result.Movie)
+	response.SuccessResponse(w, http.StatusOK, "Movies merged successfully", result)
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportFlushRows is how many rows are written between flushes to the client
+const exportFlushRows = 100
+
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"json":   "application/json",
+}
+
+// ExportMovies streams every movie matching the list filters as CSV, NDJSON
+// or a JSON array. The response is gzip-encoded when the client accepts it.
+func (h *MovieHandler) ExportMovies(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"format": "must be one of csv, ndjson, json",
+		})
+		return
+	}
+
+	query := parseMovieQuery(r)
+	query.IncludeUnpublished = true
+
+	// Headers are written with the first row so that errors before any
+	// output still get a normal error response
+	var out io.Writer = w
+	var encoder movieEncoder
+	var gz *gzip.Writer
+	count := 0
+
+	flush := func() error {
+		if err := encoder.flush(); err != nil {
+			return err
+		}
+		if gz != nil {
+			if err := gz.Flush(); err != nil {
+				return err
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	start := func() error {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="movies.`+format+`"`)
+		w.Header().Set("Vary", "Accept-Encoding")
+		if acceptsGzip(r) {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz = gzip.NewWriter(w)
+			out = gz
+		}
+		w.WriteHeader(http.StatusOK)
+
+		encoder = newMovieEncoder(format, out)
+		return encoder.begin()
+	}
+
+	err := h.movieRepo.Export(r.Context(), query, func(movie *models.Movie) error {
+		if encoder == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := encoder.write(movie); err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushRows == 0 {
+			return flush()
+		}
+		return nil
+	})
+
+	if err != nil && r.Context().Err() != nil {
+		// The client went away or the request timed out; the timeout
+		// middleware answers if nothing was sent yet
+		logger.WarnContext(r.Context(), "Movie export cancelled", logger.Field("error", err), logger.Field("rows", count))
+		return
+	}
+
+	if err != nil {
+		if encoder == nil {
+			logger.ErrorContext(r.Context(), "Error exporting movies", logger.Field("error", err))
+			response.ErrorResponse(w, http.StatusInternalServerError, "Error exporting movies")
+			return
+		}
+		// The status line is already sent; stop writing so the client sees
+		// a truncated body
+		logger.ErrorContext(r.Context(), "Movie export interrupted", logger.Field("error", err), logger.Field("rows", count))
+		return
+	}
+
+	if encoder == nil {
+		if err := start(); err != nil {
+			logger.ErrorContext(r.Context(), "Error exporting movies", logger.Field("error", err))
+			return
+		}
+	}
+
+	if err := encoder.end(); err != nil {
+		logger.ErrorContext(r.Context(), "Movie export interrupted", logger.Field("error", err), logger.Field("rows", count))
+		return
+	}
+	if err := flush(); err != nil {
+		logger.ErrorContext(r.Context(), "Movie export interrupted", logger.Field("error", err), logger.Field("rows", count))
+		return
+	}
+	if gz != nil {
+		gz.Close()
+	}
+
+	logger.InfoContext(r.Context(), "Movies exported", logger.Field("format", format), logger.Field("rows", count))
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(coding) != "gzip" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// movieEncoder writes movies in one export format
+type movieEncoder interface {
+	begin() error
+	write(movie *models.Movie) error
+	end() error
+	flush() error
+}
+
+func newMovieEncoder(format string, w io.Writer) movieEncoder {
+	switch format {
+	case "csv":
+		return &csvMovieEncoder{w: csv.NewWriter(w)}
+	case "ndjson":
+		return &ndjsonMovieEncoder{enc: json.NewEncoder(w)}
+	}
+	return &jsonMovieEncoder{w: w, enc: json.NewEncoder(w)}
+}
+
+// csvMovieEncoder writes genres as "|"-separated slugs and credits as a JSON
+// array, matching the import format
+type csvMovieEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvMovieEncoder) begin() error {
+	return e.w.Write([]string{
+		"id", "title", "description", "release_date", "rating", "average_rating",
+		"rating_count", "duration", "genres", "credits", "created_at", "updated_at",
+	})
+}
+
+func (e *csvMovieEncoder) write(movie *models.Movie) error {
+	genres := make([]string, 0, len(movie.Genres))
+	for _, genre := range movie.Genres {
+		genres = append(genres, genre.Slug)
+	}
+
+	credits, err := json.Marshal(models.NewMovieSnapshot(movie).Credits)
+	if err != nil {
+		return err
+	}
+
+	return e.w.Write([]string{
+		strconv.FormatInt(movie.ID, 10),
+		movie.Title,
+		movie.Description,
+		movie.ReleaseDate.Format("2006-01-02"),
+		strconv.FormatFloat(movie.Rating, 'f', -1, 64),
+		strconv.FormatFloat(movie.AverageRating, 'f', -1, 64),
+		strconv.Itoa(movie.RatingCount),
+		strconv.Itoa(movie.Duration),
+		strings.Join(genres, "|"),
+		string(credits),
+		movie.CreatedAt.Format(time.RFC3339),
+		movie.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (e *csvMovieEncoder) end() error {
+	return nil
+}
+
+func (e *csvMovieEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonMovieEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonMovieEncoder) begin() error {
+	return nil
+}
+
+func (e *ndjsonMovieEncoder) write(movie *models.Movie) error {
+	return e.enc.Encode(movie)
+}
+
+func (e *ndjsonMovieEncoder) end() error {
+	return nil
+}
+
+func (e *ndjsonMovieEncoder) flush() error {
+	return nil
+}
+
+// jsonMovieEncoder writes a single JSON array, one movie per line
+type jsonMovieEncoder struct {
+	w       io.Writer
+	enc     *json.Encoder
+	written bool
+}
+
+func (e *jsonMovieEncoder) begin() error {
+	_, err := io.WriteString(e.w, "[\n")
+	return err
+}
+
+func (e *jsonMovieEncoder) write(movie *models.Movie) error {
+	if e.written {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.written = true
+	return e.enc.Encode(movie)
+}
+
+func (e *jsonMovieEncoder) end() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+func (e *jsonMovieEncoder) flush() error {
+	return nil
+}
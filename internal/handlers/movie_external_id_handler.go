@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetMovieByExternalID looks a movie up by its identifier in an external catalogue
+func (h *MovieHandler) GetMovieByExternalID(w http.ResponseWriter, r *http.Request) {
+	source, externalID, ok := parseExternalID(w, r)
+	if !ok {
+		return
+	}
+
+	movie, err := h.movieRepo.GetByExternalID(r.Context(), source, externalID)
+	if err != nil {
+		if errors.Is(err, repository.ErrMovieNotFound) {
+			response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error getting movie by external ID", logger.Field("error", err), logger.Field("source", source))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error getting movie")
+		return
+	}
+
+	if !movie.IsPublic(time.Now()) && !canSeeUnpublished(r) {
+		response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+		return
+	}
+
+	etag := readMovieETag(movie)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Movie retrieved successfully", movie)
+}
+
+// UpsertMovieByExternalID replaces the movie linked to an external
+// identifier, creating and linking it if there is none. Repeating the same
+// request leaves a single movie.
+func (h *MovieHandler) UpsertMovieByExternalID(w http.ResponseWriter, r *http.Request) {
+	source, externalID, ok := parseExternalID(w, r)
+	if !ok {
+		return
+	}
+
+	ifMatch, ok := parseIfMatch(w, r, false)
+	if !ok {
+		return
+	}
+
+	var input models.ReplaceMovieInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := input.Validate(); len(errs) > 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
+	movie, created, err := h.movieRepo.UpsertByExternalID(r.Context(), source, externalID, &input, ifMatch)
+	if err != nil {
+		if errors.Is(err, repository.ErrMovieNotFound) {
+			// The linked movie is in the trash
+			response.ErrorResponse(w, http.StatusConflict, "Movie linked to this external ID is in the trash")
+			return
+		}
+		h.writeUpdateResult(w, r, 0, nil, err)
+		return
+	}
+
+	setMovieETag(w, movie)
+	if created {
+		logger.InfoContext(r.Context(), "Movie created from external ID", logger.Field("movie_id", movie.ID), logger.Field("source", source))
+		response.SuccessResponse(w, http.StatusCreated, "Movie created successfully", movie)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Movie updated from external ID", logger.Field("movie_id", movie.ID), logger.Field("source", source))
+	response.SuccessResponse(w, http.StatusOK, "Movie updated successfully", movie)
+}
+
+// parseExternalID reads the {source} and {externalID} URL parameters,
+// writing a 400 response if they are invalid
+func parseExternalID(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	source, ok := models.NormalizeExternalSource(chi.URLParam(r, "source"))
+	if !ok {
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid external source")
+		return "", "", false
+	}
+
+	externalID := strings.TrimSpace(chi.URLParam(r, "externalID"))
+	if externalID == "" || len(externalID) > 255 {
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid external ID")
+		return "", "", false
+	}
+
+	return source, externalID, true
+}
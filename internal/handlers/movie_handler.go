@@ -1,25 +1,46 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/jsonpatch"
 	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/middleware"
 	"github.com/marchelhutagalung/go-service/internal/models"
 	"github.com/marchelhutagalung/go-service/internal/repository"
 	"github.com/marchelhutagalung/go-service/internal/response"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
+// Patch media types accepted by PatchMovie
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// maxPatchAttempts bounds how often an unconditional patch is reapplied after
+// losing a race with another write
+const maxPatchAttempts = 3
+
 type MovieHandler struct {
-	movieRepo *repository.MovieRepository
+	movieRepo      repository.MovieStore
+	savedMovieRepo *repository.SavedMovieRepository // nil when watchlists are not available
+	config         *config.MoviesConfig
 }
 
-func NewMovieHandler(movieRepo *repository.MovieRepository) *MovieHandler {
+func NewMovieHandler(movieRepo repository.MovieStore, savedMovieRepo *repository.SavedMovieRepository, config *config.MoviesConfig) *MovieHandler {
 	return &MovieHandler{
-		movieRepo: movieRepo,
+		movieRepo:      movieRepo,
+		savedMovieRepo: savedMovieRepo,
+		config:         config,
 	}
 }
 
@@ -34,19 +55,35 @@ type PaginatedMovieResponse struct {
 func (h *MovieHandler) CreateMovie(w http.ResponseWriter, r *http.Request) {
 	var input models.CreateMovieInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		logger.Error("Invalid request body", logger.Field("error", err))
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
 		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
+	if errs := models.ValidateCredits(input.Credits); len(errs) > 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
 	movie, err := h.movieRepo.Create(r.Context(), &input)
 	if err != nil {
-		logger.Error("Error creating movie", logger.Field("error", err))
+		if errors.Is(err, repository.ErrGenreNotFound) {
+			logger.ErrorContext(r.Context(), "Unknown genre", logger.Field("genres", input.Genres))
+			response.ErrorResponse(w, http.StatusBadRequest, "Unknown genre")
+			return
+		}
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			logger.ErrorContext(r.Context(), "Unknown person in credits")
+			response.ErrorResponse(w, http.StatusBadRequest, "Unknown person in credits")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error creating movie", logger.Field("error", err))
 		response.ErrorResponse(w, http.StatusInternalServerError, "Error creating movie")
 		return
 	}
 
-	logger.Info("Movie created", logger.Field("movie_id", movie.ID), logger.Field("title", movie.Title))
+	logger.InfoContext(r.Context(), "Movie created", logger.Field("movie_id", movie.ID), logger.Field("title", movie.Title))
+	setMovieETag(w, movie)
 	response.SuccessResponse(w, http.StatusCreated, "Movie created successfully", movie)
 }
 
@@ -54,7 +91,7 @@ func (h *MovieHandler) GetMovie(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		logger.Error("Invalid movie ID", logger.Field("id", idStr), logger.Field("error", err))
+		logger.ErrorContext(r.Context(), "Invalid movie ID", logger.Field("id", idStr), logger.Field("error", err))
 		response.ErrorResponse(w, http.StatusBadRequest, "Invalid movie ID")
 		return
 	}
@@ -62,48 +99,191 @@ func (h *MovieHandler) GetMovie(w http.ResponseWriter, r *http.Request) {
 	movie, err := h.movieRepo.GetByID(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, repository.ErrMovieNotFound) {
-			logger.Error("Movie not found", logger.Field("movie_id", id))
+			logger.ErrorContext(r.Context(), "Movie not found", logger.Field("movie_id", id))
 			response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
 			return
 		}
-		logger.Error("Error getting movie", logger.Field("error", err), logger.Field("movie_id", id))
+		logger.ErrorContext(r.Context(), "Error getting movie", logger.Field("error", err), logger.Field("movie_id", id))
 		response.ErrorResponse(w, http.StatusInternalServerError, "Error getting movie")
 		return
 	}
 
-	logger.Info("Movie retrieved", logger.Field("movie_id", movie.ID), logger.Field("title", movie.Title))
+	if !movie.IsPublic(time.Now()) && !canSeeUnpublished(r) {
+		response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+		return
+	}
+
+	// The user status varies with the caller, so it is part of their ETag
+	if userID, ok := middleware.GetUserID(r.Context()); ok && h.savedMovieRepo != nil {
+		status, err := h.savedMovieRepo.Status(r.Context(), userID, movie.ID)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "Error getting movie user status", logger.Field("error", err), logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusInternalServerError, "Error getting movie")
+			return
+		}
+		movie.UserStatus = status
+	}
+
+	etag := readMovieETag(movie)
+	w.Header().Set("Vary", "Authorization")
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Movie retrieved", logger.Field("movie_id", movie.ID), logger.Field("title", movie.Title))
 	response.SuccessResponse(w, http.StatusOK, "Movie retrieved successfully", movie)
 }
 
+// UpdateMovie replaces a movie. Every field is written: required fields must
+// be present and optional fields that are left out are cleared.
 func (h *MovieHandler) UpdateMovie(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		logger.Error("Invalid movie ID", logger.Field("id", idStr), logger.Field("error", err))
-		response.ErrorResponse(w, http.StatusBadRequest, "Invalid movie ID")
+	id, ok := parseMovieID(w, r)
+	if !ok {
 		return
 	}
 
-	var input models.UpdateMovieInput
+	ifMatch, ok := parseIfMatch(w, r, h.config.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	var input models.ReplaceMovieInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		logger.Error("Invalid request body", logger.Field("error", err))
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
 		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	movie, err := h.movieRepo.Update(r.Context(), id, &input)
+	if errs := input.Validate(); len(errs) > 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
+	movie, err := h.movieRepo.Update(r.Context(), id, input.UpdateInput(), ifMatch)
+	h.writeUpdateResult(w, r, id, movie, err)
+}
+
+// PatchMovie applies a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902)
+// document to a movie. The patch is applied to the movie's editable fields
+// and the result must be a valid replacement.
+func (h *MovieHandler) PatchMovie(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	ifMatch, ok := parseIfMatch(w, r, h.config.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case mergePatchContentType:
+		apply = jsonpatch.MergePatch
+	case jsonPatchContentType:
+		apply = jsonpatch.Apply
+	default:
+		w.Header().Set("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		response.ErrorResponse(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergePatchContentType+" or "+jsonPatchContentType)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
 	if err != nil {
-		if errors.Is(err, repository.ErrMovieNotFound) {
-			logger.Error("Movie not found", logger.Field("movie_id", id))
-			response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Without an If-Match from the client the patch is applied to the version
+	// read here, and reapplied if another write lands in between
+	for attempt := 1; ; attempt++ {
+		current, err := h.movieRepo.GetByID(r.Context(), id)
+		if err != nil {
+			h.writeUpdateResult(w, r, id, nil, err)
 			return
 		}
-		logger.Error("Error updating movie", logger.Field("error", err), logger.Field("movie_id", id))
-		response.ErrorResponse(w, http.StatusInternalServerError, "Error updating movie")
+
+		if len(ifMatch) > 0 && !containsVersion(ifMatch, current.Version) {
+			h.writeUpdateResult(w, r, id, nil, repository.ErrMovieVersionMismatch)
+			return
+		}
+
+		// The workflow state is changed through its own endpoints, not patched
+		snapshot := models.NewMovieSnapshot(current)
+		snapshot.Status, snapshot.PublishAt = "", nil
+
+		doc, err := json.Marshal(snapshot)
+		if err != nil {
+			h.writeUpdateResult(w, r, id, nil, err)
+			return
+		}
+
+		patched, err := apply(doc, patch)
+		if err != nil {
+			switch {
+			case errors.Is(err, jsonpatch.ErrTestFailed):
+				response.ErrorResponse(w, http.StatusConflict, err.Error())
+			case errors.Is(err, jsonpatch.ErrPathNotFound):
+				response.ErrorResponse(w, http.StatusUnprocessableEntity, err.Error())
+			default:
+				response.ErrorResponse(w, http.StatusBadRequest, "Invalid patch document")
+			}
+			return
+		}
+
+		var input models.ReplaceMovieInput
+		decoder := json.NewDecoder(bytes.NewReader(patched))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&input); err != nil {
+			response.ErrorResponse(w, http.StatusUnprocessableEntity, "Patched movie is invalid: "+err.Error())
+			return
+		}
+
+		if errs := input.Validate(); len(errs) > 0 {
+			response.ValidationErrorResponse(w, http.StatusUnprocessableEntity, "Validation failed", errs)
+			return
+		}
+
+		movie, err := h.movieRepo.Update(r.Context(), id, input.UpdateInput(), []int{current.Version})
+		if errors.Is(err, repository.ErrMovieVersionMismatch) && len(ifMatch) == 0 && attempt < maxPatchAttempts {
+			continue
+		}
+
+		h.writeUpdateResult(w, r, id, movie, err)
+		return
+	}
+}
+
+// writeUpdateResult writes the response for a movie replacement or patch
+func (h *MovieHandler) writeUpdateResult(w http.ResponseWriter, r *http.Request, id int64, movie *models.Movie, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMovieNotFound):
+			logger.ErrorContext(r.Context(), "Movie not found", logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+		case errors.Is(err, repository.ErrMovieVersionMismatch):
+			logger.ErrorContext(r.Context(), "Stale movie version", logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusPreconditionFailed, "Movie has been modified")
+		case errors.Is(err, repository.ErrGenreNotFound):
+			logger.ErrorContext(r.Context(), "Unknown genre", logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusBadRequest, "Unknown genre")
+		case errors.Is(err, repository.ErrPersonNotFound):
+			logger.ErrorContext(r.Context(), "Unknown person in credits", logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusBadRequest, "Unknown person in credits")
+		default:
+			logger.ErrorContext(r.Context(), "Error updating movie", logger.Field("error", err), logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusInternalServerError, "Error updating movie")
+		}
 		return
 	}
 
-	logger.Info("Movie updated", logger.Field("movie_id", movie.ID), logger.Field("title", movie.Title))
+	logger.InfoContext(r.Context(), "Movie updated", logger.Field("movie_id", movie.ID), logger.Field("title", movie.Title))
+	setMovieETag(w, movie)
 	response.SuccessResponse(w, http.StatusOK, "Movie updated successfully", movie)
 }
 
@@ -111,85 +291,141 @@ func (h *MovieHandler) DeleteMovie(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		logger.Error("Invalid movie ID", logger.Field("id", idStr), logger.Field("error", err))
+		logger.ErrorContext(r.Context(), "Invalid movie ID", logger.Field("id", idStr), logger.Field("error", err))
 		response.ErrorResponse(w, http.StatusBadRequest, "Invalid movie ID")
 		return
 	}
 
-	err = h.movieRepo.Delete(r.Context(), id)
+	ifMatch, ok := parseIfMatch(w, r, h.config.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	err = h.movieRepo.Delete(r.Context(), id, ifMatch)
 	if err != nil {
 		if errors.Is(err, repository.ErrMovieNotFound) {
-			logger.Error("Movie not found", logger.Field("movie_id", id))
+			logger.ErrorContext(r.Context(), "Movie not found", logger.Field("movie_id", id))
 			response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
 			return
 		}
-		logger.Error("Error deleting movie", logger.Field("error", err), logger.Field("movie_id", id))
+		if errors.Is(err, repository.ErrMovieVersionMismatch) {
+			logger.ErrorContext(r.Context(), "Stale movie version", logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusPreconditionFailed, "Movie has been modified")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error deleting movie", logger.Field("error", err), logger.Field("movie_id", id))
 		response.ErrorResponse(w, http.StatusInternalServerError, "Error deleting movie")
 		return
 	}
 
-	logger.Info("Movie deleted", logger.Field("movie_id", id))
+	logger.InfoContext(r.Context(), "Movie moved to trash", logger.Field("movie_id", id))
 	response.SuccessResponse(w, http.StatusOK, "Movie deleted successfully", nil)
 }
 
 func (h *MovieHandler) ListMovies(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
-	query := &models.MovieQuery{
-		Title:    r.URL.Query().Get("title"),
-		Genre:    r.URL.Query().Get("genre"),
-		Director: r.URL.Query().Get("director"),
-		SortBy:   r.URL.Query().Get("sort_by"),
-		Order:    r.URL.Query().Get("order"),
+	query := parseMovieQuery(r)
+	if query.Status != "" && !models.ValidMovieStatuses[query.Status] {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"status": "must be one of draft, in_review, published, archived",
+		})
+		return
 	}
+	query.IncludeUnpublished = canSeeUnpublished(r)
+	w.Header().Set("Vary", "Authorization")
 
 	// Parse pagination parameters
-	if page := r.URL.Query().Get("page"); page != "" {
-		pageInt, err := strconv.Atoi(page)
-		if err == nil && pageInt > 0 {
-			query.Page = pageInt
-		}
-	}
-
-	if pageSize := r.URL.Query().Get("page_size"); pageSize != "" {
-		pageSizeInt, err := strconv.Atoi(pageSize)
-		if err == nil && pageSizeInt > 0 {
-			query.PageSize = pageSizeInt
-		}
-	}
-
-	// Default values
-	if query.Page <= 0 {
-		query.Page = 1
-	}
-	if query.PageSize <= 0 {
-		query.PageSize = 10
-	}
+	query.Page, query.PageSize = parsePagination(r)
 
 	movies, totalCount, err := h.movieRepo.List(r.Context(), query)
 	if err != nil {
-		logger.Error("Error listing movies", logger.Field("error", err))
+		logger.ErrorContext(r.Context(), "Error listing movies", logger.Field("error", err))
 		response.ErrorResponse(w, http.StatusInternalServerError, "Error listing movies")
 		return
 	}
 
-	// Calculate total pages
-	totalPages := totalCount / query.PageSize
-	if totalCount%query.PageSize != 0 {
-		totalPages++
-	}
-
 	responseData := PaginatedMovieResponse{
 		Movies:     movies,
 		TotalCount: totalCount,
 		Page:       query.Page,
 		PageSize:   query.PageSize,
-		TotalPages: totalPages,
+		TotalPages: totalPages(totalCount, query.PageSize),
 	}
 
-	logger.Info("Movies listed",
+	logger.InfoContext(r.Context(), "Movies listed",
 		logger.Field("count", len(movies)),
 		logger.Field("total", totalCount),
 		logger.Field("page", query.Page),
 	)
 	response.SuccessResponse(w, http.StatusOK, "Movies retrieved successfully", responseData)
 }
+
+// ListTrash returns the movies that have been soft-deleted and not yet purged
+func (h *MovieHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := parsePagination(r)
+
+	movies, totalCount, err := h.movieRepo.ListDeleted(r.Context(), page, pageSize)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Error listing trashed movies", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error listing trashed movies")
+		return
+	}
+
+	responseData := PaginatedMovieResponse{
+		Movies:     movies,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages(totalCount, pageSize),
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Trashed movies retrieved successfully", responseData)
+}
+
+func (h *MovieHandler) RestoreMovie(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	movie, err := h.movieRepo.Restore(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrMovieNotFound) {
+			logger.ErrorContext(r.Context(), "Trashed movie not found", logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Movie not found in trash")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error restoring movie", logger.Field("error", err), logger.Field("movie_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error restoring movie")
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Movie restored", logger.Field("movie_id", movie.ID), logger.Field("title", movie.Title))
+	setMovieETag(w, movie)
+	response.SuccessResponse(w, http.StatusOK, "Movie restored successfully", movie)
+}
+
+// parseMovieQuery reads the movie filter and sort parameters
+func parseMovieQuery(r *http.Request) *models.MovieQuery {
+	return &models.MovieQuery{
+		Title:    r.URL.Query().Get("title"),
+		Genre:    r.URL.Query().Get("genre"),
+		Director: r.URL.Query().Get("director"),
+		Status:   r.URL.Query().Get("status"),
+		SortBy:   r.URL.Query().Get("sort_by"),
+		Order:    r.URL.Query().Get("order"),
+	}
+}
+
+// parseMovieID reads the {id} URL parameter, writing a 400 response if it is invalid
+func parseMovieID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid movie ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid movie ID")
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/images"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"github.com/marchelhutagalung/go-service/internal/storage"
+	"io"
+	"net/http"
+)
+
+// multipartOverhead is the allowance for multipart headers and boundaries on
+// top of the image size limit
+const multipartOverhead = 1 << 20
+
+type MovieImageHandler struct {
+	movieRepo repository.MovieStore
+	store     storage.BlobStore
+	config    *config.ImageConfig
+}
+
+func NewMovieImageHandler(movieRepo repository.MovieStore, store storage.BlobStore, config *config.ImageConfig) *MovieImageHandler {
+	return &MovieImageHandler{
+		movieRepo: movieRepo,
+		store:     store,
+		config:    config,
+	}
+}
+
+func (h *MovieImageHandler) UploadPoster(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, models.ImageKindPoster)
+}
+
+func (h *MovieImageHandler) DeletePoster(w http.ResponseWriter, r *http.Request) {
+	h.remove(w, r, models.ImageKindPoster)
+}
+
+func (h *MovieImageHandler) UploadBackdrop(w http.ResponseWriter, r *http.Request) {
+	h.upload(w, r, models.ImageKindBackdrop)
+}
+
+func (h *MovieImageHandler) DeleteBackdrop(w http.ResponseWriter, r *http.Request) {
+	h.remove(w, r, models.ImageKindBackdrop)
+}
+
+// upload reads the image from the "file" field of a multipart body, stores it
+// with its thumbnails and attaches it to the movie
+func (h *MovieImageHandler) upload(w http.ResponseWriter, r *http.Request, kind string) {
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.movieRepo.GetByID(r.Context(), id); err != nil {
+		h.handleError(w, r, err, id)
+		return
+	}
+
+	data, ok := h.readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	img, err := images.Process(data, models.ThumbnailWidths[kind])
+	if err != nil {
+		switch {
+		case errors.Is(err, images.ErrUnsupportedType):
+			response.ErrorResponse(w, http.StatusUnsupportedMediaType, "Image must be a JPEG, PNG or WebP file")
+		case errors.Is(err, images.ErrTooManyPixels):
+			response.ErrorResponse(w, http.StatusRequestEntityTooLarge, "Image dimensions are too large")
+		default:
+			logger.ErrorContext(r.Context(), "Error processing image", logger.Field("error", err), logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusInternalServerError, "Error processing image")
+		}
+		return
+	}
+
+	image, err := h.storeImage(r.Context(), id, kind, img)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Error storing image", logger.Field("error", err), logger.Field("movie_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error storing image")
+		return
+	}
+
+	movie, previous, err := h.movieRepo.SetImage(r.Context(), id, kind, image)
+	if err != nil {
+		h.deleteBlobs(r.Context(), image)
+		h.handleError(w, r, err, id)
+		return
+	}
+
+	if previous != nil {
+		h.deleteBlobs(r.Context(), previous)
+	}
+
+	logger.InfoContext(r.Context(), "Movie image uploaded", logger.Field("movie_id", id), logger.Field("kind", kind), logger.Field("size", len(data)))
+	setMovieETag(w, movie)
+	response.SuccessResponse(w, http.StatusOK, "Image uploaded successfully", movie)
+}
+
+func (h *MovieImageHandler) remove(w http.ResponseWriter, r *http.Request, kind string) {
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	movie, removed, err := h.movieRepo.RemoveImage(r.Context(), id, kind)
+	if err != nil {
+		h.handleError(w, r, err, id)
+		return
+	}
+
+	h.deleteBlobs(r.Context(), removed)
+
+	logger.InfoContext(r.Context(), "Movie image removed", logger.Field("movie_id", id), logger.Field("kind", kind))
+	setMovieETag(w, movie)
+	response.SuccessResponse(w, http.StatusOK, "Image removed successfully", movie)
+}
+
+// readUpload returns the contents of the "file" part, writing an error
+// response if there is none or it exceeds the size limit
+func (h *MovieImageHandler) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.MaxUploadSize+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, "Request must be multipart/form-data")
+		return nil, false
+	}
+
+	tooLarge := fmt.Sprintf("Image must be at most %d bytes", h.config.MaxUploadSize)
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			switch {
+			case errors.As(err, &maxBytesErr):
+				response.ErrorResponse(w, http.StatusRequestEntityTooLarge, tooLarge)
+			case errors.Is(err, io.EOF):
+				response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+					"file": "is required",
+				})
+			default:
+				response.ErrorResponse(w, http.StatusBadRequest, "Invalid multipart body")
+			}
+			return nil, false
+		}
+
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		var buf bytes.Buffer
+		_, err = io.Copy(&buf, io.LimitReader(part, h.config.MaxUploadSize+1))
+		part.Close()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				response.ErrorResponse(w, http.StatusRequestEntityTooLarge, tooLarge)
+				return nil, false
+			}
+			response.ErrorResponse(w, http.StatusBadRequest, "Invalid multipart body")
+			return nil, false
+		}
+
+		if int64(buf.Len()) > h.config.MaxUploadSize {
+			response.ErrorResponse(w, http.StatusRequestEntityTooLarge, tooLarge)
+			return nil, false
+		}
+
+		if buf.Len() == 0 {
+			response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+				"file": "is empty",
+			})
+			return nil, false
+		}
+
+		return buf.Bytes(), true
+	}
+}
+
+// storeImage writes an image and its thumbnails to the blob store under a
+// fresh random prefix, so replaced files never collide with cached copies
+func (h *MovieImageHandler) storeImage(ctx context.Context, movieID int64, kind string, img *images.Image) (*models.MovieImage, error) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("movies/%d/%s/%s", movieID, kind, hex.EncodeToString(token))
+
+	image := &models.MovieImage{
+		Key:         prefix + "." + img.Extension,
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		Thumbnails:  []*models.ImageThumbnail{},
+	}
+	image.URL = h.store.URL(image.Key)
+
+	stored := []string{}
+	put := func(key string, data []byte, contentType string) error {
+		if err := h.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			for _, key := range stored {
+				h.store.Delete(context.WithoutCancel(ctx), key)
+			}
+			return err
+		}
+		stored = append(stored, key)
+		return nil
+	}
+
+	if err := put(image.Key, img.Data, img.ContentType); err != nil {
+		return nil, err
+	}
+
+	for _, t := range img.Thumbnails {
+		key := fmt.Sprintf("%s_w%d.jpg", prefix, t.Width)
+		if err := put(key, t.Data, "image/jpeg"); err != nil {
+			return nil, err
+		}
+		image.Thumbnails = append(image.Thumbnails, &models.ImageThumbnail{
+			Key: key, URL: h.store.URL(key), Width: t.Width, Height: t.Height,
+		})
+	}
+
+	return image, nil
+}
+
+// deleteBlobs removes an image's files. Failures only leave orphaned blobs
+// behind, so they are logged rather than reported.
+func (h *MovieImageHandler) deleteBlobs(ctx context.Context, image *models.MovieImage) {
+	for _, key := range image.Keys() {
+		if err := h.store.Delete(context.WithoutCancel(ctx), key); err != nil {
+			logger.ErrorContext(ctx, "Error deleting image blob", logger.Field("error", err), logger.Field("key", key))
+		}
+	}
+}
+
+func (h *MovieImageHandler) handleError(w http.ResponseWriter, r *http.Request, err error, movieID int64) {
+	switch {
+	case errors.Is(err, repository.ErrMovieNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+	case errors.Is(err, repository.ErrImageNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Image not found")
+	default:
+		logger.ErrorContext(r.Context(), "Error updating movie image", logger.Field("error", err), logger.Field("movie_id", movieID))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error updating movie image")
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/importer"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// maxImportBodySize bounds the size of an uploaded import file
+const maxImportBodySize = 100 << 20
+
+type MovieImportHandler struct {
+	importer *importer.Importer
+}
+
+func NewMovieImportHandler(importer *importer.Importer) *MovieImportHandler {
+	return &MovieImportHandler{
+		importer: importer,
+	}
+}
+
+// ImportMovies loads movies from a CSV or NDJSON request body. The format
+// comes from the format query parameter or the Content-Type; mode, dry_run,
+// batch_size, source and status tune the import.
+func (h *MovieImportHandler) ImportMovies(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	opts := models.MovieImportOptions{
+		Format: q.Get("format"),
+		Mode:   q.Get("mode"),
+		Source: q.Get("source"),
+		Status: q.Get("status"),
+	}
+
+	if opts.Format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			opts.Format = models.ImportFormatCSV
+		case "application/x-ndjson", "application/jsonl":
+			opts.Format = models.ImportFormatNDJSON
+		}
+	}
+
+	errs := map[string]string{}
+	if v := q.Get("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			errs["dry_run"] = "must be true or false"
+		}
+		opts.DryRun = dryRun
+	}
+	if v := q.Get("batch_size"); v != "" {
+		batchSize, err := strconv.Atoi(v)
+		if err != nil || batchSize <= 0 {
+			errs["batch_size"] = "must be a positive number"
+		}
+		opts.BatchSize = batchSize
+	}
+	if err := importer.ValidateOptions(&opts); err != nil {
+		errs["options"] = err.Error()
+	}
+	if len(errs) > 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodySize)
+
+	result, err := h.importer.Run(r.Context(), r.Body, opts)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		var inputErr *importer.InputError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			response.ErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Import file must be at most %d bytes", maxImportBodySize))
+		case errors.As(err, &inputErr):
+			response.ErrorResponse(w, http.StatusBadRequest, "Import failed: "+inputErr.Error())
+		case r.Context().Err() != nil:
+			// The client went away or the request timed out; the timeout
+			// middleware answers
+			logger.WarnContext(r.Context(), "Movie import cancelled", logger.Field("error", err))
+		default:
+			logger.ErrorContext(r.Context(), "Error importing movies", logger.Field("error", err))
+			response.ErrorResponse(w, http.StatusInternalServerError, "Error importing movies")
+		}
+		return
+	}
+
+	message := "Movies imported successfully"
+	switch {
+	case result.DryRun:
+		message = "Dry run completed, no changes were saved"
+	case !result.Committed && result.Failed > 0:
+		message = "Import rolled back, no changes were saved"
+	case result.Failed > 0:
+		message = "Movies imported with errors"
+	}
+
+	response.SuccessResponse(w, http.StatusOK, message, result)
+}
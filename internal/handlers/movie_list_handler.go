@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/middleware"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const maxListTitleLength = 200
+
+type MovieListHandler struct {
+	movieListRepo *repository.MovieListRepository
+}
+
+func NewMovieListHandler(movieListRepo *repository.MovieListRepository) *MovieListHandler {
+	return &MovieListHandler{
+		movieListRepo: movieListRepo,
+	}
+}
+
+type PaginatedMovieListResponse struct {
+	Lists      []*models.MovieList `json:"lists"`
+	TotalCount int                 `json:"total_count"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+func (h *MovieListHandler) CreateList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var input models.CreateMovieListInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = models.ListVisibilityPrivate
+	}
+
+	if errs := validateMovieList(&input.Title, &input.Visibility); len(errs) > 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
+	list, err := h.movieListRepo.Create(r.Context(), userID, &input)
+	if err != nil {
+		h.handleError(w, r, err, "Error creating list", 0)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "List created", logger.Field("list_id", list.ID), logger.Field("user_id", userID))
+	response.SuccessResponse(w, http.StatusCreated, "List created successfully", list)
+}
+
+func (h *MovieListHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	// Anonymous callers have a viewer ID of 0 and only see shared lists
+	viewerID, _ := middleware.GetUserID(r.Context())
+
+	list, err := h.movieListRepo.GetByID(r.Context(), id, viewerID)
+	if err != nil {
+		h.handleError(w, r, err, "Error getting list", id)
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "List retrieved successfully", list)
+}
+
+func (h *MovieListHandler) UpdateList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateMovieListInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validateMovieList(input.Title, input.Visibility); len(errs) > 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
+	list, err := h.movieListRepo.Update(r.Context(), id, userID, &input)
+	if err != nil {
+		h.handleError(w, r, err, "Error updating list", id)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "List updated", logger.Field("list_id", id), logger.Field("user_id", userID))
+	response.SuccessResponse(w, http.StatusOK, "List updated successfully", list)
+}
+
+func (h *MovieListHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.movieListRepo.Delete(r.Context(), id, userID); err != nil {
+		h.handleError(w, r, err, "Error deleting list", id)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "List deleted", logger.Field("list_id", id), logger.Field("user_id", userID))
+	response.SuccessResponse(w, http.StatusOK, "List deleted successfully", nil)
+}
+
+func (h *MovieListHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.AddMovieListItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.MovieID <= 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"movie_id": "is required",
+		})
+		return
+	}
+
+	list, err := h.movieListRepo.AddItem(r.Context(), id, userID, &input)
+	if err != nil {
+		h.handleError(w, r, err, "Error adding movie to list", id)
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusCreated, "Movie added to list", list)
+}
+
+func (h *MovieListHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	movieID, ok := parseSavedMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateMovieListItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	list, err := h.movieListRepo.UpdateItem(r.Context(), id, userID, movieID, &input)
+	if err != nil {
+		h.handleError(w, r, err, "Error updating list item", id)
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "List item updated", list)
+}
+
+func (h *MovieListHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	movieID, ok := parseSavedMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.movieListRepo.RemoveItem(r.Context(), id, userID, movieID)
+	if err != nil {
+		h.handleError(w, r, err, "Error removing movie from list", id)
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Movie removed from list", list)
+}
+
+func (h *MovieListHandler) ReorderItems(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.ReorderMovieListInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	list, err := h.movieListRepo.Reorder(r.Context(), id, userID, input.MovieIDs)
+	if err != nil {
+		h.handleError(w, r, err, "Error reordering list", id)
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "List reordered", list)
+}
+
+func (h *MovieListHandler) ListMyLists(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+
+	lists, totalCount, err := h.movieListRepo.ListByUser(r.Context(), userID, true, page, pageSize)
+	if err != nil {
+		h.handleError(w, r, err, "Error listing lists", 0)
+		return
+	}
+
+	h.respondPage(w, lists, totalCount, page, pageSize)
+}
+
+func (h *MovieListHandler) ListUserLists(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "userID")
+	userID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid user ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+
+	lists, totalCount, err := h.movieListRepo.ListByUser(r.Context(), userID, false, page, pageSize)
+	if err != nil {
+		h.handleError(w, r, err, "Error listing lists", 0)
+		return
+	}
+
+	h.respondPage(w, lists, totalCount, page, pageSize)
+}
+
+func (h *MovieListHandler) ListPopular(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := parsePagination(r)
+
+	lists, totalCount, err := h.movieListRepo.ListPopular(r.Context(), page, pageSize)
+	if err != nil {
+		h.handleError(w, r, err, "Error listing popular lists", 0)
+		return
+	}
+
+	h.respondPage(w, lists, totalCount, page, pageSize)
+}
+
+func (h *MovieListHandler) respondPage(w http.ResponseWriter, lists []*models.MovieList, totalCount, page, pageSize int) {
+	responseData := PaginatedMovieListResponse{
+		Lists:      lists,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages(totalCount, pageSize),
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Lists retrieved successfully", responseData)
+}
+
+func (h *MovieListHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string, listID int64) {
+	switch {
+	case errors.Is(err, repository.ErrListNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "List not found")
+	case errors.Is(err, repository.ErrListNotOwned):
+		response.ErrorResponse(w, http.StatusForbidden, "You can only modify your own lists")
+	case errors.Is(err, repository.ErrMovieNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+	case errors.Is(err, repository.ErrListItemNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Movie is not on the list")
+	case errors.Is(err, repository.ErrListItemExists):
+		response.ErrorResponse(w, http.StatusConflict, "Movie is already on the list")
+	case errors.Is(err, repository.ErrListOrderMismatch):
+		response.ErrorResponse(w, http.StatusBadRequest, "movie_ids must contain every movie on the list exactly once")
+	default:
+		logger.ErrorContext(r.Context(), message, logger.Field("error", err), logger.Field("list_id", listID))
+		response.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// validateMovieList checks the optional title and visibility fields of a list
+func validateMovieList(title, visibility *string) map[string]string {
+	errs := map[string]string{}
+	if title != nil {
+		trimmed := strings.TrimSpace(*title)
+		if trimmed == "" {
+			errs["title"] = "is required"
+		} else if len(trimmed) > maxListTitleLength {
+			errs["title"] = "is too long"
+		}
+	}
+	if visibility != nil && !models.ValidListVisibilities[*visibility] {
+		errs["visibility"] = "must be one of private, unlisted, public"
+	}
+	return errs
+}
+
+// parseListID reads the {id} URL parameter, writing a 400 response if it is invalid
+func parseListID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid list ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid list ID")
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PaginatedRevisionResponse struct {
+	Revisions  []*models.MovieRevision `json:"revisions"`
+	TotalCount int                     `json:"total_count"`
+	Page       int                     `json:"page"`
+	PageSize   int                     `json:"page_size"`
+	TotalPages int                     `json:"total_pages"`
+}
+
+// ListRevisions returns a movie's revision history, newest first
+func (h *MovieHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+
+	revisions, totalCount, err := h.movieRepo.ListRevisions(r.Context(), id, page, pageSize)
+	if err != nil {
+		h.handleRevisionError(w, r, err, id, "Error listing revisions")
+		return
+	}
+
+	responseData := PaginatedRevisionResponse{
+		Revisions:  revisions,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages(totalCount, pageSize),
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Revisions retrieved successfully", responseData)
+}
+
+// GetRevision returns a single revision with the movie snapshot it produced
+func (h *MovieHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	rev, ok := parseRevision(w, r)
+	if !ok {
+		return
+	}
+
+	revision, err := h.movieRepo.GetRevision(r.Context(), id, rev)
+	if err != nil {
+		h.handleRevisionError(w, r, err, id, "Error getting revision")
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Revision retrieved successfully", revision)
+}
+
+// RevertRevision restores a movie to the state recorded at a revision
+func (h *MovieHandler) RevertRevision(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	rev, ok := parseRevision(w, r)
+	if !ok {
+		return
+	}
+
+	movie, err := h.movieRepo.Revert(r.Context(), id, rev)
+	if err != nil {
+		h.handleRevisionError(w, r, err, id, "Error reverting movie")
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Movie reverted", logger.Field("movie_id", movie.ID), logger.Field("revision", rev))
+	setMovieETag(w, movie)
+	response.SuccessResponse(w, http.StatusOK, "Movie reverted successfully", movie)
+}
+
+func (h *MovieHandler) handleRevisionError(w http.ResponseWriter, r *http.Request, err error, movieID int64, message string) {
+	switch {
+	case errors.Is(err, repository.ErrMovieNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+	case errors.Is(err, repository.ErrRevisionNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Revision not found")
+	case errors.Is(err, repository.ErrGenreNotFound), errors.Is(err, repository.ErrPersonNotFound):
+		response.ErrorResponse(w, http.StatusConflict, "Revision references a genre or person that no longer exists")
+	default:
+		logger.ErrorContext(r.Context(), message, logger.Field("error", err), logger.Field("movie_id", movieID))
+		response.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// parseRevision reads the {rev} URL parameter, writing a 400 response if it is invalid
+func parseRevision(w http.ResponseWriter, r *http.Request) (int, bool) {
+	revStr := chi.URLParam(r, "rev")
+	rev, err := strconv.Atoi(revStr)
+	if err != nil || rev <= 0 {
+		logger.ErrorContext(r.Context(), "Invalid revision", logger.Field("rev", revStr))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid revision")
+		return 0, false
+	}
+
+	return rev, true
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/middleware"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"io"
+	"net/http"
+)
+
+// SubmitMovie sends a draft for review
+func (h *MovieHandler) SubmitMovie(w http.ResponseWriter, r *http.Request) {
+	h.transitionMovie(w, r, "submit")
+}
+
+// RejectMovie sends a movie under review back to draft
+func (h *MovieHandler) RejectMovie(w http.ResponseWriter, r *http.Request) {
+	h.transitionMovie(w, r, "reject")
+}
+
+// PublishMovie publishes a reviewed movie, either now or at the publish_at
+// time given in the body
+func (h *MovieHandler) PublishMovie(w http.ResponseWriter, r *http.Request) {
+	h.transitionMovie(w, r, "publish")
+}
+
+// ArchiveMovie takes a published movie out of the public catalogue
+func (h *MovieHandler) ArchiveMovie(w http.ResponseWriter, r *http.Request) {
+	h.transitionMovie(w, r, "archive")
+}
+
+func (h *MovieHandler) transitionMovie(w http.ResponseWriter, r *http.Request, name string) {
+	transition := models.MovieTransitions[name]
+
+	id, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	ifMatch, ok := parseIfMatch(w, r, h.config.RequireIfMatch)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var input models.MovieTransitionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.PublishAt != nil && transition.To != models.MovieStatusPublished {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"publish_at": "can only be set when publishing",
+		})
+		return
+	}
+
+	movie, err := h.movieRepo.Transition(r.Context(), id, transition, input.PublishAt, ifMatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMovieNotFound):
+			response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+		case errors.Is(err, repository.ErrMovieVersionMismatch):
+			response.ErrorResponse(w, http.StatusPreconditionFailed, "Movie has been modified")
+		case errors.Is(err, repository.ErrInvalidTransition):
+			response.ErrorResponse(w, http.StatusConflict, "Movie must be "+transition.From+" to "+name+" it")
+		default:
+			logger.ErrorContext(r.Context(), "Error changing movie status", logger.Field("error", err), logger.Field("movie_id", id))
+			response.ErrorResponse(w, http.StatusInternalServerError, "Error changing movie status")
+		}
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Movie status changed",
+		logger.Field("movie_id", movie.ID),
+		logger.Field("transition", name),
+		logger.Field("status", movie.Status),
+	)
+	setMovieETag(w, movie)
+	response.SuccessResponse(w, http.StatusOK, "Movie status changed successfully", movie)
+}
+
+// canSeeUnpublished reports whether the caller may read movies that are not
+// public yet
+func canSeeUnpublished(r *http.Request) bool {
+	role, _ := middleware.GetUserRole(r.Context())
+	return models.CanSeeUnpublished(role)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// parsePagination reads the page and page_size query parameters, falling back
+// to the first page of 10 items
+func parsePagination(r *http.Request) (int, int) {
+	page, pageSize := 1, 10
+
+	if p := r.URL.Query().Get("page"); p != "" {
+		pageInt, err := strconv.Atoi(p)
+		if err == nil && pageInt > 0 {
+			page = pageInt
+		}
+	}
+
+	if ps := r.URL.Query().Get("page_size"); ps != "" {
+		pageSizeInt, err := strconv.Atoi(ps)
+		if err == nil && pageSizeInt > 0 {
+			pageSize = pageSizeInt
+		}
+	}
+
+	return page, pageSize
+}
+
+// totalPages calculates the number of pages needed for totalCount items
+func totalPages(totalCount, pageSize int) int {
+	pages := totalCount / pageSize
+	if totalCount%pageSize != 0 {
+		pages++
+	}
+	return pages
+}
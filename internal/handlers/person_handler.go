@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type PersonHandler struct {
+	personRepo *repository.PersonRepository
+}
+
+func NewPersonHandler(personRepo *repository.PersonRepository) *PersonHandler {
+	return &PersonHandler{
+		personRepo: personRepo,
+	}
+}
+
+type PaginatedPersonResponse struct {
+	People     []*models.Person `json:"people"`
+	TotalCount int              `json:"total_count"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
+
+func (h *PersonHandler) CreatePerson(w http.ResponseWriter, r *http.Request) {
+	var input models.CreatePersonInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"name": "is required",
+		})
+		return
+	}
+
+	person, err := h.personRepo.Create(r.Context(), &input)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Error creating person", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error creating person")
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Person created", logger.Field("person_id", person.ID), logger.Field("name", person.Name))
+	response.SuccessResponse(w, http.StatusCreated, "Person created successfully", person)
+}
+
+func (h *PersonHandler) GetPerson(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid person ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid person ID")
+		return
+	}
+
+	person, err := h.personRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			logger.ErrorContext(r.Context(), "Person not found", logger.Field("person_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Person not found")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error getting person", logger.Field("error", err), logger.Field("person_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error getting person")
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Person retrieved successfully", person)
+}
+
+func (h *PersonHandler) UpdatePerson(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid person ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid person ID")
+		return
+	}
+
+	var input models.UpdatePersonInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.Name != nil && strings.TrimSpace(*input.Name) == "" {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"name": "must not be empty",
+		})
+		return
+	}
+
+	person, err := h.personRepo.Update(r.Context(), id, &input)
+	if err != nil {
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			logger.ErrorContext(r.Context(), "Person not found", logger.Field("person_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Person not found")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error updating person", logger.Field("error", err), logger.Field("person_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error updating person")
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Person updated", logger.Field("person_id", person.ID), logger.Field("name", person.Name))
+	response.SuccessResponse(w, http.StatusOK, "Person updated successfully", person)
+}
+
+func (h *PersonHandler) DeletePerson(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid person ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid person ID")
+		return
+	}
+
+	err = h.personRepo.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			logger.ErrorContext(r.Context(), "Person not found", logger.Field("person_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Person not found")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error deleting person", logger.Field("error", err), logger.Field("person_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error deleting person")
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Person deleted", logger.Field("person_id", id))
+	response.SuccessResponse(w, http.StatusOK, "Person deleted successfully", nil)
+}
+
+func (h *PersonHandler) ListPeople(w http.ResponseWriter, r *http.Request) {
+	query := &models.PersonQuery{
+		Name: r.URL.Query().Get("name"),
+	}
+	query.Page, query.PageSize = parsePagination(r)
+
+	people, totalCount, err := h.personRepo.List(r.Context(), query)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Error listing people", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error listing people")
+		return
+	}
+
+	responseData := PaginatedPersonResponse{
+		People:     people,
+		TotalCount: totalCount,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: totalPages(totalCount, query.PageSize),
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "People retrieved successfully", responseData)
+}
+
+func (h *PersonHandler) GetFilmography(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid person ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid person ID")
+		return
+	}
+
+	filmography, err := h.personRepo.Filmography(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPersonNotFound) {
+			logger.ErrorContext(r.Context(), "Person not found", logger.Field("person_id", id))
+			response.ErrorResponse(w, http.StatusNotFound, "Person not found")
+			return
+		}
+		logger.ErrorContext(r.Context(), "Error getting filmography", logger.Field("error", err), logger.Field("person_id", id))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error getting filmography")
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Filmography retrieved successfully", filmography)
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/middleware"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const maxReviewBodyLength = 10000
+
+type ReviewHandler struct {
+	reviewRepo *repository.ReviewRepository
+}
+
+func NewReviewHandler(reviewRepo *repository.ReviewRepository) *ReviewHandler {
+	return &ReviewHandler{
+		reviewRepo: reviewRepo,
+	}
+}
+
+type PaginatedReviewResponse struct {
+	Reviews    []*models.Review `json:"reviews"`
+	TotalCount int              `json:"total_count"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
+
+func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	movieID, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.CreateReviewInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validateReview(&input.Rating, &input.Body); len(errs) > 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
+	review, err := h.reviewRepo.Create(r.Context(), movieID, userID, &input)
+	if err != nil {
+		h.handleError(w, r, err, "Error creating review", movieID)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Review created", logger.Field("review_id", review.ID), logger.Field("movie_id", movieID), logger.Field("user_id", userID))
+	response.SuccessResponse(w, http.StatusCreated, "Review created successfully", review)
+}
+
+func (h *ReviewHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	movieID, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := parseReviewID(w, r)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateReviewInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if errs := validateReview(input.Rating, input.Body); len(errs) > 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", errs)
+		return
+	}
+
+	review, err := h.reviewRepo.Update(r.Context(), movieID, reviewID, userID, &input)
+	if err != nil {
+		h.handleError(w, r, err, "Error updating review", movieID)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Review updated", logger.Field("review_id", review.ID), logger.Field("movie_id", movieID), logger.Field("user_id", userID))
+	response.SuccessResponse(w, http.StatusOK, "Review updated successfully", review)
+}
+
+func (h *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	movieID, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	reviewID, ok := parseReviewID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.reviewRepo.Delete(r.Context(), movieID, reviewID, userID); err != nil {
+		h.handleError(w, r, err, "Error deleting review", movieID)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Review deleted", logger.Field("review_id", reviewID), logger.Field("movie_id", movieID), logger.Field("user_id", userID))
+	response.SuccessResponse(w, http.StatusOK, "Review deleted successfully", nil)
+}
+
+func (h *ReviewHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	movieID, ok := parseMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+
+	reviews, totalCount, err := h.reviewRepo.ListByMovie(r.Context(), movieID, page, pageSize)
+	if err != nil {
+		h.handleError(w, r, err, "Error listing reviews", movieID)
+		return
+	}
+
+	responseData := PaginatedReviewResponse{
+		Reviews:    reviews,
+		TotalCount: totalCount,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages(totalCount, pageSize),
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Reviews retrieved successfully", responseData)
+}
+
+func (h *ReviewHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string, movieID int64) {
+	switch {
+	case errors.Is(err, repository.ErrMovieNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+	case errors.Is(err, repository.ErrReviewNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Review not found")
+	case errors.Is(err, repository.ErrReviewNotOwned):
+		response.ErrorResponse(w, http.StatusForbidden, "You can only modify your own review")
+	case errors.Is(err, repository.ErrReviewExists):
+		response.ErrorResponse(w, http.StatusConflict, "You have already reviewed this movie")
+	default:
+		logger.ErrorContext(r.Context(), message, logger.Field("error", err), logger.Field("movie_id", movieID))
+		response.ErrorResponse(w, http.StatusInternalServerError, message)
+	}
+}
+
+// validateReview checks the optional rating and body fields of a review
+func validateReview(rating *int, body *string) map[string]string {
+	errs := map[string]string{}
+	if rating != nil && (*rating < models.MinReviewRating || *rating > models.MaxReviewRating) {
+		errs["rating"] = fmt.Sprintf("must be between %d and %d", models.MinReviewRating, models.MaxReviewRating)
+	}
+	if body != nil && len(*body) > maxReviewBodyLength {
+		errs["body"] = fmt.Sprintf("must be at most %d characters", maxReviewBodyLength)
+	}
+	return errs
+}
+
+// parseReviewID reads the {reviewID} URL parameter, writing a 400 response if it is invalid
+func parseReviewID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := chi.URLParam(r, "reviewID")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid review ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid review ID")
+		return 0, false
+	}
+
+	return id, true
+}
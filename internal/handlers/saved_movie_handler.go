@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/middleware"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SavedMovieHandler serves the current user's watchlist and favorites
+type SavedMovieHandler struct {
+	savedMovieRepo *repository.SavedMovieRepository
+}
+
+func NewSavedMovieHandler(savedMovieRepo *repository.SavedMovieRepository) *SavedMovieHandler {
+	return &SavedMovieHandler{
+		savedMovieRepo: savedMovieRepo,
+	}
+}
+
+type PaginatedSavedMovieResponse struct {
+	Items      []*models.SavedMovie `json:"items"`
+	TotalCount int                  `json:"total_count"`
+	Page       int                  `json:"page"`
+	PageSize   int                  `json:"page_size"`
+	TotalPages int                  `json:"total_pages"`
+}
+
+func (h *SavedMovieHandler) ListWatchlist(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, models.SavedMovieWatchlist)
+}
+
+func (h *SavedMovieHandler) AddToWatchlist(w http.ResponseWriter, r *http.Request) {
+	h.add(w, r, models.SavedMovieWatchlist)
+}
+
+func (h *SavedMovieHandler) RemoveFromWatchlist(w http.ResponseWriter, r *http.Request) {
+	h.remove(w, r, models.SavedMovieWatchlist)
+}
+
+func (h *SavedMovieHandler) MarkWatchlistWatched(w http.ResponseWriter, r *http.Request) {
+	h.markWatched(w, r, models.SavedMovieWatchlist)
+}
+
+func (h *SavedMovieHandler) UnmarkWatchlistWatched(w http.ResponseWriter, r *http.Request) {
+	h.unmarkWatched(w, r, models.SavedMovieWatchlist)
+}
+
+func (h *SavedMovieHandler) ListFavorites(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, models.SavedMovieFavorites)
+}
+
+func (h *SavedMovieHandler) AddToFavorites(w http.ResponseWriter, r *http.Request) {
+	h.add(w, r, models.SavedMovieFavorites)
+}
+
+func (h *SavedMovieHandler) RemoveFromFavorites(w http.ResponseWriter, r *http.Request) {
+	h.remove(w, r, models.SavedMovieFavorites)
+}
+
+func (h *SavedMovieHandler) MarkFavoriteWatched(w http.ResponseWriter, r *http.Request) {
+	h.markWatched(w, r, models.SavedMovieFavorites)
+}
+
+func (h *SavedMovieHandler) UnmarkFavoriteWatched(w http.ResponseWriter, r *http.Request) {
+	h.unmarkWatched(w, r, models.SavedMovieFavorites)
+}
+
+func (h *SavedMovieHandler) list(w http.ResponseWriter, r *http.Request, kind string) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	query := &models.SavedMovieQuery{
+		MovieQuery: models.MovieQuery{
+			Title:    r.URL.Query().Get("title"),
+			Genre:    r.URL.Query().Get("genre"),
+			Director: r.URL.Query().Get("director"),
+			SortBy:   r.URL.Query().Get("sort_by"),
+			Order:    r.URL.Query().Get("order"),
+		},
+	}
+	query.Page, query.PageSize = parsePagination(r)
+
+	if watched := r.URL.Query().Get("watched"); watched != "" {
+		watchedBool, err := strconv.ParseBool(watched)
+		if err != nil {
+			response.ErrorResponse(w, http.StatusBadRequest, "Invalid watched filter")
+			return
+		}
+		query.Watched = &watchedBool
+	}
+
+	items, totalCount, err := h.savedMovieRepo.List(r.Context(), userID, kind, query)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Error listing saved movies", logger.Field("error", err), logger.Field("user_id", userID), logger.Field("list", kind))
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error listing "+kind)
+		return
+	}
+
+	responseData := PaginatedSavedMovieResponse{
+		Items:      items,
+		TotalCount: totalCount,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: totalPages(totalCount, query.PageSize),
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Movies retrieved successfully", responseData)
+}
+
+func (h *SavedMovieHandler) add(w http.ResponseWriter, r *http.Request, kind string) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var input models.AddSavedMovieInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if input.MovieID <= 0 {
+		response.ValidationErrorResponse(w, http.StatusBadRequest, "Validation failed", map[string]string{
+			"movie_id": "is required",
+		})
+		return
+	}
+
+	item, err := h.savedMovieRepo.Add(r.Context(), userID, kind, input.MovieID)
+	if err != nil {
+		h.handleError(w, r, err, userID, kind, input.MovieID)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Movie saved", logger.Field("user_id", userID), logger.Field("list", kind), logger.Field("movie_id", input.MovieID))
+	response.SuccessResponse(w, http.StatusCreated, "Movie added to "+kind, item)
+}
+
+func (h *SavedMovieHandler) remove(w http.ResponseWriter, r *http.Request, kind string) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	movieID, ok := parseSavedMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.savedMovieRepo.Remove(r.Context(), userID, kind, movieID); err != nil {
+		h.handleError(w, r, err, userID, kind, movieID)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Movie unsaved", logger.Field("user_id", userID), logger.Field("list", kind), logger.Field("movie_id", movieID))
+	response.SuccessResponse(w, http.StatusOK, "Movie removed from "+kind, nil)
+}
+
+func (h *SavedMovieHandler) markWatched(w http.ResponseWriter, r *http.Request, kind string) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	movieID, ok := parseSavedMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional; an empty one marks the movie watched now
+	var input models.MarkWatchedInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	watchedAt := time.Now()
+	if input.WatchedAt != nil {
+		watchedAt = *input.WatchedAt
+	}
+
+	item, err := h.savedMovieRepo.SetWatched(r.Context(), userID, kind, movieID, &watchedAt)
+	if err != nil {
+		h.handleError(w, r, err, userID, kind, movieID)
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Movie marked as watched", item)
+}
+
+func (h *SavedMovieHandler) unmarkWatched(w http.ResponseWriter, r *http.Request, kind string) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	movieID, ok := parseSavedMovieID(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.savedMovieRepo.SetWatched(r.Context(), userID, kind, movieID, nil)
+	if err != nil {
+		h.handleError(w, r, err, userID, kind, movieID)
+		return
+	}
+
+	response.SuccessResponse(w, http.StatusOK, "Movie marked as unwatched", item)
+}
+
+func (h *SavedMovieHandler) handleError(w http.ResponseWriter, r *http.Request, err error, userID int64, kind string, movieID int64) {
+	switch {
+	case errors.Is(err, repository.ErrMovieNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Movie not found")
+	case errors.Is(err, repository.ErrSavedMovieNotFound):
+		response.ErrorResponse(w, http.StatusNotFound, "Movie is not in your "+kind)
+	case errors.Is(err, repository.ErrSavedMovieExists):
+		response.ErrorResponse(w, http.StatusConflict, "Movie is already in your "+kind)
+	default:
+		logger.ErrorContext(r.Context(), "Error updating saved movies",
+			logger.Field("error", err),
+			logger.Field("user_id", userID),
+			logger.Field("list", kind),
+			logger.Field("movie_id", movieID),
+		)
+		response.ErrorResponse(w, http.StatusInternalServerError, "Error updating "+kind)
+	}
+}
+
+// parseSavedMovieID reads the {movieID} URL parameter, writing a 400 response if it is invalid
+func parseSavedMovieID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := chi.URLParam(r, "movieID")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		logger.ErrorContext(r.Context(), "Invalid movie ID", logger.Field("id", idStr), logger.Field("error", err))
+		response.ErrorResponse(w, http.StatusBadRequest, "Invalid movie ID")
+		return 0, false
+	}
+
+	return id, true
+}
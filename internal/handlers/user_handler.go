@@ -10,10 +10,10 @@ import (
 )
 
 type UserHandler struct {
-	userRepo *repository.UserRepository
+	userRepo repository.UserStore
 }
 
-func NewUserHandler(userRepo *repository.UserRepository) *UserHandler {
+func NewUserHandler(userRepo repository.UserStore) *UserHandler {
 	return &UserHandler{
 		userRepo: userRepo,
 	}
@@ -22,7 +22,7 @@ func NewUserHandler(userRepo *repository.UserRepository) *UserHandler {
 func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
-		logger.Error("Get current user attempted without authentication")
+		logger.ErrorContext(r.Context(), "Get current user attempted without authentication")
 		response.ErrorResponse(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
@@ -30,15 +30,15 @@ func (h *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	user, err := h.userRepo.GetByID(r.Context(), userID)
 	if err != nil {
 		if errors.Is(err, repository.ErrUserNotFound) {
-			logger.Error("User not found", logger.Field("user_id", userID))
+			logger.ErrorContext(r.Context(), "User not found", logger.Field("user_id", userID))
 			response.ErrorResponse(w, http.StatusNotFound, "User not found")
 			return
 		}
-		logger.Error("Error fetching user", logger.Field("error", err), logger.Field("user_id", userID))
+		logger.ErrorContext(r.Context(), "Error fetching user", logger.Field("error", err), logger.Field("user_id", userID))
 		response.ErrorResponse(w, http.StatusInternalServerError, "Error fetching user")
 		return
 	}
 
-	logger.Info("User fetched", logger.Field("user_id", userID))
+	logger.InfoContext(r.Context(), "User fetched", logger.Field("user_id", userID))
 	response.SuccessResponse(w, http.StatusOK, "User retrieved successfully", user.ToResponse())
 }
@@ -0,0 +1,155 @@
+// Package health runs the dependency checks behind the readiness probe
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of a check and of the service as a whole
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded" // a non-critical check failed; still serving
+	StatusDown     = "down"
+)
+
+// CheckFunc reports whether a dependency is usable, returning nil if it is
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+}
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks. Status is down if a critical check
+// failed or the service is shutting down, and degraded if only non-critical
+// ones did.
+type Report struct {
+	Status       string                 `json:"status"`
+	ShuttingDown bool                   `json:"shutting_down"`
+	Checks       map[string]CheckResult `json:"checks"`
+}
+
+// Checker holds the checks dependencies registered and whether the service
+// is shutting down
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []check
+
+	shuttingDown atomic.Bool
+}
+
+// NewChecker creates a Checker that gives every check timeout to answer
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a check. When a critical check fails the service is reported
+// down; when a non-critical one fails it is reported degraded.
+func (c *Checker) Register(name string, fn CheckFunc, critical bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, check{name: name, fn: fn, critical: critical})
+}
+
+// SetShuttingDown makes every following report down, so load balancers stop
+// routing requests here while in-flight ones drain
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check runs every check concurrently, each with the checker's timeout
+func (c *Checker) Check(ctx context.Context) *Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	report := &Report{
+		Status:       StatusUp,
+		ShuttingDown: c.shuttingDown.Load(),
+		Checks:       make(map[string]CheckResult, len(checks)),
+	}
+
+	for i, chk := range checks {
+		result := results[i]
+		report.Checks[chk.name] = result
+
+		if result.Status == StatusDown {
+			if chk.critical {
+				report.Status = StatusDown
+			} else if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+
+	if report.ShuttingDown {
+		report.Status = StatusDown
+	}
+
+	return report
+}
+
+// run runs one check, turning a timeout or panic into a failure
+func (c *Checker) run(ctx context.Context, chk check) (result CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errc <- errPanic
+			}
+		}()
+		errc <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		// A check that ignores its context must not hold up the probe
+		err = ctx.Err()
+	}
+
+	result = CheckResult{
+		Status:    StatusUp,
+		Critical:  chk.critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
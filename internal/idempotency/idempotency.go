@@ -0,0 +1,31 @@
+// Package idempotency stores the responses of requests sent with an
+// Idempotency-Key so retries get the same response instead of repeating the
+// request
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Record is what is stored under a key: the request's fingerprint, and its
+// response once it has one
+type Record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Done        bool        `json:"done"` // false while the request is in flight
+	StatusCode  int         `json:"status_code,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Store keeps records by key
+type Store interface {
+	// Get returns the record under key, or nil if there is none
+	Get(ctx context.Context, key string) (*Record, error)
+	// Acquire stores record under key only if the key is free, reporting
+	// whether it did; this is the lock on in-flight requests
+	Acquire(ctx context.Context, key string, record *Record, ttl time.Duration) (bool, error)
+	Save(ctx context.Context, key string, record *Record, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,96 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// sweepInterval is how often MemoryStore drops expired records
+const sweepInterval = time.Minute
+
+// MemoryStore keeps records in process, for running without Redis
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.live(key)
+	if !ok {
+		return nil, nil
+	}
+
+	record := entry.record
+	return &record, nil
+}
+
+func (s *MemoryStore) Acquire(ctx context.Context, key string, record *Record, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	if _, ok := s.live(key); ok {
+		return false, nil
+	}
+
+	s.entries[key] = memoryEntry{record: *record, expiresAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, key string, record *Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{record: *record, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// live returns the entry under key unless it has expired, which it drops
+func (s *MemoryStore) live(key string) (memoryEntry, bool) {
+	entry, ok := s.entries[key]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, ok
+}
+
+// sweep drops expired records of keys that are not requested again
+func (s *MemoryStore) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package images validates uploaded images and renders their thumbnails.
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// maxPixels bounds the decoded size of an upload, which protects against
+// small files that decompress into huge images
+const maxPixels = 50_000_000
+
+// thumbnailQuality is the JPEG quality thumbnails are encoded with
+const thumbnailQuality = 85
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+)
+
+// extensions maps the accepted content types to file extensions
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// Image is a validated upload and its thumbnails
+type Image struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Data        []byte
+	Thumbnails  []*Thumbnail
+}
+
+// Thumbnail is a resized JPEG copy of an image
+type Thumbnail struct {
+	Width  int
+	Height int
+	Data   []byte
+}
+
+// Process sniffs the content type of data, checks that it is a JPEG, PNG or
+// WebP image that decodes, and renders a thumbnail for each width smaller
+// than the image. The declared content type of the upload is not trusted.
+func Process(data []byte, widths []int) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	extension, ok := extensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+
+	img := &Image{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Data:        data,
+		Thumbnails:  []*Thumbnail{},
+	}
+
+	for _, width := range widths {
+		if width >= cfg.Width {
+			continue
+		}
+
+		thumbnail, err := resize(src, width)
+		if err != nil {
+			return nil, err
+		}
+		img.Thumbnails = append(img.Thumbnails, thumbnail)
+	}
+
+	return img, nil
+}
+
+// resize scales src to the given width, keeping its aspect ratio
+func resize(src image.Image, width int) (*Thumbnail, error) {
+	bounds := src.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	// JPEG has no alpha channel, so transparent areas are flattened onto white
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+
+	return &Thumbnail{Width: width, Height: height, Data: buf.Bytes()}, nil
+}
@@ -0,0 +1,222 @@
+// Package importer loads movies in bulk from CSV and NDJSON files.
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"io"
+)
+
+// DefaultBatchSize is the number of rows committed together in best-effort
+// mode when no batch size is given
+const DefaultBatchSize = 500
+
+// Importer streams import rows into the movie repository
+type Importer struct {
+	movieRepo *repository.MovieRepository
+}
+
+func NewImporter(movieRepo *repository.MovieRepository) *Importer {
+	return &Importer{
+		movieRepo: movieRepo,
+	}
+}
+
+// ValidateOptions fills in defaults and checks the import options
+func ValidateOptions(opts *models.MovieImportOptions) error {
+	if opts.Mode == "" {
+		opts.Mode = models.ImportModeAllOrNothing
+	}
+	if opts.Mode != models.ImportModeAllOrNothing && opts.Mode != models.ImportModeBestEffort {
+		return fmt.Errorf("mode must be %s or %s", models.ImportModeAllOrNothing, models.ImportModeBestEffort)
+	}
+
+	if opts.Format != models.ImportFormatCSV && opts.Format != models.ImportFormatNDJSON {
+		return fmt.Errorf("format must be %s or %s", models.ImportFormatCSV, models.ImportFormatNDJSON)
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	// Imports load existing catalogues, so their movies are public unless
+	// asked otherwise
+	if opts.Status == "" {
+		opts.Status = models.MovieStatusPublished
+	}
+	if !models.ValidMovieStatuses[opts.Status] {
+		return fmt.Errorf("status must be %s, %s, %s or %s",
+			models.MovieStatusDraft, models.MovieStatusInReview, models.MovieStatusPublished, models.MovieStatusArchived)
+	}
+
+	return nil
+}
+
+// Run imports every row read from src. Rows that fail to parse, validate or
+// save are reported in the result rather than stopping the import.
+//
+// In all-or-nothing mode every row is written in one transaction that is
+// only committed if no row failed. In best-effort mode each batch of rows is
+// committed on its own. A dry run performs every write and then rolls it
+// back, so the report also covers unknown genres and people.
+//
+// An error is only returned when the import could not run at all, in which
+// case any uncommitted batch is rolled back. Bad options and unreadable input
+// are reported as an *InputError.
+func (i *Importer) Run(ctx context.Context, src io.Reader, opts models.MovieImportOptions) (*models.MovieImportResult, error) {
+	if err := ValidateOptions(&opts); err != nil {
+		return nil, &InputError{Err: err}
+	}
+
+	reader, err := newRowReader(opts.Format, src)
+	if err != nil {
+		return nil, &InputError{Err: err}
+	}
+
+	bestEffort := opts.Mode == models.ImportModeBestEffort
+	result := &models.MovieImportResult{
+		Mode:   opts.Mode,
+		DryRun: opts.DryRun,
+		Errors: []*models.MovieImportRowError{},
+	}
+
+	var batch *repository.MovieImport
+	batchRows := 0
+
+	// finish ends the open batch, committing it when commit is set
+	finish := func(commit bool) error {
+		if batch == nil {
+			return nil
+		}
+		current := batch
+		batch, batchRows = nil, 0
+
+		if !commit {
+			return current.Rollback()
+		}
+		if err := current.Commit(); err != nil {
+			return err
+		}
+		result.Committed = true
+		return nil
+	}
+
+	fail := func(err error) (*models.MovieImportResult, error) {
+		if batch != nil {
+			batch.Rollback()
+		}
+		return nil, err
+	}
+
+	for {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			result.Total++
+			result.Failed++
+			result.Errors = append(result.Errors, &models.MovieImportRowError{
+				Line: rowErr.Line, Message: rowErr.Message, Fields: rowErr.Fields,
+			})
+			continue
+		}
+		if err != nil {
+			return fail(&InputError{Err: err})
+		}
+
+		result.Total++
+
+		if row.Source == "" {
+			row.Source = opts.Source
+		}
+
+		fields := row.Validate()
+		if row.ExternalID != "" {
+			var ok bool
+			if row.Source, ok = models.NormalizeExternalSource(row.Source); !ok {
+				fields["source"] = "is required with external_id and may only contain letters, digits, - and _"
+			}
+		}
+		if len(fields) > 0 {
+			result.Failed++
+			result.Errors = append(result.Errors, &models.MovieImportRowError{
+				Line: row.Line, ExternalID: row.ExternalID, Message: "Validation failed", Fields: fields,
+			})
+			continue
+		}
+
+		if batch == nil {
+			if batch, err = i.movieRepo.BeginImport(ctx, opts.Status); err != nil {
+				return fail(err)
+			}
+		}
+
+		created, saveErr, err := batch.Upsert(ctx, row)
+		if err != nil {
+			return fail(err)
+		}
+		if saveErr != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, &models.MovieImportRowError{
+				Line: row.Line, ExternalID: row.ExternalID, Message: rowErrorMessage(ctx, row, saveErr),
+			})
+			continue
+		}
+
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+
+		batchRows++
+		if bestEffort && batchRows >= opts.BatchSize {
+			if err := finish(!opts.DryRun); err != nil {
+				return fail(err)
+			}
+			logger.InfoContext(ctx, "Import batch finished", logger.Field("rows", result.Total), logger.Field("dry_run", opts.DryRun))
+		}
+	}
+
+	commit := !opts.DryRun && (bestEffort || result.Failed == 0)
+	if err := finish(commit); err != nil {
+		return fail(err)
+	}
+
+	logger.InfoContext(ctx, "Import finished",
+		logger.Field("mode", opts.Mode),
+		logger.Field("dry_run", opts.DryRun),
+		logger.Field("committed", result.Committed),
+		logger.Field("total", result.Total),
+		logger.Field("created", result.Created),
+		logger.Field("updated", result.Updated),
+		logger.Field("failed", result.Failed),
+	)
+
+	return result, nil
+}
+
+// rowErrorMessage describes why the repository rejected a row. Unexpected
+// errors are logged rather than shown, since they come from the database.
+func rowErrorMessage(ctx context.Context, row *models.MovieImportRow, err error) string {
+	switch {
+	case errors.Is(err, repository.ErrGenreNotFound):
+		return "Unknown genre"
+	case errors.Is(err, repository.ErrPersonNotFound):
+		return "Unknown person in credits"
+	case errors.Is(err, repository.ErrMovieNotFound):
+		return "The movie linked to this external ID is in the trash"
+	case errors.Is(err, repository.ErrExternalIDExists):
+		return "External ID was linked to another movie during the import"
+	}
+
+	logger.ErrorContext(ctx, "Error importing row", logger.Field("error", err), logger.Field("line", row.Line))
+	return "Could not save row"
+}
@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLineSize bounds a single NDJSON line
+const maxLineSize = 1 << 20
+
+// csvColumns are the columns a CSV import may contain. Only title is required
+// in the header; genres are separated by "|" and credits hold a JSON array.
+var csvColumns = map[string]bool{
+	"source": true, "external_id": true, "title": true, "description": true,
+	"release_date": true, "rating": true, "duration": true, "genres": true, "credits": true,
+}
+
+// RowError reports a row that could not be parsed. Reading continues with
+// the next row.
+type RowError struct {
+	Line    int
+	Message string
+	Fields  map[string]string
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// InputError reports options or an import file that cannot be read at all,
+// as opposed to a failure to save the rows
+type InputError struct {
+	Err error
+}
+
+func (e *InputError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// rowReader yields import rows until io.EOF
+type rowReader interface {
+	Next() (*models.MovieImportRow, error)
+}
+
+func newRowReader(format string, src io.Reader) (rowReader, error) {
+	switch format {
+	case models.ImportFormatCSV:
+		return newCSVReader(src)
+	case models.ImportFormatNDJSON:
+		scanner := bufio.NewScanner(src)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &ndjsonReader{scanner: scanner}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported import format %q", format)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVReader(src io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(src)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV file is empty")
+		}
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	hasTitle := false
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !csvColumns[column] {
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+		hasTitle = hasTitle || column == "title"
+		columns[i] = column
+	}
+
+	if !hasTitle {
+		return nil, errors.New("CSV header must include a title column")
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Next() (*models.MovieImportRow, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &RowError{Line: parseErr.StartLine, Message: parseErr.Err.Error()}
+		}
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	row := &models.MovieImportRow{Line: line}
+	fields := map[string]string{}
+
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		switch column := r.columns[i]; column {
+		case "source":
+			row.Source = value
+		case "external_id":
+			row.ExternalID = value
+		case "title":
+			row.Title = &value
+		case "description":
+			row.Description = &value
+		case "release_date":
+			date, err := parseDate(value)
+			if err != nil {
+				fields[column] = "must be a date like 2006-01-02"
+				continue
+			}
+			row.ReleaseDate = &date
+		case "rating":
+			rating, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				fields[column] = "must be a number"
+				continue
+			}
+			row.Rating = &rating
+		case "duration":
+			duration, err := strconv.Atoi(value)
+			if err != nil {
+				fields[column] = "must be a whole number of minutes"
+				continue
+			}
+			row.Duration = &duration
+		case "genres":
+			for _, slug := range strings.Split(value, "|") {
+				if slug = strings.TrimSpace(slug); slug != "" {
+					row.Genres = append(row.Genres, slug)
+				}
+			}
+		case "credits":
+			if err := json.Unmarshal([]byte(value), &row.Credits); err != nil {
+				fields[column] = "must be a JSON array of credits"
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, &RowError{Line: line, Message: "Validation failed", Fields: fields}
+	}
+
+	return row, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) Next() (*models.MovieImportRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		row := &models.MovieImportRow{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(row); err != nil {
+			return nil, &RowError{Line: r.line, Message: "Invalid JSON: " + err.Error()}
+		}
+		row.Line = r.line
+
+		return row, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"time"
+)
+
+// TrashPurger periodically hard-deletes movies that have been in the trash
+// for longer than the configured retention period
+type TrashPurger struct {
+	movieRepo repository.MovieStore
+	config    *config.TrashConfig
+}
+
+func NewTrashPurger(movieRepo repository.MovieStore, config *config.TrashConfig) *TrashPurger {
+	return &TrashPurger{
+		movieRepo: movieRepo,
+		config:    config,
+	}
+}
+
+// Run purges on every interval until ctx is cancelled. It returns immediately
+// when retention is disabled.
+func (p *TrashPurger) Run(ctx context.Context) {
+	if p.config.Retention <= 0 || p.config.PurgeInterval <= 0 {
+		logger.InfoContext(ctx, "Trash purging disabled")
+		return
+	}
+
+	ticker := time.NewTicker(p.config.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	cutoff := time.Now().Add(-p.config.Retention)
+
+	purged, err := p.movieRepo.PurgeDeleted(ctx, cutoff)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.ErrorContext(ctx, "Error purging trashed movies", logger.Field("error", err))
+		}
+		return
+	}
+
+	if purged > 0 {
+		logger.InfoContext(ctx, "Purged trashed movies", logger.Field("count", purged), logger.Field("deleted_before", cutoff))
+	}
+}
@@ -0,0 +1,448 @@
+// Package jsonpatch applies JSON Patch (RFC 6902) and JSON Merge Patch
+// (RFC 7396) documents to JSON values.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned when a patch document is malformed
+	ErrInvalidPatch = errors.New("invalid patch document")
+	// ErrPathNotFound is returned when an operation targets a location that does not exist
+	ErrPathNotFound = errors.New("patch path not found")
+	// ErrTestFailed is returned when a test operation does not match
+	ErrTestFailed = errors.New("patch test operation failed")
+)
+
+// Operation is a single JSON Patch operation. Path, From and Value are nil
+// when the member is missing; a null value is kept as the JSON null literal.
+type Operation struct {
+	Op    string
+	Path  *string
+	From  *string
+	Value *json.RawMessage
+}
+
+// UnmarshalJSON decodes an operation, telling a "value": null apart from a
+// missing value. Members the operation doesn't use are ignored.
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	if op, ok := members["op"]; ok {
+		if err := json.Unmarshal(op, &o.Op); err != nil {
+			return err
+		}
+	}
+
+	if path, ok := members["path"]; ok {
+		if err := json.Unmarshal(path, &o.Path); err != nil {
+			return err
+		}
+	}
+
+	if from, ok := members["from"]; ok {
+		if err := json.Unmarshal(from, &o.From); err != nil {
+			return err
+		}
+	}
+
+	if value, ok := members["value"]; ok {
+		o.Value = &value
+	}
+
+	return nil
+}
+
+// Apply applies a JSON Patch document to doc and returns the patched document.
+// Operations are applied in order and the whole patch fails if any of them does.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, fmt.Errorf("%w: operation %d is missing path", ErrInvalidPatch, i)
+		}
+
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d is missing value", ErrInvalidPatch, i)
+			}
+			value, err := decode(*op.Value)
+			if err != nil {
+				return nil, err
+			}
+
+			switch op.Op {
+			case "add":
+				target, err = add(target, path, value)
+			case "replace":
+				target, err = replace(target, path, value)
+			case "test":
+				err = test(target, path, value)
+			}
+			if err != nil {
+				return nil, err
+			}
+		case "remove":
+			if target, _, err = remove(target, path); err != nil {
+				return nil, err
+			}
+		case "move", "copy":
+			if op.From == nil {
+				return nil, fmt.Errorf("%w: operation %d is missing from", ErrInvalidPatch, i)
+			}
+			from, err := parsePointer(*op.From)
+			if err != nil {
+				return nil, err
+			}
+
+			var value interface{}
+			if op.Op == "move" {
+				if isPrefix(from, path) && len(from) < len(path) {
+					return nil, fmt.Errorf("%w: cannot move a value into one of its children", ErrInvalidPatch)
+				}
+				target, value, err = remove(target, from)
+			} else {
+				value, err = get(target, from)
+				if err == nil {
+					value, err = clone(value)
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if target, err = add(target, path, value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+// MergePatch applies a JSON Merge Patch document to doc and returns the
+// patched document
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergePatch(target, p))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func clone(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses an array reference token. allowEnd accepts "-" and the
+// index one past the last element, which are only valid when adding.
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrPathNotFound, token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrPathNotFound, token)
+	}
+
+	if index > length || (index == length && !allowEnd) {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrPathNotFound, index)
+	}
+
+	return index, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	current := doc
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+		}
+	}
+
+	return current, nil
+}
+
+// update walks to the parent of path and replaces it with the result of fn,
+// rebuilding the containers on the way back up. An empty path replaces the
+// whole document.
+func update(doc interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 0 {
+		return fn(nil, "")
+	}
+
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	child, err := get(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := update(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		node[path[0]] = updated
+		return node, nil
+	case []interface{}:
+		index, _ := arrayIndex(path[0], len(node), false)
+		node[index] = updated
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrPathNotFound, path[0])
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+	})
+}
+
+func replace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if _, err := get(doc, path); err != nil {
+		return nil, err
+	}
+
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, _ := arrayIndex(token, len(node), false)
+			node[index] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+	})
+}
+
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+
+	removed, err := get(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err = update(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, _ := arrayIndex(token, len(node), false)
+			return append(node[:index], node[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return doc, removed, nil
+}
+
+func test(doc interface{}, path []string, value interface{}) error {
+	current, err := get(doc, path)
+	if err != nil {
+		return err
+	}
+
+	if !equal(current, value) {
+		return fmt.Errorf("%w: value at %q does not match", ErrTestFailed, "/"+strings.Join(path, "/"))
+	}
+
+	return nil
+}
+
+// equal compares two decoded JSON values, treating numbers by value
+func equal(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		af, aerr := av.Float64()
+		bf, berr := bv.Float64()
+		if aerr != nil || berr != nil {
+			return av == bv
+		}
+		return af == bf
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,338 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// jsonEqual reports whether two JSON documents hold the same value
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var av, bv interface{}
+	if err := json.Unmarshal(a, &av); err != nil {
+		t.Fatalf("decoding %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &bv); err != nil {
+		t.Fatalf("decoding %s: %v", b, err)
+	}
+
+	return reflect.DeepEqual(av, bv)
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		err   error
+	}{
+		// RFC 6902 Appendix A
+		{
+			name:  "A.1 add an object member",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			want:  `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name:  "A.2 add an array element",
+			doc:   `{"foo": ["bar", "baz"]}`,
+			patch: `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			want:  `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name:  "A.3 remove an object member",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "remove", "path": "/baz"}]`,
+			want:  `{"foo": "bar"}`,
+		},
+		{
+			name:  "A.4 remove an array element",
+			doc:   `{"foo": ["bar", "qux", "baz"]}`,
+			patch: `[{"op": "remove", "path": "/foo/1"}]`,
+			want:  `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name:  "A.5 replace a value",
+			doc:   `{"baz": "qux", "foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			want:  `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name:  "A.6 move a value",
+			doc:   `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch: `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			want:  `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name:  "A.7 move an array element",
+			doc:   `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch: `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			want:  `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			name:  "A.8 test a value: success",
+			doc:   `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			want:  `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name:  "A.9 test a value: error",
+			doc:   `{"baz": "qux"}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "A.10 add a nested member object",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			want:  `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name:  "A.11 ignore unrecognized elements",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			want:  `{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			name:  "A.12 add to a nonexistent target",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "A.14 ~ escape ordering",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": 10}]`,
+			want:  `{"/": 9, "~1": 10}`,
+		},
+		{
+			name:  "A.15 compare strings and numbers",
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "A.16 add an array value",
+			doc:   `{"foo": ["bar"]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			want:  `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+
+		// Pointer escaping
+		{
+			name:  "~1 escapes a slash",
+			doc:   `{"a/b": 1}`,
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 2}]`,
+			want:  `{"a/b": 2}`,
+		},
+		{
+			name:  "~0 escapes a tilde",
+			doc:   `{"m~n": 1}`,
+			patch: `[{"op": "remove", "path": "/m~0n"}]`,
+			want:  `{}`,
+		},
+		{
+			name:  "path must start with a slash",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "remove", "path": "foo"}]`,
+			err:   ErrInvalidPatch,
+		},
+
+		// Null values
+		{
+			name:  "add a null value",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": null}]`,
+			want:  `{"foo": "bar", "baz": null}`,
+		},
+		{
+			name:  "replace with a null value",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "replace", "path": "/foo", "value": null}]`,
+			want:  `{"foo": null}`,
+		},
+		{
+			name:  "test a null value",
+			doc:   `{"foo": null}`,
+			patch: `[{"op": "test", "path": "/foo", "value": null}]`,
+			want:  `{"foo": null}`,
+		},
+		{
+			name:  "missing value",
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz"}]`,
+			err:   ErrInvalidPatch,
+		},
+
+		// Arrays
+		{
+			name:  "add with - appends",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/foo/-", "value": 3}]`,
+			want:  `{"foo": [1, 2, 3]}`,
+		},
+		{
+			name:  "add at the length appends",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/foo/2", "value": 3}]`,
+			want:  `{"foo": [1, 2, 3]}`,
+		},
+		{
+			name:  "add past the length",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "add", "path": "/foo/3", "value": 3}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "replace at the length",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "replace", "path": "/foo/2", "value": 3}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "remove with -",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/foo/-"}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "leading zero index",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/foo/01"}]`,
+			err:   ErrPathNotFound,
+		},
+		{
+			name:  "negative index",
+			doc:   `{"foo": [1, 2]}`,
+			patch: `[{"op": "remove", "path": "/foo/-1"}]`,
+			err:   ErrPathNotFound,
+		},
+
+		// Move and copy
+		{
+			name:  "move into a child",
+			doc:   `{"foo": {"bar": 1}}`,
+			patch: `[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "move to a sibling with a shared prefix",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "move", "from": "/foo", "path": "/foobar"}]`,
+			want:  `{"foobar": 1}`,
+		},
+		{
+			name:  "move onto itself",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "move", "from": "/foo", "path": "/foo"}]`,
+			want:  `{"foo": 1}`,
+		},
+		{
+			name:  "copy is independent of the source",
+			doc:   `{"foo": {"bar": 1}}`,
+			patch: `[{"op": "copy", "from": "/foo", "path": "/baz"}, {"op": "replace", "path": "/baz/bar", "value": 2}]`,
+			want:  `{"foo": {"bar": 1}, "baz": {"bar": 2}}`,
+		},
+		{
+			name:  "missing from",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "copy", "path": "/bar"}]`,
+			err:   ErrInvalidPatch,
+		},
+
+		// Malformed patches
+		{
+			name:  "unknown op",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "frobnicate", "path": "/foo"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "missing path",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "remove"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "not an array",
+			doc:   `{"foo": 1}`,
+			patch: `{"op": "remove", "path": "/foo"}`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "remove the whole document",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "remove", "path": ""}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "a failed operation discards earlier ones",
+			doc:   `{"foo": 1}`,
+			patch: `[{"op": "add", "path": "/bar", "value": 2}, {"op": "test", "path": "/foo", "value": 2}]`,
+			err:   ErrTestFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Apply() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("Apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// RFC 7396 Appendix A
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a": "b"}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "b"}`, `{"b": "c"}`, `{"a": "b", "b": "c"}`},
+		{`{"a": "b"}`, `{"a": null}`, `{}`},
+		{`{"a": "b", "b": "c"}`, `{"a": null}`, `{"b": "c"}`},
+		{`{"a": ["b"]}`, `{"a": "c"}`, `{"a": "c"}`},
+		{`{"a": "c"}`, `{"a": ["b"]}`, `{"a": ["b"]}`},
+		{`{"a": {"b": "c"}}`, `{"a": {"b": "d", "c": null}}`, `{"a": {"b": "d"}}`},
+		{`{"a": [{"b": "c"}]}`, `{"a": [1]}`, `{"a": [1]}`},
+		{`["a", "b"]`, `["c", "d"]`, `["c", "d"]`},
+		{`{"a": "b"}`, `["c"]`, `["c"]`},
+		{`{"a": "foo"}`, `null`, `null`},
+		{`{"a": "foo"}`, `"bar"`, `"bar"`},
+		{`{"e": null}`, `{"a": 1}`, `{"e": null, "a": 1}`},
+		{`[1, 2]`, `{"a": "b", "c": null}`, `{"a": "b"}`},
+		{`{}`, `{"a": {"bb": {"ccc": null}}}`, `{"a": {"bb": {}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch() error = %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("MergePatch(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MergePatch([]byte(`{}`), []byte(`{"a":`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("MergePatch() with a malformed patch error = %v, want %v", err, ErrInvalidPatch)
+	}
+}
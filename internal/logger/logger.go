@@ -1,13 +1,19 @@
 package logger
 
 import (
+	"context"
 	"os"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var log = logrus.New()
 
+func init() {
+	log.AddHook(traceHook{})
+}
+
 // Init initializes the logger with the specified environment configuration
 func Init(env string) {
 	// Set default formatter
@@ -46,6 +52,12 @@ func WithFields(fields map[string]interface{}) *logrus.Entry {
 	return log.WithFields(logrus.Fields(fields))
 }
 
+// WithContext creates an entry that carries the trace and span IDs of the
+// span in ctx, if any
+func WithContext(ctx context.Context) *logrus.Entry {
+	return log.WithContext(ctx)
+}
+
 // Debug logs a debug message
 func Debug(message string, fields ...logrus.Fields) {
 	if len(fields) > 0 {
@@ -86,6 +98,26 @@ func Error(message string, fields ...logrus.Fields) {
 	}
 }
 
+// DebugContext logs a debug message with the trace and span IDs of ctx
+func DebugContext(ctx context.Context, message string, fields ...logrus.Fields) {
+	log.WithContext(ctx).WithFields(mergeFields(fields...)).Debug(message)
+}
+
+// InfoContext logs an informational message with the trace and span IDs of ctx
+func InfoContext(ctx context.Context, message string, fields ...logrus.Fields) {
+	log.WithContext(ctx).WithFields(mergeFields(fields...)).Info(message)
+}
+
+// WarnContext logs a warning message with the trace and span IDs of ctx
+func WarnContext(ctx context.Context, message string, fields ...logrus.Fields) {
+	log.WithContext(ctx).WithFields(mergeFields(fields...)).Warn(message)
+}
+
+// ErrorContext logs an error message with the trace and span IDs of ctx
+func ErrorContext(ctx context.Context, message string, fields ...logrus.Fields) {
+	log.WithContext(ctx).WithFields(mergeFields(fields...)).Error(message)
+}
+
 // Fatal logs a fatal message and exits the application
 func Fatal(message string, fields ...logrus.Fields) {
 	if len(fields) > 0 {
@@ -107,6 +139,28 @@ func mergeFields(fieldsArray ...logrus.Fields) logrus.Fields {
 	return result
 }
 
+// traceHook adds the IDs of the span in an entry's context to its fields
+type traceHook struct{}
+
+func (traceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (traceHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+
+	entry.Data["trace_id"] = spanContext.TraceID().String()
+	entry.Data["span_id"] = spanContext.SpanID().String()
+	return nil
+}
+
 // GetLogger returns the underlying logrus logger
 func GetLogger() *logrus.Logger {
 	return log
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/marchelhutagalung/go-service/internal/auth"
+	"github.com/marchelhutagalung/go-service/internal/models"
 	"github.com/marchelhutagalung/go-service/internal/response"
 	"net/http"
 	"strings"
@@ -12,7 +13,11 @@ import (
 type contextKey string
 
 const (
-	UserIDKey contextKey = "userID"
+	UserIDKey   contextKey = "userID"
+	UserRoleKey contextKey = "userRole"
+
+	// authResultKey holds the *authResult of a request once its token is checked
+	authResultKey contextKey = "authResult"
 )
 
 type Middleware struct {
@@ -25,44 +30,97 @@ func NewMiddleware(jwtService *auth.JWTService) *Middleware {
 	}
 }
 
+// authResult is the outcome of checking a request's bearer token. Either
+// claims is set, or status and message describe why the request is anonymous.
+type authResult struct {
+	claims  *auth.Claims
+	status  int
+	message string
+}
+
+// authenticate checks the bearer token of r once per request. The result is
+// kept in the returned request's context, so the rate limiter, OptionalAuth
+// and RequireAuth share a single token validation.
+func (m *Middleware) authenticate(r *http.Request) (*http.Request, *authResult) {
+	if result, ok := r.Context().Value(authResultKey).(*authResult); ok {
+		return r, result
+	}
+
+	result := m.validate(r)
+	ctx := context.WithValue(r.Context(), authResultKey, result)
+	if result.claims != nil {
+		ctx = withClaims(ctx, result.claims)
+	}
+
+	return r.WithContext(ctx), result
+}
+
+func (m *Middleware) validate(r *http.Request) *authResult {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return &authResult{status: http.StatusUnauthorized, message: "Authorization header required"}
+	}
+
+	headerParts := strings.Split(authHeader, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return &authResult{status: http.StatusUnauthorized, message: "Invalid authorization header format"}
+	}
+
+	claims, err := m.jwtService.ValidateToken(r.Context(), headerParts[1])
+	if err != nil {
+		if errors.Is(err, auth.ErrExpiredToken) {
+			return &authResult{status: http.StatusUnauthorized, message: "Token has expired"}
+		}
+		return &authResult{status: http.StatusForbidden, message: "Invalid token"}
+	}
+
+	return &authResult{claims: claims}
+}
+
 // RequireAuth is a middleware that requires JWT authentication
 func (m *Middleware) RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			response.ErrorResponse(w, http.StatusUnauthorized, "Authorization header required")
+		r, result := m.authenticate(r)
+		if result.claims == nil {
+			response.ErrorResponse(w, result.status, result.message)
 			return
 		}
 
-		headerParts := strings.Split(authHeader, " ")
-		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
-			response.ErrorResponse(w, http.StatusUnauthorized, "Invalid authorization header format")
-			return
-		}
-
-		tokenString := headerParts[1]
+		next.ServeHTTP(w, r)
+	})
+}
 
-		claims, err := m.jwtService.ValidateToken(tokenString)
-		if err != nil {
-			var statusCode int
-			var message string
+// OptionalAuth attaches the user ID to the context when a valid bearer token
+// is present, and otherwise lets the request through anonymously
+func (m *Middleware) OptionalAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, _ = m.authenticate(r)
+		next.ServeHTTP(w, r)
+	})
+}
 
-			if errors.Is(err, auth.ErrExpiredToken) {
-				statusCode = http.StatusUnauthorized
-				message = "Token has expired"
-			} else {
-				statusCode = http.StatusForbidden
-				message = "Invalid token"
+// RequireRole is a middleware that only lets through users whose role grants
+// at least minRole. It must run after RequireAuth.
+func RequireRole(minRole string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := GetUserRole(r.Context())
+			if !models.HasRole(role, minRole) {
+				response.ErrorResponse(w, http.StatusForbidden, "Insufficient permissions")
+				return
 			}
 
-			response.ErrorResponse(w, statusCode, message)
-			return
-		}
-
-		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// withClaims attaches the authenticated user to the context. Movie writes
+// made with it are recorded as edits by that user.
+func withClaims(ctx context.Context, claims *auth.Claims) context.Context {
+	ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+	ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+	return models.WithEditor(ctx, claims.UserID)
 }
 
 // GetUserID extracts the user ID from the request context
@@ -70,3 +128,9 @@ func GetUserID(ctx context.Context) (int64, bool) {
 	userID, ok := ctx.Value(UserIDKey).(int64)
 	return userID, ok
 }
+
+// GetUserRole extracts the user role from the request context
+func GetUserRole(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(UserRoleKey).(string)
+	return role, ok
+}
@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/idempotency"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader is the header clients send the key of a request in
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyPollInterval is how often a duplicate checks whether the
+	// request it waits for has finished
+	idempotencyPollInterval = 100 * time.Millisecond
+)
+
+// replayedHeaders are the response headers stored and replayed along with
+// the status code and body
+var replayedHeaders = []string{"Content-Type", "ETag", "Location", "Last-Modified"}
+
+var errIdempotencyKeyInFlight = errors.New("request with this key is still in flight")
+
+// Idempotency replays the response of a request sent again with the same
+// Idempotency-Key instead of handling it twice
+type Idempotency struct {
+	store  idempotency.Store
+	config *config.IdempotencyConfig
+	// redacted are the fields left out of the data of stored responses
+	redacted []string
+}
+
+// NewIdempotency creates an Idempotency keeping responses in store
+func NewIdempotency(store idempotency.Store, cfg *config.IdempotencyConfig) *Idempotency {
+	return &Idempotency{
+		store:  store,
+		config: cfg,
+	}
+}
+
+// Redact returns a copy of m that leaves fields out of the response data it
+// stores, for secrets such as tokens that must not be handed out again. A
+// replay then answers without them.
+func (m *Idempotency) Redact(fields ...string) *Idempotency {
+	if m == nil {
+		return nil
+	}
+
+	redacted := *m
+	redacted.redacted = append(append([]string(nil), m.redacted...), fields...)
+	return &redacted
+}
+
+// Handle honors the Idempotency-Key header of requests to next. The first
+// request with a key is handled and its response stored; repeats with the
+// same body get that response replayed, and with a different body a 409.
+// A repeat arriving while the first is in flight waits for its response.
+// Keys are scoped to the route and the signed-in user, so Handle must run
+// after RequireAuth on authenticated routes. A nil Idempotency does nothing.
+func (m *Idempotency) Handle(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			response.ErrorResponse(w, http.StatusBadRequest, "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLength)+" characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, m.config.MaxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				response.ErrorResponse(w, http.StatusRequestEntityTooLarge, "Request body too large")
+				return
+			}
+			logger.ErrorContext(r.Context(), "Invalid request body", logger.Field("error", err))
+			response.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := m.scopedKey(r, key)
+		fingerprint := requestFingerprint(r, body)
+
+		record, err := m.acquire(r.Context(), storeKey, fingerprint)
+		switch {
+		case errors.Is(err, errIdempotencyKeyInFlight):
+			response.ErrorResponse(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+			return
+		case err != nil:
+			// Without the store the request can still be served, just not
+			// deduplicated
+			logger.ErrorContext(r.Context(), "Error checking idempotency key", logger.Field("error", err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if record != nil {
+			if record.Fingerprint != fingerprint {
+				response.ErrorResponse(w, http.StatusConflict, "Idempotency-Key was already used for a different request")
+				return
+			}
+
+			replay(w, record)
+			return
+		}
+
+		var recorded bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&recorded)
+
+		completed := false
+		defer func() {
+			// Release the key if the handler panicked or failed on our side,
+			// so the client can retry
+			if !completed {
+				if err := m.store.Delete(context.WithoutCancel(r.Context()), storeKey); err != nil {
+					logger.ErrorContext(r.Context(), "Error releasing idempotency key", logger.Field("error", err))
+				}
+			}
+		}()
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= 500 {
+			return
+		}
+
+		stored, err := redactData(recorded.Bytes(), m.redacted)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "Error redacting idempotent response", logger.Field("error", err))
+			return
+		}
+
+		done := &idempotency.Record{
+			Fingerprint: fingerprint,
+			Done:        true,
+			StatusCode:  status,
+			Header:      http.Header{},
+			Body:        stored,
+		}
+		for _, name := range replayedHeaders {
+			if values := w.Header().Values(name); len(values) > 0 {
+				done.Header[name] = values
+			}
+		}
+
+		if err := m.store.Save(context.WithoutCancel(r.Context()), storeKey, done, m.config.TTL); err != nil {
+			logger.ErrorContext(r.Context(), "Error saving idempotent response", logger.Field("error", err))
+			return
+		}
+		completed = true
+	})
+}
+
+// acquire locks key for a new request and returns nil, or returns the
+// finished record of an earlier one. A request still in flight is waited for
+// until it finishes or the wait times out.
+func (m *Idempotency) acquire(ctx context.Context, key, fingerprint string) (*idempotency.Record, error) {
+	deadline := time.Now().Add(m.config.WaitTimeout)
+
+	for {
+		locked, err := m.store.Acquire(ctx, key, &idempotency.Record{Fingerprint: fingerprint}, m.config.LockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if locked {
+			return nil, nil
+		}
+
+		record, err := m.store.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if record == nil {
+			// Released or expired in between; try to take it
+			continue
+		}
+		if record.Done || record.Fingerprint != fingerprint {
+			return record, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errIdempotencyKeyInFlight
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// scopedKey is the store key of an Idempotency-Key, scoped to the route and
+// the user so clients cannot see each other's responses
+func (m *Idempotency) scopedKey(r *http.Request, key string) string {
+	owner := "anonymous"
+	if userID, ok := GetUserID(r.Context()); ok {
+		owner = strconv.FormatInt(userID, 10)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return owner + ":" + r.Method + ":" + r.URL.Path + ":" + hex.EncodeToString(sum[:])
+}
+
+// requestFingerprint identifies the content of a request
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// redactData removes fields from the data object of a response envelope
+func redactData(body []byte, fields []string) ([]byte, error) {
+	if len(fields) == 0 {
+		return body, nil
+	}
+
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+
+	var data map[string]json.RawMessage
+	if raw, ok := envelope["data"]; !ok || json.Unmarshal(raw, &data) != nil || data == nil {
+		// Nothing to leave out of an error or a non-object
+		return body, nil
+	}
+	for _, field := range fields {
+		delete(data, field)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	envelope["data"] = raw
+
+	return json.Marshal(envelope)
+}
+
+// replay writes a stored response
+func replay(w http.ResponseWriter, record *idempotency.Record) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
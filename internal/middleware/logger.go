@@ -30,7 +30,7 @@ func RequestLogger(next http.Handler) http.Handler {
 			}
 
 			// Log with appropriate level based on status code
-			entry := logger.WithFields(fields)
+			entry := logger.WithContext(r.Context()).WithFields(fields)
 			statusCode := ww.Status()
 
 			switch {
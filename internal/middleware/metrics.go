@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/marchelhutagalung/go-service/internal/metrics"
+	"net/http"
+	"time"
+)
+
+// unmatchedRoute labels requests no route matched, so probes of random paths
+// cannot blow up the number of series
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count, latency and in-flight requests of every route
+// in routes. Requests are labelled by route pattern rather than path, which
+// is resolved up front so in-flight requests can carry it too.
+func Metrics(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path)
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			inFlight := metrics.HTTPInFlight.WithLabelValues(r.Method, route)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				code := ww.Status()
+				if code == 0 {
+					// Nothing was written, which net/http answers with 200
+					code = http.StatusOK
+				}
+
+				status := metrics.StatusClass(code)
+				metrics.HTTPRequests.WithLabelValues(r.Method, route, status).Inc()
+				metrics.HTTPDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+			}()
+
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/ratelimit"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIKeyHeader is the header clients send their API key in
+const APIKeyHeader = "X-API-Key"
+
+// RateLimiter limits the requests of each client per route group
+type RateLimiter struct {
+	limiter ratelimit.Limiter
+	auth    *Middleware
+	limits  map[string]ratelimit.Limit
+	apiKeys map[string]bool
+}
+
+// NewRateLimiter creates a RateLimiter with the group limits of cfg. auth
+// identifies signed-in users so they are limited by account rather than IP.
+func NewRateLimiter(limiter ratelimit.Limiter, cfg *config.RateLimitConfig, auth *Middleware) *RateLimiter {
+	apiKeys := make(map[string]bool, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		apiKeys[key] = true
+	}
+
+	return &RateLimiter{
+		limiter: limiter,
+		auth:    auth,
+		limits: map[string]ratelimit.Limit{
+			"auth":   ratelimit.Limit(cfg.Auth),
+			"users":  ratelimit.Limit(cfg.Users),
+			"movies": ratelimit.Limit(cfg.Movies),
+			"genres": ratelimit.Limit(cfg.Genres),
+			"lists":  ratelimit.Limit(cfg.Lists),
+			"people": ratelimit.Limit(cfg.People),
+		},
+		apiKeys: apiKeys,
+	}
+}
+
+// Limit limits the requests to a route group, answering 429 once a client
+// has used up its quota. Responses carry RateLimit-* headers, and Retry-After
+// when limited. A nil RateLimiter does not limit.
+func (rl *RateLimiter) Limit(group string) func(http.Handler) http.Handler {
+	if rl == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	limit, ok := rl.limits[group]
+	if !ok {
+		panic(fmt.Sprintf("no rate limit for route group %q", group))
+	}
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		limited := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := group + ":" + rl.clientKey(r)
+
+			result, err := rl.limiter.Allow(r.Context(), key, limit)
+			if err != nil {
+				// Better to serve unlimited than not at all
+				logger.ErrorContext(r.Context(), "Error checking rate limit", logger.Field("error", err), logger.Field("key", key))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Policy", policy)
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				logger.WarnContext(r.Context(), "Rate limit exceeded", logger.Field("key", key))
+				response.ErrorResponse(w, http.StatusTooManyRequests, "Too many requests")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+
+		return rl.auth.OptionalAuth(limited)
+	}
+}
+
+// clientKey identifies the client of r: the signed-in user, a known API key
+// or the client IP, in that order
+func (rl *RateLimiter) clientKey(r *http.Request) string {
+	if userID, ok := GetUserID(r.Context()); ok {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+
+	if key := r.Header.Get(APIKeyHeader); key != "" && rl.apiKeys[key] {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+
+	// RealIP has already replaced RemoteAddr with the client IP, which only
+	// comes from forwarded headers when a trusted proxy sent them
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return "ip:" + ip
+}
+
+// ceilSeconds rounds d up to whole seconds, as the headers expect
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP replaces RemoteAddr with the client IP. X-Forwarded-For and X-Real-IP
+// are only believed when the connection comes from one of the trusted
+// proxies, since anyone else can set them to whatever they like. The client
+// is the last address in X-Forwarded-For that is not a trusted proxy itself.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+
+			peer, err := netip.ParseAddr(host)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client := peer
+			if isTrusted(peer) {
+				client = forwardedClient(r, peer, isTrusted)
+			}
+
+			r.RemoteAddr = client.Unmap().String()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient walks X-Forwarded-For from the nearest hop back, stopping at
+// the first address that is not a trusted proxy. Without the header it falls
+// back to X-Real-IP, and to the peer when neither holds a valid address.
+func forwardedClient(r *http.Request, peer netip.Addr, isTrusted func(netip.Addr) bool) netip.Addr {
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+
+		addr, err := netip.ParseAddr(hop)
+		if err != nil {
+			return client
+		}
+
+		client = addr
+		if !isTrusted(addr) {
+			return client
+		}
+	}
+
+	if client != peer {
+		return client
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr
+	}
+
+	return peer
+}
@@ -15,7 +15,7 @@ func Recovery(next http.Handler) http.Handler {
 				stackTrace := string(debug.Stack())
 
 				// Log the panic with details
-				logger.WithFields(map[string]interface{}{
+				logger.WithContext(r.Context()).WithFields(map[string]interface{}{
 					"error":       err,
 					"stack":       stackTrace,
 					"path":        r.URL.Path,
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/marchelhutagalung/go-service/internal/response"
+	"net/http"
+	"time"
+)
+
+// Timeout cancels the request context after d, or after the timeout listed in
+// routes for the request's route pattern. Handlers are expected to stop once
+// the context is done. The 504 is only sent if they had not started a
+// response by then, so a long stream is cut short rather than corrupted.
+func Timeout(router chi.Routes, d time.Duration, routes map[string]time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := d
+			if routeTimeout, ok := routes[router.Find(chi.NewRouteContext(), r.Method, r.URL.Path)]; ok {
+				timeout = routeTimeout
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && ww.Status() == 0 {
+				response.ErrorResponse(w, http.StatusGatewayTimeout, "Request timed out")
+			}
+		})
+	}
+}
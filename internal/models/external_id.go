@@ -0,0 +1,21 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+var externalSourcePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// ExternalID identifies a movie in an external catalogue such as IMDb or TMDB
+type ExternalID struct {
+	Source string `json:"source"`
+	ID     string `json:"id"`
+}
+
+// NormalizeExternalSource lowercases a source name and reports whether it is
+// valid: up to 50 letters, digits, dashes or underscores
+func NormalizeExternalSource(source string) (string, bool) {
+	source = strings.ToLower(strings.TrimSpace(source))
+	return source, externalSourcePattern.MatchString(source)
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+type Genre struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateGenreInput struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"` // optional, derived from name when empty
+}
+
+type UpdateGenreInput struct {
+	Name *string `json:"name"`
+	Slug *string `json:"slug"`
+}
+
+// Slugify turns a genre name into its canonical slug, so that "Sci Fi",
+// "sci-fi" and "SCI/FI" all resolve to "sci-fi".
+func Slugify(s string) string {
+	var b strings.Builder
+	pendingDash := false
+
+	for _, c := range strings.ToLower(s) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(c)
+			pendingDash = false
+			continue
+		}
+		pendingDash = true
+	}
+
+	return b.String()
+}
@@ -1,46 +1,135 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Movie struct {
-	ID          int64     `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	ReleaseDate time.Time `json:"release_date"`
-	Rating      float64   `json:"rating"`
-	Duration    int       `json:"duration"` // in minutes
-	Genre       string    `json:"genre"`
-	Director    string    `json:"director"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            int64                  `json:"id"`
+	Title         string                 `json:"title"`
+	Description   string                 `json:"description"`
+	ReleaseDate   time.Time              `json:"release_date"`
+	Rating        float64                `json:"rating"`
+	AverageRating float64                `json:"average_rating"` // mean of user review ratings
+	RatingCount   int                    `json:"rating_count"`
+	Duration      int                    `json:"duration"` // in minutes
+	Genres        []*Genre               `json:"genres"`
+	Credits       []*Credit              `json:"credits"`
+	ExternalIDs   []*ExternalID          `json:"external_ids"`
+	Images        map[string]*MovieImage `json:"images"` // keyed by image kind
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	DeletedAt     *time.Time             `json:"deleted_at,omitempty"`
+	Version       int                    `json:"version"` // incremented on every write, used as the ETag
+	Status        string                 `json:"status"`
+	PublishAt     *time.Time             `json:"publish_at"` // a published movie stays hidden until then
+
+	// UserStatus is only set when an authenticated user reads a single movie
+	UserStatus *MovieUserStatus `json:"user_status,omitempty"`
 }
 
 type CreateMovieInput struct {
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	ReleaseDate time.Time `json:"release_date"`
-	Rating      float64   `json:"rating"`
-	Duration    int       `json:"duration"`
-	Genre       string    `json:"genre"`
-	Director    string    `json:"director"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	ReleaseDate time.Time     `json:"release_date"`
+	Rating      float64       `json:"rating"`
+	Duration    int           `json:"duration"`
+	Genres      []string      `json:"genres"` // genre slugs
+	Credits     []CreditInput `json:"credits"`
 }
 
 type UpdateMovieInput struct {
-	Title       *string    `json:"title"`
-	Description *string    `json:"description"`
-	ReleaseDate *time.Time `json:"release_date"`
-	Rating      *float64   `json:"rating"`
-	Duration    *int       `json:"duration"`
-	Genre       *string    `json:"genre"`
-	Director    *string    `json:"director"`
+	Title       *string        `json:"title"`
+	Description *string        `json:"description"`
+	ReleaseDate *time.Time     `json:"release_date"`
+	Rating      *float64       `json:"rating"`
+	Duration    *int           `json:"duration"`
+	Genres      *[]string      `json:"genres"`  // replaces the movie's genres when set
+	Credits     *[]CreditInput `json:"credits"` // replaces the movie's credits when set
+}
+
+// ReplaceMovieInput is the body of a full replacement. Title, release date
+// and duration are required; any other field that is missing or null is
+// cleared.
+type ReplaceMovieInput struct {
+	Title       *string       `json:"title"`
+	Description *string       `json:"description"`
+	ReleaseDate *time.Time    `json:"release_date"`
+	Rating      *float64      `json:"rating"`
+	Duration    *int          `json:"duration"`
+	Genres      []string      `json:"genres"`  // genre slugs
+	Credits     []CreditInput `json:"credits"` // replaces the movie's credits
+}
+
+// Validate checks the required fields and credits of a replacement,
+// returning the problems keyed by field
+func (in *ReplaceMovieInput) Validate() map[string]string {
+	errs := ValidateCredits(in.Credits)
+	if in.Title == nil || strings.TrimSpace(*in.Title) == "" {
+		errs["title"] = "is required"
+	}
+	if in.ReleaseDate == nil {
+		errs["release_date"] = "is required"
+	}
+	if in.Duration == nil {
+		errs["duration"] = "is required"
+	}
+	return errs
+}
+
+// UpdateInput returns an update that sets every field of the movie
+func (in *ReplaceMovieInput) UpdateInput() *UpdateMovieInput {
+	var description string
+	if in.Description != nil {
+		description = *in.Description
+	}
+
+	var rating float64
+	if in.Rating != nil {
+		rating = *in.Rating
+	}
+
+	genres := append([]string{}, in.Genres...)
+	credits := append([]CreditInput{}, in.Credits...)
+
+	return &UpdateMovieInput{
+		Title:       in.Title,
+		Description: &description,
+		ReleaseDate: in.ReleaseDate,
+		Rating:      &rating,
+		Duration:    in.Duration,
+		Genres:      &genres,
+		Credits:     &credits,
+	}
+}
+
+// CreateInput returns the replacement as the input for a new movie
+func (in *ReplaceMovieInput) CreateInput() *CreateMovieInput {
+	update := in.UpdateInput()
+
+	return &CreateMovieInput{
+		Title:       *update.Title,
+		Description: *update.Description,
+		ReleaseDate: *update.ReleaseDate,
+		Rating:      *update.Rating,
+		Duration:    *update.Duration,
+		Genres:      *update.Genres,
+		Credits:     *update.Credits,
+	}
 }
 
 type MovieQuery struct {
 	Title    string `json:"title"`
 	Genre    string `json:"genre"`
 	Director string `json:"director"`
+	Status   string `json:"status"`
 	SortBy   string `json:"sort_by"`
 	Order    string `json:"order"`
 	Page     int    `json:"page"`
 	PageSize int    `json:"page_size"`
+
+	// IncludeUnpublished lists movies that are not public yet. Only editors
+	// may set it.
+	IncludeUnpublished bool `json:"-"`
 }
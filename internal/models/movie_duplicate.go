@@ -0,0 +1,34 @@
+package models
+
+// Duplicate detection defaults
+const (
+	DefaultDuplicateSimilarity = 0.6
+	MinDuplicateSimilarity     = 0.3
+)
+
+// DuplicateCandidate is a pair of movies that are likely the same title:
+// similar titles released in the same year, sharing a director when both
+// have one
+type DuplicateCandidate struct {
+	Movie          *Movie  `json:"movie"`
+	Duplicate      *Movie  `json:"duplicate"`
+	Similarity     float64 `json:"similarity"` // trigram similarity of the titles, 0 to 1
+	SharedDirector bool    `json:"shared_director"`
+}
+
+type MergeMovieInput struct {
+	DuplicateID int64 `json:"duplicate_id"`
+}
+
+// MovieMergeResult reports what was moved from the duplicate onto the
+// surviving movie. Rows that would clash with the survivor's own, such as a
+// second review by the same user, stay with the trashed duplicate.
+type MovieMergeResult struct {
+	Movie            *Movie `json:"movie"`
+	DuplicateID      int64  `json:"duplicate_id"`
+	MovedReviews     int64  `json:"moved_reviews"`
+	MovedListItems   int64  `json:"moved_list_items"`
+	MovedSavedMovies int64  `json:"moved_saved_movies"`
+	MovedCredits     int64  `json:"moved_credits"`
+	MovedExternalIDs int64  `json:"moved_external_ids"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// Movie image kinds
+const (
+	ImageKindPoster   = "poster"
+	ImageKindBackdrop = "backdrop"
+)
+
+// ThumbnailWidths lists the widths thumbnails are rendered at for each image kind
+var ThumbnailWidths = map[string][]int{
+	ImageKindPoster:   {185, 342, 500},
+	ImageKindBackdrop: {300, 780, 1280},
+}
+
+// MovieImage is an uploaded poster or backdrop
+type MovieImage struct {
+	Key         string            `json:"-"`
+	URL         string            `json:"url"`
+	ContentType string            `json:"content_type"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Thumbnails  []*ImageThumbnail `json:"thumbnails"`
+}
+
+// ImageThumbnail is a resized JPEG copy of a movie image
+type ImageThumbnail struct {
+	Key    string `json:"-"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// Keys returns the blob store keys of the image and its thumbnails
+func (i *MovieImage) Keys() []string {
+	keys := []string{i.Key}
+	for _, thumbnail := range i.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+	return keys
+}
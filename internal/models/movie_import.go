@@ -0,0 +1,53 @@
+package models
+
+// Import transaction modes
+const (
+	// ImportModeAllOrNothing imports every row in one transaction and rolls
+	// it back if any row fails
+	ImportModeAllOrNothing = "all_or_nothing"
+	// ImportModeBestEffort commits each batch, skipping the rows that fail
+	ImportModeBestEffort = "best_effort"
+)
+
+// Import file formats
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+)
+
+// MovieImportRow is a single movie read from an import file. Rows with an
+// external ID update the movie already linked to it, if any.
+type MovieImportRow struct {
+	Line       int    `json:"-"`
+	Source     string `json:"source"`
+	ExternalID string `json:"external_id"`
+	ReplaceMovieInput
+}
+
+type MovieImportOptions struct {
+	Format    string
+	Mode      string
+	DryRun    bool
+	BatchSize int
+	Source    string // default source for rows that don't name one
+	Status    string // workflow status of the movies created, published by default
+}
+
+// MovieImportRowError describes why a row was not imported
+type MovieImportRowError struct {
+	Line       int               `json:"line"`
+	ExternalID string            `json:"external_id,omitempty"`
+	Message    string            `json:"message"`
+	Fields     map[string]string `json:"fields,omitempty"`
+}
+
+type MovieImportResult struct {
+	Mode      string                 `json:"mode"`
+	DryRun    bool                   `json:"dry_run"`
+	Committed bool                   `json:"committed"` // false for dry runs and failed all-or-nothing imports
+	Total     int                    `json:"total"`
+	Created   int                    `json:"created"`
+	Updated   int                    `json:"updated"`
+	Failed    int                    `json:"failed"`
+	Errors    []*MovieImportRowError `json:"errors"`
+}
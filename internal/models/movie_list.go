@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// List visibilities
+const (
+	ListVisibilityPrivate  = "private"
+	ListVisibilityUnlisted = "unlisted" // readable by anyone with the link, never browsable
+	ListVisibilityPublic   = "public"
+)
+
+// ValidListVisibilities lists the accepted visibility values
+var ValidListVisibilities = map[string]bool{
+	ListVisibilityPrivate:  true,
+	ListVisibilityUnlisted: true,
+	ListVisibilityPublic:   true,
+}
+
+// MovieList is an ordered, user-curated list of movies
+type MovieList struct {
+	ID          int64            `json:"id"`
+	UserID      int64            `json:"user_id"`
+	Owner       string           `json:"owner"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Visibility  string           `json:"visibility"`
+	ItemCount   int              `json:"item_count"`
+	ViewCount   int64            `json:"view_count"`
+	Items       []*MovieListItem `json:"items,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type MovieListItem struct {
+	Position int       `json:"position"`
+	Note     string    `json:"note"`
+	AddedAt  time.Time `json:"added_at"`
+	Movie    *Movie    `json:"movie"`
+}
+
+type CreateMovieListInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"` // defaults to private
+}
+
+type UpdateMovieListInput struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Visibility  *string `json:"visibility"`
+}
+
+type AddMovieListItemInput struct {
+	MovieID  int64  `json:"movie_id"`
+	Note     string `json:"note"`
+	Position int    `json:"position"` // 1-based; appended to the end when zero
+}
+
+type UpdateMovieListItemInput struct {
+	Note string `json:"note"`
+}
+
+type ReorderMovieListInput struct {
+	MovieIDs []int64 `json:"movie_ids"` // every movie on the list, in the new order
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Movie revision actions
+const (
+	RevisionActionCreate     = "create"
+	RevisionActionUpdate     = "update"
+	RevisionActionDelete     = "delete"
+	RevisionActionRestore    = "restore"
+	RevisionActionRevert     = "revert"
+	RevisionActionMerge      = "merge"
+	RevisionActionTransition = "transition"
+)
+
+type editorKey struct{}
+
+// WithEditor returns a context whose movie writes are recorded as made by the
+// given user
+func WithEditor(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, editorKey{}, userID)
+}
+
+// EditorFromContext returns the user that movie writes made with ctx are
+// recorded against
+func EditorFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(editorKey{}).(int64)
+	return userID, ok
+}
+
+// MovieRevision is an immutable record of a single change to a movie
+type MovieRevision struct {
+	ID        int64                  `json:"id"`
+	MovieID   int64                  `json:"movie_id"`
+	Revision  int                    `json:"revision"`
+	Action    string                 `json:"action"`
+	EditorID  *int64                 `json:"editor_id"`
+	Snapshot  *MovieSnapshot         `json:"snapshot,omitempty"` // only set when a single revision is read
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// MovieSnapshot is the editable state of a movie at a given revision. The
+// workflow status is recorded for the history but is not restored by a revert.
+type MovieSnapshot struct {
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	ReleaseDate time.Time     `json:"release_date"`
+	Rating      float64       `json:"rating"`
+	Duration    int           `json:"duration"`
+	Genres      []string      `json:"genres"` // genre slugs
+	Credits     []CreditInput `json:"credits"`
+	Status      string        `json:"status,omitempty"`
+	PublishAt   *time.Time    `json:"publish_at,omitempty"`
+}
+
+// FieldChange holds the old and new JSON values of a changed field. Old is
+// null for fields set when the movie was created.
+type FieldChange struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+// NewMovieSnapshot captures the editable fields of a movie
+func NewMovieSnapshot(movie *Movie) *MovieSnapshot {
+	snapshot := &MovieSnapshot{
+		Title:       movie.Title,
+		Description: movie.Description,
+		ReleaseDate: movie.ReleaseDate,
+		Rating:      movie.Rating,
+		Duration:    movie.Duration,
+		Status:      movie.Status,
+		PublishAt:   movie.PublishAt,
+		Genres:      make([]string, 0, len(movie.Genres)),
+		Credits:     make([]CreditInput, 0, len(movie.Credits)),
+	}
+
+	for _, genre := range movie.Genres {
+		snapshot.Genres = append(snapshot.Genres, genre.Slug)
+	}
+
+	for _, credit := range movie.Credits {
+		snapshot.Credits = append(snapshot.Credits, CreditInput{
+			PersonID:     credit.PersonID,
+			Role:         credit.Role,
+			Character:    credit.Character,
+			BillingOrder: credit.BillingOrder,
+		})
+	}
+
+	return snapshot
+}
+
+// UpdateInput returns an update that restores every editable field to the
+// snapshot's values
+func (s *MovieSnapshot) UpdateInput() *UpdateMovieInput {
+	genres := append([]string{}, s.Genres...)
+	credits := append([]CreditInput{}, s.Credits...)
+
+	return &UpdateMovieInput{
+		Title:       &s.Title,
+		Description: &s.Description,
+		ReleaseDate: &s.ReleaseDate,
+		Rating:      &s.Rating,
+		Duration:    &s.Duration,
+		Genres:      &genres,
+		Credits:     &credits,
+	}
+}
+
+// DiffMovieSnapshots compares two snapshots field by field using their JSON
+// encoding. A nil before reports every field as new.
+func DiffMovieSnapshots(before, after *MovieSnapshot) (map[string]FieldChange, error) {
+	afterFields, err := snapshotFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	beforeFields := map[string]json.RawMessage{}
+	if before != nil {
+		if beforeFields, err = snapshotFields(before); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := map[string]FieldChange{}
+	for field, value := range afterFields {
+		old, ok := beforeFields[field]
+		if ok && bytes.Equal(old, value) {
+			continue
+		}
+		if !ok {
+			old = json.RawMessage("null")
+		}
+		changes[field] = FieldChange{Old: old, New: value}
+	}
+
+	return changes, nil
+}
+
+func snapshotFields(snapshot *MovieSnapshot) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
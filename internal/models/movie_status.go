@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Movie editorial statuses
+const (
+	MovieStatusDraft     = "draft"
+	MovieStatusInReview  = "in_review"
+	MovieStatusPublished = "published"
+	MovieStatusArchived  = "archived"
+)
+
+// ValidMovieStatuses lists the statuses a movie can be in
+var ValidMovieStatuses = map[string]bool{
+	MovieStatusDraft:     true,
+	MovieStatusInReview:  true,
+	MovieStatusPublished: true,
+	MovieStatusArchived:  true,
+}
+
+// MovieTransition is a named move between two statuses. The role needed to
+// make it is enforced by the routes.
+type MovieTransition struct {
+	From string
+	To   string
+}
+
+// Movie workflow transitions, keyed by name
+var MovieTransitions = map[string]MovieTransition{
+	"submit":  {From: MovieStatusDraft, To: MovieStatusInReview},
+	"reject":  {From: MovieStatusInReview, To: MovieStatusDraft},
+	"publish": {From: MovieStatusInReview, To: MovieStatusPublished},
+	"archive": {From: MovieStatusPublished, To: MovieStatusArchived},
+}
+
+// MovieTransitionInput is the optional body of a transition. PublishAt
+// schedules a publish; the movie stays hidden until then.
+type MovieTransitionInput struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// IsPublic reports whether anonymous readers can see the movie at the given time
+func (m *Movie) IsPublic(now time.Time) bool {
+	return m.DeletedAt == nil && m.Status == MovieStatusPublished &&
+		(m.PublishAt == nil || !m.PublishAt.After(now))
+}
+
+// CanSeeUnpublished reports whether a role may read movies that are not public
+func CanSeeUnpublished(role string) bool {
+	return HasRole(role, UserRoleEditor)
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Credit roles
+const (
+	RoleDirector = "director"
+	RoleActor    = "actor"
+	RoleWriter   = "writer"
+	RoleProducer = "producer"
+)
+
+// ValidCreditRoles lists the roles a person can be credited with on a movie
+var ValidCreditRoles = map[string]bool{
+	RoleDirector: true,
+	RoleActor:    true,
+	RoleWriter:   true,
+	RoleProducer: true,
+}
+
+type Person struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Biography string     `json:"biography"`
+	BirthDate *time.Time `json:"birth_date"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type CreatePersonInput struct {
+	Name      string     `json:"name"`
+	Biography string     `json:"biography"`
+	BirthDate *time.Time `json:"birth_date"`
+}
+
+type UpdatePersonInput struct {
+	Name      *string    `json:"name"`
+	Biography *string    `json:"biography"`
+	BirthDate *time.Time `json:"birth_date"`
+}
+
+type PersonQuery struct {
+	Name     string `json:"name"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+// Credit is a person's role on a movie, embedded in movie reads
+type Credit struct {
+	PersonID     int64  `json:"person_id"`
+	Name         string `json:"name"`
+	Role         string `json:"role"`
+	Character    string `json:"character,omitempty"`
+	BillingOrder int    `json:"billing_order"`
+}
+
+type CreditInput struct {
+	PersonID     int64  `json:"person_id"`
+	Role         string `json:"role"`
+	Character    string `json:"character"`
+	BillingOrder int    `json:"billing_order"`
+}
+
+// FilmographyEntry is a movie a person is credited on
+type FilmographyEntry struct {
+	MovieID      int64     `json:"movie_id"`
+	Title        string    `json:"title"`
+	ReleaseDate  time.Time `json:"release_date"`
+	Role         string    `json:"role"`
+	Character    string    `json:"character,omitempty"`
+	BillingOrder int       `json:"billing_order"`
+}
+
+// ValidateCredits checks that every credit names a person and a known role
+func ValidateCredits(credits []CreditInput) map[string]string {
+	errs := map[string]string{}
+	for i, credit := range credits {
+		if credit.PersonID <= 0 {
+			errs[fmt.Sprintf("credits[%d].person_id", i)] = "is required"
+		}
+		if !ValidCreditRoles[credit.Role] {
+			errs[fmt.Sprintf("credits[%d].role", i)] = "must be one of director, actor, writer, producer"
+		}
+	}
+	return errs
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Review rating bounds
+const (
+	MinReviewRating = 1
+	MaxReviewRating = 10
+)
+
+type Review struct {
+	ID        int64     `json:"id"`
+	MovieID   int64     `json:"movie_id"`
+	UserID    int64     `json:"user_id"`
+	Author    string    `json:"author"`
+	Rating    int       `json:"rating"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateReviewInput struct {
+	Rating int    `json:"rating"`
+	Body   string `json:"body"`
+}
+
+type UpdateReviewInput struct {
+	Rating *int    `json:"rating"`
+	Body   *string `json:"body"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Personal movie lists kept per user
+const (
+	SavedMovieWatchlist = "watchlist"
+	SavedMovieFavorites = "favorites"
+)
+
+// SavedMovie is a movie on one of a user's personal lists
+type SavedMovie struct {
+	Movie     *Movie     `json:"movie"`
+	AddedAt   time.Time  `json:"added_at"`
+	WatchedAt *time.Time `json:"watched_at"`
+}
+
+type AddSavedMovieInput struct {
+	MovieID int64 `json:"movie_id"`
+}
+
+type MarkWatchedInput struct {
+	WatchedAt *time.Time `json:"watched_at"` // defaults to now
+}
+
+// SavedMovieQuery filters a user's personal list using the movie filter fields
+type SavedMovieQuery struct {
+	MovieQuery
+	Watched *bool `json:"watched"`
+}
+
+// MovieUserStatus describes the calling user's relationship to a movie
+type MovieUserStatus struct {
+	InWatchlist bool       `json:"in_watchlist"`
+	InFavorites bool       `json:"in_favorites"`
+	WatchedAt   *time.Time `json:"watched_at"`
+}
@@ -6,12 +6,32 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// User roles, from least to most privileged
+const (
+	UserRoleUser   = "user"
+	UserRoleEditor = "editor"
+	UserRoleAdmin  = "admin"
+)
+
+var userRoleRank = map[string]int{
+	UserRoleUser:   0,
+	UserRoleEditor: 1,
+	UserRoleAdmin:  2,
+}
+
+// HasRole reports whether role grants at least the privileges of required.
+// Unknown or empty roles are treated as a regular user.
+func HasRole(role, required string) bool {
+	return userRoleRank[role] >= userRoleRank[required]
+}
+
 type User struct {
 	ID           int64     `json:"id"`
 	Email        string    `json:"email"`
 	PasswordHash string    `json:"-"`
 	FirstName    string    `json:"first_name"`
 	LastName     string    `json:"last_name"`
+	Role         string    `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -21,6 +41,7 @@ type CreateUserInput struct {
 	Password  string `json:"password"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+	Role      string `json:"-"` // set by operators only, a regular user when empty
 }
 
 type LoginInput struct {
@@ -43,6 +64,7 @@ type UserResponse struct {
 	Email     string `json:"email"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+	Role      string `json:"role"`
 }
 
 func (u *User) ToResponse() *UserResponse {
@@ -51,5 +73,6 @@ func (u *User) ToResponse() *UserResponse {
 		Email:     u.Email,
 		FirstName: u.FirstName,
 		LastName:  u.LastName,
+		Role:      u.Role,
 	}
 }
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"sync"
+	"time"
+)
+
+// fallbackCooldown is how long FallbackLimiter stays on its fallback after
+// the primary failed, so an outage does not add a timeout to every request
+const fallbackCooldown = 5 * time.Second
+
+// FallbackLimiter uses its primary limiter, normally Redis, and switches to
+// the fallback, normally in memory, while the primary is failing
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+// NewFallbackLimiter creates a FallbackLimiter
+func NewFallbackLimiter(primary, fallback Limiter) *FallbackLimiter {
+	return &FallbackLimiter{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	if l.primaryUp() {
+		result, err := l.primary.Allow(ctx, key, limit)
+		if err == nil {
+			return result, nil
+		}
+
+		// The request's own cancellation says nothing about the primary
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		l.markDown(ctx, err)
+	}
+
+	return l.fallback.Allow(ctx, key, limit)
+}
+
+func (l *FallbackLimiter) primaryUp() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return time.Now().After(l.downUntil)
+}
+
+func (l *FallbackLimiter) markDown(ctx context.Context, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Now().After(l.downUntil) {
+		logger.WarnContext(ctx, "Rate limiter falling back to memory", logger.Field("error", err), logger.Field("retry_in", fallbackCooldown.String()))
+	}
+	l.downUntil = time.Now().Add(fallbackCooldown)
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryLimiter drops keys whose quota is full
+const sweepInterval = time.Minute
+
+// MemoryLimiter keeps limits in process, so each instance limits on its own
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter creates an empty MemoryLimiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		tats: make(map[string]time.Time),
+	}
+}
+
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	result, tat := gcra(now, l.tats[key], limit)
+	if result.Allowed {
+		l.tats[key] = tat
+	}
+
+	return result, nil
+}
+
+// sweep forgets keys whose theoretical arrival time has passed, as they are
+// no different from keys never seen
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, tat := range l.tats {
+		if !tat.After(now) {
+			delete(l.tats, key)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Package ratelimit limits request rates with the generic cell rate algorithm
+// (GCRA), in Redis so all instances share the limits, or in memory
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Requests per Period, all of which may be used in a burst
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// emissionInterval is the time one request's worth of quota takes to refill
+func (l Limit) emissionInterval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Result describes the state of a key after a request was counted against it
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request is allowed; zero when
+	// this one was
+	RetryAfter time.Duration
+	// ResetAfter is how long until the full quota is available again
+	ResetAfter time.Duration
+}
+
+// Limiter counts a request against key and reports whether it is allowed
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (*Result, error)
+}
+
+// gcra applies one request to the theoretical arrival time tat of a key at
+// now, returning the result and the new tat to store if the request is
+// allowed
+func gcra(now, tat time.Time, limit Limit) (*Result, time.Time) {
+	interval := limit.emissionInterval()
+	burstOffset := interval * time.Duration(limit.Requests)
+
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-burstOffset)
+
+	result := &Result{Limit: limit.Requests}
+
+	diff := now.Sub(allowAt)
+	if diff < 0 {
+		result.RetryAfter = -diff
+		result.ResetAfter = tat.Sub(now)
+		return result, tat
+	}
+
+	result.Allowed = true
+	result.Remaining = int(math.Floor(float64(diff) / float64(interval)))
+	result.ResetAfter = newTAT.Sub(now)
+
+	return result, newTAT
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript is gcra in Lua, so the read and write of a key's theoretical
+// arrival time are atomic and every instance uses the Redis clock. Times are
+// in microseconds.
+//
+// KEYS[1] is the key, ARGV[1] the requests and ARGV[2] the period. Returns
+// allowed (0 or 1), remaining, retry after and reset after.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+
+local requests = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local interval = period / requests
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = now
+local stored = redis.call("GET", KEYS[1])
+if stored then
+	tat = math.max(tonumber(stored), now)
+end
+
+local new_tat = tat + interval
+local diff = now - (new_tat - period)
+if diff < 0 then
+	return {0, 0, -diff, tat - now}
+end
+
+redis.call("SET", KEYS[1], string.format("%.0f", new_tat), "PX", math.max(1, math.ceil((new_tat - now) / 1000)))
+return {1, math.floor(diff / interval), 0, new_tat - now}
+`)
+
+// RedisLimiter keeps limits in Redis, shared by every instance
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLimiter creates a RedisLimiter storing its keys under prefix
+func NewRedisLimiter(client *redis.Client, prefix string) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (*Result, error) {
+	values, err := gcraScript.Run(ctx, l.client, []string{l.prefix + key}, limit.Requests, limit.Period.Microseconds()).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("run rate limit script: %w", err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("rate limit script returned %d values", len(values))
+	}
+
+	return &Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Requests,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
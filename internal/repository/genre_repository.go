@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrGenreNotFound   = errors.New("genre not found")
+	ErrGenreSlugExists = errors.New("genre slug already exists")
+)
+
+// GenreRepository handles database operations related to genres
+type GenreRepository struct {
+	db         *database.PostgresDB
+	movieCache *movieCache // nil unless the movie repository caches reads
+}
+
+// NewGenreRepository creates a new GenreRepository
+func NewGenreRepository(db *database.PostgresDB) *GenreRepository {
+	return &GenreRepository{
+		db: db,
+	}
+}
+
+// UseMovieCache makes genre renames and deletes invalidate the cached reads
+// of movies, which embed their genres
+func (r *GenreRepository) UseMovieCache(movies *MovieRepository) {
+	r.movieCache = movies.cache
+}
+
+func (r *GenreRepository) Create(ctx context.Context, input *models.CreateGenreInput) (*models.Genre, error) {
+	slug := input.Slug
+	if slug == "" {
+		slug = input.Name
+	}
+
+	query := `
+		INSERT INTO genres (name, slug, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		RETURNING id, name, slug, created_at, updated_at
+	`
+
+	genre := &models.Genre{}
+	err := r.db.QueryRowContext(ctx, query, strings.TrimSpace(input.Name), models.Slugify(slug), time.Now()).Scan(
+		&genre.ID, &genre.Name, &genre.Slug, &genre.CreatedAt, &genre.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrGenreSlugExists
+		}
+		return nil, err
+	}
+
+	return genre, nil
+}
+
+func (r *GenreRepository) GetByID(ctx context.Context, id int64) (*models.Genre, error) {
+	query := `SELECT id, name, slug, created_at, updated_at FROM genres WHERE id = $1`
+
+	genre := &models.Genre{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&genre.ID, &genre.Name, &genre.Slug, &genre.CreatedAt, &genre.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGenreNotFound
+		}
+		return nil, err
+	}
+
+	return genre, nil
+}
+
+func (r *GenreRepository) Update(ctx context.Context, id int64, input *models.UpdateGenreInput) (*models.Genre, error) {
+	updates := []string{}
+	args := []interface{}{}
+	argPosition := 1
+
+	if input.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argPosition))
+		args = append(args, strings.TrimSpace(*input.Name))
+		argPosition++
+	}
+
+	if input.Slug != nil {
+		updates = append(updates, fmt.Sprintf("slug = $%d", argPosition))
+		args = append(args, models.Slugify(*input.Slug))
+		argPosition++
+	}
+
+	if len(updates) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", argPosition))
+	args = append(args, time.Now())
+	argPosition++
+
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE genres
+		SET %s
+		WHERE id = $%d
+		RETURNING id, name, slug, created_at, updated_at
+	`, strings.Join(updates, ", "), argPosition)
+
+	// Movies embed the genre's name and slug, so their versions move with it
+	genre := &models.Genre{}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, args...).Scan(
+			&genre.ID, &genre.Name, &genre.Slug, &genre.CreatedAt, &genre.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		return bumpGenreMovieVersions(ctx, tx, id)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGenreNotFound
+		}
+		if isUniqueViolation(err) {
+			return nil, ErrGenreSlugExists
+		}
+		return nil, err
+	}
+
+	r.movieCache.invalidateAll(ctx)
+	return genre, nil
+}
+
+func (r *GenreRepository) Delete(ctx context.Context, id int64) error {
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// The versions are bumped first, while the links still exist
+		if err := bumpGenreMovieVersions(ctx, tx, id); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM genres WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrGenreNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.movieCache.invalidateAll(ctx)
+	return nil
+}
+
+// bumpGenreMovieVersions bumps the version of every movie tagged with the genre
+func bumpGenreMovieVersions(ctx context.Context, q queryer, genreID int64) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE movies SET version = version + 1
+		WHERE id IN (SELECT movie_id FROM movie_genres WHERE genre_id = $1)
+	`, genreID)
+	return err
+}
+
+// List returns every genre ordered by name
+func (r *GenreRepository) List(ctx context.Context) ([]*models.Genre, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name, slug, created_at, updated_at FROM genres ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []*models.Genre{}
+	for rows.Next() {
+		genre := &models.Genre{}
+		if err := rows.Scan(&genre.ID, &genre.Name, &genre.Slug, &genre.CreatedAt, &genre.UpdatedAt); err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return genres, nil
+}
+
+// resolveGenreIDs maps genre slugs to their IDs, failing with ErrGenreNotFound
+// if any slug is unknown
+func resolveGenreIDs(ctx context.Context, q queryer, slugs []string) ([]int64, error) {
+	if len(slugs) == 0 {
+		return []int64{}, nil
+	}
+
+	normalized := make([]string, 0, len(slugs))
+	seen := map[string]bool{}
+	for _, slug := range slugs {
+		s := models.Slugify(slug)
+		if !seen[s] {
+			seen[s] = true
+			normalized = append(normalized, s)
+		}
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT id FROM genres WHERE slug = ANY($1)`, pq.Array(normalized))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) != len(normalized) {
+		return nil, ErrGenreNotFound
+	}
+
+	return ids, nil
+}
+
+// setMovieGenres replaces the genres attached to a movie
+func setMovieGenres(ctx context.Context, q queryer, movieID int64, slugs []string) error {
+	genreIDs, err := resolveGenreIDs(ctx, q, slugs)
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.ExecContext(ctx, `DELETE FROM movie_genres WHERE movie_id = $1`, movieID); err != nil {
+		return err
+	}
+
+	if len(genreIDs) == 0 {
+		return nil
+	}
+
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO movie_genres (movie_id, genre_id)
+		SELECT $1, unnest($2::bigint[])
+	`, movieID, pq.Array(genreIDs))
+
+	return err
+}
+
+// loadMovieGenres attaches genres to the given movies with a single query
+func loadMovieGenres(ctx context.Context, q queryer, movies []*models.Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Movie, len(movies))
+	ids := make([]int64, 0, len(movies))
+	for _, movie := range movies {
+		movie.Genres = []*models.Genre{}
+		byID[movie.ID] = movie
+		ids = append(ids, movie.ID)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT mg.movie_id, g.id, g.name, g.slug, g.created_at, g.updated_at
+		FROM movie_genres mg
+		JOIN genres g ON g.id = mg.genre_id
+		WHERE mg.movie_id = ANY($1)
+		ORDER BY g.name
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID int64
+		genre := &models.Genre{}
+		if err := rows.Scan(&movieID, &genre.ID, &genre.Name, &genre.Slug, &genre.CreatedAt, &genre.UpdatedAt); err != nil {
+			return err
+		}
+		if movie, ok := byID[movieID]; ok {
+			movie.Genres = append(movie.Genres, genre)
+		}
+	}
+
+	return rows.Err()
+}
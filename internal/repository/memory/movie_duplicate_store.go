@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// FindDuplicates returns a page of likely duplicate pairs, most similar
+// first, using the same trigram similarity as pg_trgm
+func (s *MovieStore) FindDuplicates(ctx context.Context, minSimilarity float64, page, pageSize int) ([]*models.DuplicateCandidate, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	live := []*models.Movie{}
+	for _, movie := range s.movies {
+		if movie.DeletedAt == nil {
+			live = append(live, movie)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].ID < live[j].ID })
+
+	// pg_trgm's % operator never matches below its default threshold
+	threshold := minSimilarity
+	if threshold < models.MinDuplicateSimilarity {
+		threshold = models.MinDuplicateSimilarity
+	}
+
+	candidates := []*models.DuplicateCandidate{}
+	for i, a := range live {
+		for _, b := range live[i+1:] {
+			if a.ReleaseDate.Year() != b.ReleaseDate.Year() {
+				continue
+			}
+
+			score := trigramSimilarity(a.Title, b.Title)
+			if score < threshold {
+				continue
+			}
+
+			shared, missing := compareDirectors(a, b)
+			if !shared && !missing {
+				continue
+			}
+
+			candidates = append(candidates, &models.DuplicateCandidate{
+				Movie:          cloneMovie(a),
+				Duplicate:      cloneMovie(b),
+				Similarity:     score,
+				SharedDirector: shared,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+
+	return paginate(candidates, page, pageSize), len(candidates), nil
+}
+
+// Merge folds a duplicate movie into the surviving one. Credits the survivor
+// lacks and all external IDs move over, and the duplicate is moved to the
+// trash. Both changes are recorded in the revision history.
+func (s *MovieStore) Merge(ctx context.Context, survivorID, duplicateID int64) (*models.MovieMergeResult, error) {
+	if survivorID == duplicateID {
+		return nil, repository.ErrMergeSameMovie
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	survivor, err := s.liveMovie(survivorID)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate, err := s.liveMovie(duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.MovieMergeResult{DuplicateID: duplicateID}
+	survivorBefore, duplicateBefore := cloneMovie(survivor), cloneMovie(duplicate)
+
+	kept := []*models.Credit{}
+	for _, credit := range duplicate.Credits {
+		if hasCredit(survivor, credit) {
+			kept = append(kept, credit)
+			continue
+		}
+		survivor.Credits = append(survivor.Credits, credit)
+		result.MovedCredits++
+	}
+	duplicate.Credits = kept
+	sort.SliceStable(survivor.Credits, func(i, j int) bool {
+		return survivor.Credits[i].BillingOrder < survivor.Credits[j].BillingOrder
+	})
+
+	for _, externalID := range duplicate.ExternalIDs {
+		s.externalIDs[externalKey{externalID.Source, externalID.ID}] = survivorID
+		survivor.ExternalIDs = append(survivor.ExternalIDs, externalID)
+		result.MovedExternalIDs++
+	}
+	duplicate.ExternalIDs = []*models.ExternalID{}
+
+	now := time.Now()
+	survivor.UpdatedAt = now
+	duplicate.DeletedAt = &now
+	duplicate.Version++
+
+	s.recordRevision(ctx, models.RevisionActionMerge, survivorBefore, survivor)
+	s.recordRevision(ctx, models.RevisionActionDelete, duplicateBefore, duplicateBefore)
+
+	result.Movie = cloneMovie(survivor)
+	return result, nil
+}
+
+func hasCredit(movie *models.Movie, credit *models.Credit) bool {
+	for _, c := range movie.Credits {
+		if c.PersonID == credit.PersonID && c.Role == credit.Role && c.Character == credit.Character {
+			return true
+		}
+	}
+	return false
+}
+
+// compareDirectors reports whether two movies share a director and whether
+// either of them has none
+func compareDirectors(a, b *models.Movie) (shared, missing bool) {
+	directors := map[int64]bool{}
+	for _, credit := range a.Credits {
+		if credit.Role == models.RoleDirector {
+			directors[credit.PersonID] = true
+		}
+	}
+
+	hasDirector := false
+	for _, credit := range b.Credits {
+		if credit.Role != models.RoleDirector {
+			continue
+		}
+		hasDirector = true
+		if directors[credit.PersonID] {
+			shared = true
+		}
+	}
+
+	return shared, len(directors) == 0 || !hasDirector
+}
+
+// trigramSimilarity is pg_trgm's similarity: the share of distinct trigrams
+// the two strings have in common. Each lowercased word is padded with two
+// spaces in front and one behind before it is split into trigrams.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for trigram := range ta {
+		if tb[trigram] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+
+	return set
+}
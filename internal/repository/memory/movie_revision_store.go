@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+)
+
+// ListRevisions returns a page of a movie's revisions, newest first. Like the
+// Postgres store it includes revisions of trashed movies.
+func (s *MovieStore) ListRevisions(ctx context.Context, movieID int64, page, pageSize int) ([]*models.MovieRevision, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Revisions outlive purged movies
+	stored := s.revisions[movieID]
+	if _, ok := s.movies[movieID]; !ok && len(stored) == 0 {
+		return nil, 0, repository.ErrMovieNotFound
+	}
+
+	revisions := make([]*models.MovieRevision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		revision := *stored[i]
+		revision.Snapshot = nil
+		revisions = append(revisions, &revision)
+	}
+
+	return paginate(revisions, page, pageSize), len(revisions), nil
+}
+
+func (s *MovieStore) GetRevision(ctx context.Context, movieID int64, revision int) (*models.MovieRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getRevision(movieID, revision)
+}
+
+// Revert restores a live movie's fields to the snapshot taken at the given
+// revision and records the revert as a new revision
+func (s *MovieStore) Revert(ctx context.Context, movieID int64, revision int) (*models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target, err := s.getRevision(movieID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	movie, err := s.update(ctx, movieID, target.Snapshot.UpdateInput(), nil, models.RevisionActionRevert)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneMovie(movie), nil
+}
+
+// getRevision returns a copy of a revision including its snapshot. The
+// caller holds the lock.
+func (s *MovieStore) getRevision(movieID int64, revision int) (*models.MovieRevision, error) {
+	stored := s.revisions[movieID]
+	if revision < 1 || revision > len(stored) {
+		return nil, repository.ErrRevisionNotFound
+	}
+
+	copied := *stored[revision-1]
+	snapshot := *copied.Snapshot
+	copied.Snapshot = &snapshot
+
+	return &copied, nil
+}
@@ -0,0 +1,750 @@
+package memory
+
+import (
+	"context"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type externalKey struct {
+	source, id string
+}
+
+// MovieStore keeps movies and their revision history in memory. Genres and
+// people are not managed through it; movies can use the ones added with
+// AddGenre and AddPerson. Reviews, lists and saved movies are not kept, so
+// merges never move any and average ratings stay at zero.
+type MovieStore struct {
+	mu          sync.RWMutex
+	movies      map[int64]*models.Movie
+	revisions   map[int64][]*models.MovieRevision // by movie, oldest first
+	externalIDs map[externalKey]int64
+	genres      map[string]*models.Genre // by slug
+	people      map[int64]*models.Person
+
+	nextMovieID, nextRevisionID, nextGenreID, nextPersonID int64
+}
+
+var _ repository.MovieStore = (*MovieStore)(nil)
+
+func NewMovieStore() *MovieStore {
+	return &MovieStore{
+		movies:      map[int64]*models.Movie{},
+		revisions:   map[int64][]*models.MovieRevision{},
+		externalIDs: map[externalKey]int64{},
+		genres:      map[string]*models.Genre{},
+		people:      map[int64]*models.Person{},
+	}
+}
+
+// AddGenre makes a genre available to movies and returns it. Adding a name
+// whose slug is already known returns the existing genre.
+func (s *MovieStore) AddGenre(name string) *models.Genre {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slug := models.Slugify(name)
+	if genre, ok := s.genres[slug]; ok {
+		copied := *genre
+		return &copied
+	}
+
+	s.nextGenreID++
+	now := time.Now()
+	genre := &models.Genre{ID: s.nextGenreID, Name: name, Slug: slug, CreatedAt: now, UpdatedAt: now}
+	s.genres[slug] = genre
+
+	copied := *genre
+	return &copied
+}
+
+// AddPerson makes a person available to movie credits and returns them
+func (s *MovieStore) AddPerson(name string) *models.Person {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextPersonID++
+	now := time.Now()
+	person := &models.Person{ID: s.nextPersonID, Name: name, CreatedAt: now, UpdatedAt: now}
+	s.people[person.ID] = person
+
+	copied := *person
+	return &copied
+}
+
+func (s *MovieStore) Create(ctx context.Context, input *models.CreateMovieInput) (*models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movie, err := s.create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneMovie(movie), nil
+}
+
+// create adds a movie and records its first revision. The caller holds the
+// write lock.
+func (s *MovieStore) create(ctx context.Context, input *models.CreateMovieInput) (*models.Movie, error) {
+	genres, err := s.resolveGenres(input.Genres)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := s.resolveCredits(input.Credits)
+	if err != nil {
+		return nil, err
+	}
+
+	s.nextMovieID++
+	now := time.Now()
+	movie := &models.Movie{
+		ID:          s.nextMovieID,
+		Title:       input.Title,
+		Description: input.Description,
+		ReleaseDate: input.ReleaseDate,
+		Rating:      input.Rating,
+		Duration:    input.Duration,
+		Genres:      genres,
+		Credits:     credits,
+		ExternalIDs: []*models.ExternalID{},
+		Images:      map[string]*models.MovieImage{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+		Status:      models.MovieStatusDraft,
+	}
+	s.movies[movie.ID] = movie
+
+	s.recordRevision(ctx, models.RevisionActionCreate, nil, movie)
+	return movie, nil
+}
+
+func (s *MovieStore) GetByID(ctx context.Context, id int64) (*models.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	movie, err := s.liveMovie(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneMovie(movie), nil
+}
+
+func (s *MovieStore) List(ctx context.Context, query *models.MovieQuery) ([]*models.Movie, int, error) {
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	if query.PageSize <= 0 {
+		query.PageSize = 10
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	movies := s.query(query)
+	return paginate(movies, query.Page, query.PageSize), len(movies), nil
+}
+
+func (s *MovieStore) Export(ctx context.Context, query *models.MovieQuery, fn func(*models.Movie) error) error {
+	s.mu.RLock()
+	movies := s.query(query)
+	s.mu.RUnlock()
+
+	for _, movie := range movies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(movie); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// query returns copies of the live movies matching the query's filters in
+// its sort order. The caller holds the lock.
+func (s *MovieStore) query(query *models.MovieQuery) []*models.Movie {
+	now := time.Now()
+	movies := []*models.Movie{}
+	for _, movie := range s.movies {
+		if matchesQuery(movie, query, now) {
+			movies = append(movies, cloneMovie(movie))
+		}
+	}
+
+	sortMovies(movies, query.SortBy, query.Order)
+	return movies
+}
+
+func (s *MovieStore) Update(ctx context.Context, id int64, input *models.UpdateMovieInput, ifMatch []int) (*models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movie, err := s.update(ctx, id, input, ifMatch, models.RevisionActionUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneMovie(movie), nil
+}
+
+// update applies a partial update and records a revision with the given
+// action when anything changed. The caller holds the write lock.
+func (s *MovieStore) update(ctx context.Context, id int64, input *models.UpdateMovieInput, ifMatch []int, action string) (*models.Movie, error) {
+	movie, err := s.liveMovie(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(movie, ifMatch) {
+		return nil, repository.ErrMovieVersionMismatch
+	}
+
+	if input.Title == nil && input.Description == nil && input.ReleaseDate == nil &&
+		input.Rating == nil && input.Duration == nil && input.Genres == nil && input.Credits == nil {
+		return movie, nil
+	}
+
+	// Resolve relations first so a failed update changes nothing
+	var genres []*models.Genre
+	if input.Genres != nil {
+		if genres, err = s.resolveGenres(*input.Genres); err != nil {
+			return nil, err
+		}
+	}
+
+	var credits []*models.Credit
+	if input.Credits != nil {
+		if credits, err = s.resolveCredits(*input.Credits); err != nil {
+			return nil, err
+		}
+	}
+
+	before := cloneMovie(movie)
+
+	if input.Title != nil {
+		movie.Title = *input.Title
+	}
+	if input.Description != nil {
+		movie.Description = *input.Description
+	}
+	if input.ReleaseDate != nil {
+		movie.ReleaseDate = *input.ReleaseDate
+	}
+	if input.Rating != nil {
+		movie.Rating = *input.Rating
+	}
+	if input.Duration != nil {
+		movie.Duration = *input.Duration
+	}
+	if input.Genres != nil {
+		movie.Genres = genres
+	}
+	if input.Credits != nil {
+		movie.Credits = credits
+	}
+
+	movie.Version++
+	movie.UpdatedAt = time.Now()
+
+	s.recordRevision(ctx, action, before, movie)
+	return movie, nil
+}
+
+func (s *MovieStore) Delete(ctx context.Context, id int64, ifMatch []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movie, err := s.liveMovie(id)
+	if err != nil {
+		return err
+	}
+
+	if !versionMatches(movie, ifMatch) {
+		return repository.ErrMovieVersionMismatch
+	}
+
+	before := cloneMovie(movie)
+
+	now := time.Now()
+	movie.DeletedAt = &now
+	movie.Version++
+
+	s.recordRevision(ctx, models.RevisionActionDelete, before, before)
+	return nil
+}
+
+func (s *MovieStore) ListDeleted(ctx context.Context, page, pageSize int) ([]*models.Movie, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	movies := []*models.Movie{}
+	for _, movie := range s.movies {
+		if movie.DeletedAt != nil {
+			movies = append(movies, cloneMovie(movie))
+		}
+	}
+
+	sort.Slice(movies, func(i, j int) bool {
+		a, b := movies[i], movies[j]
+		if !a.DeletedAt.Equal(*b.DeletedAt) {
+			return a.DeletedAt.After(*b.DeletedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	return paginate(movies, page, pageSize), len(movies), nil
+}
+
+func (s *MovieStore) Restore(ctx context.Context, id int64) (*models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movie, ok := s.movies[id]
+	if !ok || movie.DeletedAt == nil {
+		return nil, repository.ErrMovieNotFound
+	}
+
+	movie.DeletedAt = nil
+	movie.UpdatedAt = time.Now()
+	movie.Version++
+
+	s.recordRevision(ctx, models.RevisionActionRestore, movie, movie)
+	return cloneMovie(movie), nil
+}
+
+func (s *MovieStore) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, movie := range s.movies {
+		if movie.DeletedAt == nil || !movie.DeletedAt.Before(before) {
+			continue
+		}
+
+		for _, externalID := range movie.ExternalIDs {
+			delete(s.externalIDs, externalKey{externalID.Source, externalID.ID})
+		}
+		delete(s.movies, id)
+		purged++
+	}
+
+	return purged, nil
+}
+
+func (s *MovieStore) GetByExternalID(ctx context.Context, source, externalID string) (*models.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.externalIDs[externalKey{source, externalID}]
+	if !ok {
+		return nil, repository.ErrMovieNotFound
+	}
+
+	movie, err := s.liveMovie(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloneMovie(movie), nil
+}
+
+func (s *MovieStore) UpsertByExternalID(ctx context.Context, source, externalID string, input *models.ReplaceMovieInput, ifMatch []int) (*models.Movie, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := externalKey{source, externalID}
+	if id, ok := s.externalIDs[key]; ok {
+		movie, err := s.update(ctx, id, input.UpdateInput(), ifMatch, models.RevisionActionUpdate)
+		if err != nil {
+			return nil, false, err
+		}
+		return cloneMovie(movie), false, nil
+	}
+
+	movie, err := s.create(ctx, input.CreateInput())
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.externalIDs[key] = movie.ID
+	movie.ExternalIDs = append(movie.ExternalIDs, &models.ExternalID{Source: source, ID: externalID})
+
+	return cloneMovie(movie), true, nil
+}
+
+func (s *MovieStore) SetImage(ctx context.Context, movieID int64, kind string, image *models.MovieImage) (*models.Movie, *models.MovieImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movie, err := s.liveMovie(movieID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	previous := movie.Images[kind]
+	movie.Images[kind] = cloneImage(image)
+	touchMovie(movie)
+
+	if previous != nil {
+		previous = cloneImage(previous)
+	}
+
+	return cloneMovie(movie), previous, nil
+}
+
+func (s *MovieStore) RemoveImage(ctx context.Context, movieID int64, kind string) (*models.Movie, *models.MovieImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movie, err := s.liveMovie(movieID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed := movie.Images[kind]
+	if removed == nil {
+		return nil, nil, repository.ErrImageNotFound
+	}
+
+	delete(movie.Images, kind)
+	touchMovie(movie)
+
+	return cloneMovie(movie), cloneImage(removed), nil
+}
+
+func (s *MovieStore) Transition(ctx context.Context, id int64, transition models.MovieTransition, publishAt *time.Time, ifMatch []int) (*models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movie, err := s.liveMovie(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(movie, ifMatch) {
+		return nil, repository.ErrMovieVersionMismatch
+	}
+
+	if movie.Status != transition.From {
+		return nil, repository.ErrInvalidTransition
+	}
+
+	before := cloneMovie(movie)
+
+	now := time.Now()
+	switch transition.To {
+	case models.MovieStatusPublished:
+		if publishAt != nil {
+			at := *publishAt
+			movie.PublishAt = &at
+		} else {
+			movie.PublishAt = &now
+		}
+	case models.MovieStatusDraft:
+		movie.PublishAt = nil
+	}
+
+	movie.Status = transition.To
+	movie.UpdatedAt = now
+	movie.Version++
+
+	s.recordRevision(ctx, models.RevisionActionTransition, before, movie)
+	return cloneMovie(movie), nil
+}
+
+// liveMovie returns the stored movie, failing with ErrMovieNotFound if it
+// does not exist or is in the trash. The caller holds the lock.
+func (s *MovieStore) liveMovie(id int64) (*models.Movie, error) {
+	movie, ok := s.movies[id]
+	if !ok || movie.DeletedAt != nil {
+		return nil, repository.ErrMovieNotFound
+	}
+	return movie, nil
+}
+
+// resolveGenres maps genre slugs to the known genres sorted by name, failing
+// with ErrGenreNotFound if any slug is unknown
+func (s *MovieStore) resolveGenres(slugs []string) ([]*models.Genre, error) {
+	genres := []*models.Genre{}
+	seen := map[string]bool{}
+	for _, slug := range slugs {
+		slug = models.Slugify(slug)
+		if seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		genre, ok := s.genres[slug]
+		if !ok {
+			return nil, repository.ErrGenreNotFound
+		}
+		copied := *genre
+		genres = append(genres, &copied)
+	}
+
+	sort.Slice(genres, func(i, j int) bool { return genres[i].Name < genres[j].Name })
+	return genres, nil
+}
+
+// resolveCredits turns credit inputs into credits in billing order, failing
+// with ErrPersonNotFound if any person is unknown
+func (s *MovieStore) resolveCredits(inputs []models.CreditInput) ([]*models.Credit, error) {
+	credits := make([]*models.Credit, 0, len(inputs))
+	for _, input := range inputs {
+		person, ok := s.people[input.PersonID]
+		if !ok {
+			return nil, repository.ErrPersonNotFound
+		}
+		credits = append(credits, &models.Credit{
+			PersonID:     person.ID,
+			Name:         person.Name,
+			Role:         input.Role,
+			Character:    input.Character,
+			BillingOrder: input.BillingOrder,
+		})
+	}
+
+	sort.SliceStable(credits, func(i, j int) bool { return credits[i].BillingOrder < credits[j].BillingOrder })
+	return credits, nil
+}
+
+// touchMovie bumps a movie's version and update time after a change to its
+// images
+func touchMovie(movie *models.Movie) {
+	movie.Version++
+	movie.UpdatedAt = time.Now()
+}
+
+// versionMatches reports whether the movie's version is one of the accepted
+// versions. An empty list accepts any version.
+func versionMatches(movie *models.Movie, ifMatch []int) bool {
+	if len(ifMatch) == 0 {
+		return true
+	}
+
+	for _, version := range ifMatch {
+		if movie.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesQuery applies the filters of a movie list to a stored movie the way
+// the Postgres store does
+func matchesQuery(movie *models.Movie, query *models.MovieQuery, now time.Time) bool {
+	if movie.DeletedAt != nil {
+		return false
+	}
+
+	if !query.IncludeUnpublished && !movie.IsPublic(now) {
+		return false
+	}
+
+	if query.Status != "" && movie.Status != query.Status {
+		return false
+	}
+
+	if query.Title != "" && !containsFold(movie.Title, query.Title) {
+		return false
+	}
+
+	if query.Genre != "" {
+		slug := models.Slugify(query.Genre)
+		found := false
+		for _, genre := range movie.Genres {
+			if genre.Slug == slug {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if query.Director != "" {
+		found := false
+		for _, credit := range movie.Credits {
+			if credit.Role == models.RoleDirector && containsFold(credit.Name, query.Director) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// sortMovies orders movies like a list query: by the sort key with ties
+// broken by ID, or newest first when the key is unknown
+func sortMovies(movies []*models.Movie, sortBy, order string) {
+	compare, ok := movieComparators[sortBy]
+	if !ok {
+		sort.Slice(movies, func(i, j int) bool {
+			a, b := movies[i], movies[j]
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		})
+		return
+	}
+
+	desc := strings.ToUpper(order) == "DESC"
+	sort.Slice(movies, func(i, j int) bool {
+		a, b := movies[i], movies[j]
+		if c := compare(a, b); c != 0 {
+			return (c < 0) != desc
+		}
+		return a.ID < b.ID
+	})
+}
+
+// movieComparators holds the sort keys of a movie list
+var movieComparators = map[string]func(a, b *models.Movie) int{
+	"id":             func(a, b *models.Movie) int { return compareOrdered(a.ID, b.ID) },
+	"title":          func(a, b *models.Movie) int { return strings.Compare(a.Title, b.Title) },
+	"release_date":   func(a, b *models.Movie) int { return a.ReleaseDate.Compare(b.ReleaseDate) },
+	"rating":         func(a, b *models.Movie) int { return compareOrdered(a.Rating, b.Rating) },
+	"average_rating": func(a, b *models.Movie) int { return compareOrdered(a.AverageRating, b.AverageRating) },
+	"rating_count":   func(a, b *models.Movie) int { return compareOrdered(a.RatingCount, b.RatingCount) },
+	"duration":       func(a, b *models.Movie) int { return compareOrdered(a.Duration, b.Duration) },
+	"created_at":     func(a, b *models.Movie) int { return a.CreatedAt.Compare(b.CreatedAt) },
+}
+
+func compareOrdered[T int | int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// paginate returns the given 1-based page of items
+func paginate[T any](items []T, page, pageSize int) []T {
+	start := (page - 1) * pageSize
+	if start >= len(items) {
+		return []T{}
+	}
+
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[start:end]
+}
+
+// recordRevision appends a revision for a movie write like the Postgres store
+// does. The caller holds the write lock.
+func (s *MovieStore) recordRevision(ctx context.Context, action string, before, after *models.Movie) {
+	snapshot := models.NewMovieSnapshot(after)
+
+	var previous *models.MovieSnapshot
+	if before != nil {
+		previous = models.NewMovieSnapshot(before)
+	}
+
+	// Snapshots always encode, so diffing them cannot fail
+	changes, _ := models.DiffMovieSnapshots(previous, snapshot)
+	if len(changes) == 0 && (action == models.RevisionActionUpdate || action == models.RevisionActionRevert) {
+		return
+	}
+
+	var editorID *int64
+	if userID, ok := models.EditorFromContext(ctx); ok {
+		editorID = &userID
+	}
+
+	s.nextRevisionID++
+	s.revisions[after.ID] = append(s.revisions[after.ID], &models.MovieRevision{
+		ID:        s.nextRevisionID,
+		MovieID:   after.ID,
+		Revision:  len(s.revisions[after.ID]) + 1,
+		Action:    action,
+		EditorID:  editorID,
+		Snapshot:  snapshot,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	})
+}
+
+// cloneMovie deep-copies a stored movie so callers cannot change the store
+func cloneMovie(movie *models.Movie) *models.Movie {
+	copied := *movie
+	copied.UserStatus = nil
+
+	copied.Genres = make([]*models.Genre, 0, len(movie.Genres))
+	for _, genre := range movie.Genres {
+		g := *genre
+		copied.Genres = append(copied.Genres, &g)
+	}
+
+	copied.Credits = make([]*models.Credit, 0, len(movie.Credits))
+	for _, credit := range movie.Credits {
+		c := *credit
+		copied.Credits = append(copied.Credits, &c)
+	}
+
+	copied.ExternalIDs = make([]*models.ExternalID, 0, len(movie.ExternalIDs))
+	for _, externalID := range movie.ExternalIDs {
+		e := *externalID
+		copied.ExternalIDs = append(copied.ExternalIDs, &e)
+	}
+	sort.Slice(copied.ExternalIDs, func(i, j int) bool {
+		a, b := copied.ExternalIDs[i], copied.ExternalIDs[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.ID < b.ID
+	})
+
+	copied.Images = make(map[string]*models.MovieImage, len(movie.Images))
+	for kind, image := range movie.Images {
+		copied.Images[kind] = cloneImage(image)
+	}
+
+	if movie.DeletedAt != nil {
+		deletedAt := *movie.DeletedAt
+		copied.DeletedAt = &deletedAt
+	}
+
+	if movie.PublishAt != nil {
+		publishAt := *movie.PublishAt
+		copied.PublishAt = &publishAt
+	}
+
+	return &copied
+}
+
+func cloneImage(image *models.MovieImage) *models.MovieImage {
+	copied := *image
+	copied.Thumbnails = make([]*models.ImageThumbnail, 0, len(image.Thumbnails))
+	for _, thumbnail := range image.Thumbnails {
+		t := *thumbnail
+		copied.Thumbnails = append(copied.Thumbnails, &t)
+	}
+	return &copied
+}
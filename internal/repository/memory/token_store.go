@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/auth"
+	"sync"
+	"time"
+)
+
+var (
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+type tokenEntry struct {
+	value     string
+	expiresAt time.Time // zero when the entry never expires
+}
+
+// TokenStore keeps tokens in memory with Redis-like expiry. Expired entries
+// are dropped when they are next read.
+type TokenStore struct {
+	mu      sync.Mutex
+	entries map[string]tokenEntry
+}
+
+var _ auth.TokenStore = (*TokenStore)(nil)
+
+func NewTokenStore() *TokenStore {
+	return &TokenStore{
+		entries: map[string]tokenEntry{},
+	}
+}
+
+func (s *TokenStore) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := tokenEntry{value: fmt.Sprint(value)}
+	if expiration > 0 {
+		entry.expiresAt = time.Now().Add(expiration)
+	}
+	s.entries[key] = entry
+
+	return nil
+}
+
+// Get returns ErrKeyNotFound when there is no live value under key
+func (s *TokenStore) Get(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return "", ErrKeyNotFound
+	}
+
+	return entry.value, nil
+}
+
+func (s *TokenStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
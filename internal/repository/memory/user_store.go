@@ -0,0 +1,139 @@
+// Package memory holds in-memory implementations of the repository stores
+// and the token store. They behave like their Postgres and Redis
+// counterparts, including errors, but keep nothing across restarts, which
+// makes them suitable for tests and for local development without
+// databases.
+package memory
+
+import (
+	"context"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"sync"
+	"time"
+)
+
+// UserStore keeps user accounts in memory
+type UserStore struct {
+	mu     sync.RWMutex
+	users  map[int64]*models.User
+	nextID int64
+}
+
+var _ repository.UserStore = (*UserStore)(nil)
+
+func NewUserStore() *UserStore {
+	return &UserStore{
+		users: map[int64]*models.User{},
+	}
+}
+
+func (s *UserStore) Create(ctx context.Context, input *models.CreateUserInput, passwordHash string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findByEmail(input.Email) != nil {
+		return nil, repository.ErrEmailExists
+	}
+
+	role := input.Role
+	if role == "" {
+		role = models.UserRoleUser
+	}
+
+	s.nextID++
+	now := time.Now()
+	user := &models.User{
+		ID:           s.nextID,
+		Email:        input.Email,
+		PasswordHash: passwordHash,
+		FirstName:    input.FirstName,
+		LastName:     input.LastName,
+		Role:         role,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	s.users[user.ID] = user
+
+	copied := *user
+	return &copied, nil
+}
+
+func (s *UserStore) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user := s.findByEmail(email)
+	if user == nil {
+		return nil, repository.ErrUserNotFound
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (s *UserStore) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	user, err := s.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, repository.ErrInvalidCredentials
+	}
+
+	if !models.CheckPasswordHash(password, user.PasswordHash) {
+		return nil, repository.ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+func (s *UserStore) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+
+	user.PasswordHash = passwordHash
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *UserStore) SetRole(ctx context.Context, id int64, role string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+
+	copied := *user
+	return &copied, nil
+}
+
+// findByEmail matches emails exactly, like the unique index on users.email
+func (s *UserStore) findByEmail(email string) *models.User {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
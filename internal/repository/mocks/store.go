@@ -0,0 +1,439 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	models "github.com/marchelhutagalung/go-service/internal/models"
+)
+
+// MockUserStore is a mock of UserStore interface.
+type MockUserStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStoreMockRecorder
+}
+
+// MockUserStoreMockRecorder is the mock recorder for MockUserStore.
+type MockUserStoreMockRecorder struct {
+	mock *MockUserStore
+}
+
+// NewMockUserStore creates a new mock instance.
+func NewMockUserStore(ctrl *gomock.Controller) *MockUserStore {
+	mock := &MockUserStore{ctrl: ctrl}
+	mock.recorder = &MockUserStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserStore) EXPECT() *MockUserStoreMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockUserStore) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, email, password)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockUserStoreMockRecorder) Authenticate(ctx, email, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockUserStore)(nil).Authenticate), ctx, email, password)
+}
+
+// Create mocks base method.
+func (m *MockUserStore) Create(ctx context.Context, input *models.CreateUserInput, passwordHash string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, input, passwordHash)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockUserStoreMockRecorder) Create(ctx, input, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserStore)(nil).Create), ctx, input, passwordHash)
+}
+
+// GetByEmail mocks base method.
+func (m *MockUserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByEmail", ctx, email)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByEmail indicates an expected call of GetByEmail.
+func (mr *MockUserStoreMockRecorder) GetByEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserStore)(nil).GetByEmail), ctx, email)
+}
+
+// GetByID mocks base method.
+func (m *MockUserStore) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUserStoreMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserStore)(nil).GetByID), ctx, id)
+}
+
+// SetRole mocks base method.
+func (m *MockUserStore) SetRole(ctx context.Context, id int64, role string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", ctx, id, role)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockUserStoreMockRecorder) SetRole(ctx, id, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockUserStore)(nil).SetRole), ctx, id, role)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserStore) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserStoreMockRecorder) UpdatePassword(ctx, id, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserStore)(nil).UpdatePassword), ctx, id, passwordHash)
+}
+
+// MockMovieStore is a mock of MovieStore interface.
+type MockMovieStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockMovieStoreMockRecorder
+}
+
+// MockMovieStoreMockRecorder is the mock recorder for MockMovieStore.
+type MockMovieStoreMockRecorder struct {
+	mock *MockMovieStore
+}
+
+// NewMockMovieStore creates a new mock instance.
+func NewMockMovieStore(ctrl *gomock.Controller) *MockMovieStore {
+	mock := &MockMovieStore{ctrl: ctrl}
+	mock.recorder = &MockMovieStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMovieStore) EXPECT() *MockMovieStoreMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockMovieStore) Create(ctx context.Context, input *models.CreateMovieInput) (*models.Movie, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, input)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMovieStoreMockRecorder) Create(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMovieStore)(nil).Create), ctx, input)
+}
+
+// Delete mocks base method.
+func (m *MockMovieStore) Delete(ctx context.Context, id int64, ifMatch []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id, ifMatch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMovieStoreMockRecorder) Delete(ctx, id, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMovieStore)(nil).Delete), ctx, id, ifMatch)
+}
+
+// Export mocks base method.
+func (m *MockMovieStore) Export(ctx context.Context, query *models.MovieQuery, fn func(*models.Movie) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, query, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockMovieStoreMockRecorder) Export(ctx, query, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockMovieStore)(nil).Export), ctx, query, fn)
+}
+
+// FindDuplicates mocks base method.
+func (m *MockMovieStore) FindDuplicates(ctx context.Context, minSimilarity float64, page, pageSize int) ([]*models.DuplicateCandidate, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDuplicates", ctx, minSimilarity, page, pageSize)
+	ret0, _ := ret[0].([]*models.DuplicateCandidate)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindDuplicates indicates an expected call of FindDuplicates.
+func (mr *MockMovieStoreMockRecorder) FindDuplicates(ctx, minSimilarity, page, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicates", reflect.TypeOf((*MockMovieStore)(nil).FindDuplicates), ctx, minSimilarity, page, pageSize)
+}
+
+// GetByExternalID mocks base method.
+func (m *MockMovieStore) GetByExternalID(ctx context.Context, source, externalID string) (*models.Movie, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByExternalID", ctx, source, externalID)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByExternalID indicates an expected call of GetByExternalID.
+func (mr *MockMovieStoreMockRecorder) GetByExternalID(ctx, source, externalID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByExternalID", reflect.TypeOf((*MockMovieStore)(nil).GetByExternalID), ctx, source, externalID)
+}
+
+// GetByID mocks base method.
+func (m *MockMovieStore) GetByID(ctx context.Context, id int64) (*models.Movie, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockMovieStoreMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockMovieStore)(nil).GetByID), ctx, id)
+}
+
+// GetRevision mocks base method.
+func (m *MockMovieStore) GetRevision(ctx context.Context, movieID int64, revision int) (*models.MovieRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", ctx, movieID, revision)
+	ret0, _ := ret[0].(*models.MovieRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockMovieStoreMockRecorder) GetRevision(ctx, movieID, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockMovieStore)(nil).GetRevision), ctx, movieID, revision)
+}
+
+// List mocks base method.
+func (m *MockMovieStore) List(ctx context.Context, query *models.MovieQuery) ([]*models.Movie, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, query)
+	ret0, _ := ret[0].([]*models.Movie)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockMovieStoreMockRecorder) List(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockMovieStore)(nil).List), ctx, query)
+}
+
+// ListDeleted mocks base method.
+func (m *MockMovieStore) ListDeleted(ctx context.Context, page, pageSize int) ([]*models.Movie, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeleted", ctx, page, pageSize)
+	ret0, _ := ret[0].([]*models.Movie)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListDeleted indicates an expected call of ListDeleted.
+func (mr *MockMovieStoreMockRecorder) ListDeleted(ctx, page, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeleted", reflect.TypeOf((*MockMovieStore)(nil).ListDeleted), ctx, page, pageSize)
+}
+
+// ListRevisions mocks base method.
+func (m *MockMovieStore) ListRevisions(ctx context.Context, movieID int64, page, pageSize int) ([]*models.MovieRevision, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, movieID, page, pageSize)
+	ret0, _ := ret[0].([]*models.MovieRevision)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockMovieStoreMockRecorder) ListRevisions(ctx, movieID, page, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockMovieStore)(nil).ListRevisions), ctx, movieID, page, pageSize)
+}
+
+// Merge mocks base method.
+func (m *MockMovieStore) Merge(ctx context.Context, survivorID, duplicateID int64) (*models.MovieMergeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", ctx, survivorID, duplicateID)
+	ret0, _ := ret[0].(*models.MovieMergeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockMovieStoreMockRecorder) Merge(ctx, survivorID, duplicateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockMovieStore)(nil).Merge), ctx, survivorID, duplicateID)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockMovieStore) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockMovieStoreMockRecorder) PurgeDeleted(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockMovieStore)(nil).PurgeDeleted), ctx, before)
+}
+
+// RemoveImage mocks base method.
+func (m *MockMovieStore) RemoveImage(ctx context.Context, movieID int64, kind string) (*models.Movie, *models.MovieImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveImage", ctx, movieID, kind)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(*models.MovieImage)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RemoveImage indicates an expected call of RemoveImage.
+func (mr *MockMovieStoreMockRecorder) RemoveImage(ctx, movieID, kind interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveImage", reflect.TypeOf((*MockMovieStore)(nil).RemoveImage), ctx, movieID, kind)
+}
+
+// Restore mocks base method.
+func (m *MockMovieStore) Restore(ctx context.Context, id int64) (*models.Movie, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockMovieStoreMockRecorder) Restore(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockMovieStore)(nil).Restore), ctx, id)
+}
+
+// Revert mocks base method.
+func (m *MockMovieStore) Revert(ctx context.Context, movieID int64, revision int) (*models.Movie, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revert", ctx, movieID, revision)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revert indicates an expected call of Revert.
+func (mr *MockMovieStoreMockRecorder) Revert(ctx, movieID, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revert", reflect.TypeOf((*MockMovieStore)(nil).Revert), ctx, movieID, revision)
+}
+
+// SetImage mocks base method.
+func (m *MockMovieStore) SetImage(ctx context.Context, movieID int64, kind string, image *models.MovieImage) (*models.Movie, *models.MovieImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetImage", ctx, movieID, kind, image)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(*models.MovieImage)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SetImage indicates an expected call of SetImage.
+func (mr *MockMovieStoreMockRecorder) SetImage(ctx, movieID, kind, image interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetImage", reflect.TypeOf((*MockMovieStore)(nil).SetImage), ctx, movieID, kind, image)
+}
+
+// Transition mocks base method.
+func (m *MockMovieStore) Transition(ctx context.Context, id int64, transition models.MovieTransition, publishAt *time.Time, ifMatch []int) (*models.Movie, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transition", ctx, id, transition, publishAt, ifMatch)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transition indicates an expected call of Transition.
+func (mr *MockMovieStoreMockRecorder) Transition(ctx, id, transition, publishAt, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transition", reflect.TypeOf((*MockMovieStore)(nil).Transition), ctx, id, transition, publishAt, ifMatch)
+}
+
+// Update mocks base method.
+func (m *MockMovieStore) Update(ctx context.Context, id int64, input *models.UpdateMovieInput, ifMatch []int) (*models.Movie, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, id, input, ifMatch)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockMovieStoreMockRecorder) Update(ctx, id, input, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockMovieStore)(nil).Update), ctx, id, input, ifMatch)
+}
+
+// UpsertByExternalID mocks base method.
+func (m *MockMovieStore) UpsertByExternalID(ctx context.Context, source, externalID string, input *models.ReplaceMovieInput, ifMatch []int) (*models.Movie, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertByExternalID", ctx, source, externalID, input, ifMatch)
+	ret0, _ := ret[0].(*models.Movie)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpsertByExternalID indicates an expected call of UpsertByExternalID.
+func (mr *MockMovieStoreMockRecorder) UpsertByExternalID(ctx, source, externalID, input, ifMatch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertByExternalID", reflect.TypeOf((*MockMovieStore)(nil).UpsertByExternalID), ctx, source, externalID, input, ifMatch)
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/cache"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/logger"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Movie cache tags. A write to a movie bumps that movie's tag and the list
+// tag; a write that can touch any number of movies bumps the all tag, which
+// is part of every key.
+const (
+	movieCacheTagAll  = "movies"
+	movieCacheTagList = "movies:list"
+)
+
+func movieCacheTag(id int64) string {
+	return "movie:" + strconv.FormatInt(id, 10)
+}
+
+// movieCache is the read-through cache in front of GetByID and List
+type movieCache struct {
+	cache  *cache.Cache
+	config *config.CacheConfig
+}
+
+// cachedMovieList is a cached page of List
+type cachedMovieList struct {
+	Movies     []*models.Movie `json:"movies"`
+	TotalCount int             `json:"total_count"`
+}
+
+// UseCache makes GetByID and List read through c. Writes made through the
+// repository invalidate what they change; the genre, person and review
+// repositories do the same once given the cache with UseMovieCache.
+func (r *MovieRepository) UseCache(c *cache.Cache, cfg *config.CacheConfig) {
+	r.cache = &movieCache{cache: c, config: cfg}
+}
+
+func (c *movieCache) getByID(ctx context.Context, id int64, load func(context.Context, int64) (*models.Movie, error)) (*models.Movie, error) {
+	generations, err := c.cache.Generations(ctx, movieCacheTagAll, movieCacheTag(id))
+	if err != nil {
+		logger.WarnContext(ctx, "Movie cache unavailable", logger.Field("error", err), logger.Field("cache", "bypass"))
+		return load(ctx, id)
+	}
+
+	key := fmt.Sprintf("movie:%d:%d.%d", id, generations[0], generations[1])
+	movie := &models.Movie{}
+	err = c.cache.Fetch(ctx, key, c.config.MovieTTL, movie, func(ctx context.Context) (interface{}, error) {
+		return load(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+func (c *movieCache) list(ctx context.Context, query *models.MovieQuery, load func(context.Context, *models.MovieQuery) ([]*models.Movie, int, error)) ([]*models.Movie, int, error) {
+	normalized := normalizeMovieQuery(query)
+	query.Page, query.PageSize = normalized.Page, normalized.PageSize
+
+	generations, err := c.cache.Generations(ctx, movieCacheTagAll, movieCacheTagList)
+	if err != nil {
+		logger.WarnContext(ctx, "Movie cache unavailable", logger.Field("error", err), logger.Field("cache", "bypass"))
+		return load(ctx, &normalized)
+	}
+
+	// The query is flat, so its Go syntax representation is a stable key
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", normalized)))
+	key := fmt.Sprintf("movies:list:%d.%d:%x", generations[0], generations[1], sum[:16])
+
+	var page cachedMovieList
+	err = c.cache.Fetch(ctx, key, c.config.ListTTL, &page, func(ctx context.Context) (interface{}, error) {
+		movies, totalCount, err := load(ctx, &normalized)
+		if err != nil {
+			return nil, err
+		}
+		return &cachedMovieList{Movies: movies, TotalCount: totalCount}, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return page.Movies, page.TotalCount, nil
+}
+
+// normalizeMovieQuery maps queries that List answers the same way to the
+// same value, so they share a cache entry
+func normalizeMovieQuery(query *models.MovieQuery) models.MovieQuery {
+	normalized := *query
+
+	// Title and director match case-insensitively
+	normalized.Title = strings.ToLower(normalized.Title)
+	normalized.Director = strings.ToLower(normalized.Director)
+	if normalized.Genre != "" {
+		normalized.Genre = models.Slugify(normalized.Genre)
+	}
+
+	// Unknown sort keys fall back to the default order, where the direction
+	// is fixed
+	if _, ok := movieSortColumns[normalized.SortBy]; !ok {
+		normalized.SortBy, normalized.Order = "", ""
+	} else if strings.ToUpper(normalized.Order) == "DESC" {
+		normalized.Order = "desc"
+	} else {
+		normalized.Order = "asc"
+	}
+
+	if normalized.Page <= 0 {
+		normalized.Page = 1
+	}
+	if normalized.PageSize <= 0 {
+		normalized.PageSize = 10
+	}
+
+	return normalized
+}
+
+// invalidate drops the cached reads of the given movies and every cached
+// list. It is a no-op on a nil cache.
+func (c *movieCache) invalidate(ctx context.Context, ids ...int64) {
+	if c == nil {
+		return
+	}
+
+	tags := []string{movieCacheTagList}
+	for _, id := range ids {
+		tags = append(tags, movieCacheTag(id))
+	}
+
+	c.bump(ctx, tags...)
+}
+
+// invalidateAll drops every cached movie read. It is a no-op on a nil cache.
+func (c *movieCache) invalidateAll(ctx context.Context) {
+	if c != nil {
+		c.bump(ctx, movieCacheTagAll)
+	}
+}
+
+// bump runs even if the request that made the write has gone away. When it
+// fails the stale entries live until their TTL.
+func (c *movieCache) bump(ctx context.Context, tags ...string) {
+	if err := c.cache.Bump(context.WithoutCancel(ctx), tags...); err != nil {
+		logger.ErrorContext(ctx, "Movie cache invalidation failed", logger.Field("error", err), logger.Field("tags", tags))
+	}
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrMergeSameMovie = errors.New("cannot merge a movie into itself")
+)
+
+// duplicatePairs selects pairs of live movies released in the same year whose
+// titles have a trigram similarity of at least $1. Pairs where both movies
+// have directors are only kept if they share one.
+const duplicatePairs = `
+	WITH directors AS (
+		SELECT movie_id, array_agg(person_id) AS ids
+		FROM movie_credits
+		WHERE role = 'director'
+		GROUP BY movie_id
+	), pairs AS (
+		SELECT a.id AS movie_id, b.id AS duplicate_id,
+			similarity(a.title, b.title) AS score,
+			COALESCE(da.ids && db.ids, FALSE) AS shared_director,
+			da.ids IS NULL OR db.ids IS NULL AS missing_director
+		FROM movies a
+		JOIN movies b ON a.id < b.id AND a.title % b.title
+		LEFT JOIN directors da ON da.movie_id = a.id
+		LEFT JOIN directors db ON db.movie_id = b.id
+		WHERE a.deleted_at IS NULL AND b.deleted_at IS NULL
+			AND EXTRACT(YEAR FROM a.release_date) = EXTRACT(YEAR FROM b.release_date)
+			AND similarity(a.title, b.title) >= $1
+	)
+`
+
+// FindDuplicates returns a page of likely duplicate pairs, most similar first
+func (r *MovieRepository) FindDuplicates(ctx context.Context, minSimilarity float64, page, pageSize int) ([]*models.DuplicateCandidate, int, error) {
+	var candidates []*models.DuplicateCandidate
+	var totalCount int
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// The % operator filters on pg_trgm.similarity_threshold, so it is set
+		// to the requested minimum for this transaction; otherwise the
+		// server's setting would silently override it
+		threshold := strconv.FormatFloat(minSimilarity, 'f', -1, 64)
+		if _, err := tx.ExecContext(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, threshold); err != nil {
+			return err
+		}
+
+		var err error
+		candidates, totalCount, err = findDuplicates(ctx, tx, minSimilarity, page, pageSize)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return candidates, totalCount, nil
+}
+
+func findDuplicates(ctx context.Context, q queryer, minSimilarity float64, page, pageSize int) ([]*models.DuplicateCandidate, int, error) {
+	var totalCount int
+	err := q.QueryRowContext(ctx, duplicatePairs+`
+		SELECT COUNT(*) FROM pairs WHERE shared_director OR missing_director
+	`, minSimilarity).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := q.QueryContext(ctx, duplicatePairs+`
+		SELECT movie_id, duplicate_id, score, shared_director
+		FROM pairs
+		WHERE shared_director OR missing_director
+		ORDER BY score DESC, movie_id, duplicate_id
+		LIMIT $2 OFFSET $3
+	`, minSimilarity, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	type pair struct {
+		candidate            *models.DuplicateCandidate
+		movieID, duplicateID int64
+	}
+
+	pairs := []pair{}
+	ids := []int64{}
+	for rows.Next() {
+		p := pair{candidate: &models.DuplicateCandidate{}}
+		if err := rows.Scan(&p.movieID, &p.duplicateID, &p.candidate.Similarity, &p.candidate.SharedDirector); err != nil {
+			return nil, 0, err
+		}
+		pairs = append(pairs, p)
+		ids = append(ids, p.movieID, p.duplicateID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	movies, err := getMoviesByID(ctx, q, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	candidates := make([]*models.DuplicateCandidate, 0, len(pairs))
+	for _, p := range pairs {
+		p.candidate.Movie = movies[p.movieID]
+		p.candidate.Duplicate = movies[p.duplicateID]
+		candidates = append(candidates, p.candidate)
+	}
+
+	return candidates, totalCount, nil
+}
+
+// Merge folds a duplicate movie into the surviving one. Reviews, list
+// entries, watchlist and favorites entries, credits and external IDs move to
+// the survivor unless the survivor already has an equivalent row, and the
+// duplicate is then moved to the trash. Both changes are recorded in the
+// revision history.
+func (r *MovieRepository) Merge(ctx context.Context, survivorID, duplicateID int64) (*models.MovieMergeResult, error) {
+	if survivorID == duplicateID {
+		return nil, ErrMergeSameMovie
+	}
+
+	result := &models.MovieMergeResult{DuplicateID: duplicateID}
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// Lock both rows in id order so concurrent merges cannot deadlock
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id FROM movies WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE
+		`, pq.Array([]int64{survivorID, duplicateID}))
+		if err != nil {
+			return err
+		}
+		locked := 0
+		for rows.Next() {
+			locked++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if locked != 2 {
+			return ErrMovieNotFound
+		}
+
+		survivor, err := getMovieForUpdate(ctx, tx, survivorID)
+		if err != nil {
+			return err
+		}
+
+		duplicate, err := getMovieForUpdate(ctx, tx, duplicateID)
+		if err != nil {
+			return err
+		}
+
+		moves := []struct {
+			count *int64
+			query string
+		}{
+			{&result.MovedReviews, `
+				UPDATE reviews SET movie_id = $1
+				WHERE movie_id = $2
+					AND user_id NOT IN (SELECT user_id FROM reviews WHERE movie_id = $1)`},
+			{&result.MovedListItems, `
+				UPDATE list_items SET movie_id = $1
+				WHERE movie_id = $2
+					AND list_id NOT IN (SELECT list_id FROM list_items WHERE movie_id = $1)`},
+			{&result.MovedSavedMovies, `
+				UPDATE user_movies um SET movie_id = $1
+				WHERE movie_id = $2
+					AND NOT EXISTS (
+						SELECT 1 FROM user_movies s
+						WHERE s.movie_id = $1 AND s.user_id = um.user_id AND s.kind = um.kind
+					)`},
+			{&result.MovedCredits, `
+				UPDATE movie_credits mc SET movie_id = $1
+				WHERE movie_id = $2
+					AND NOT EXISTS (
+						SELECT 1 FROM movie_credits s
+						WHERE s.movie_id = $1 AND s.person_id = mc.person_id
+							AND s.role = mc.role AND s.character_name = mc.character_name
+					)`},
+			{&result.MovedExternalIDs, `UPDATE movie_external_ids SET movie_id = $1 WHERE movie_id = $2`},
+		}
+
+		for _, move := range moves {
+			res, err := tx.ExecContext(ctx, move.query, survivorID, duplicateID)
+			if err != nil {
+				return err
+			}
+			if *move.count, err = res.RowsAffected(); err != nil {
+				return err
+			}
+		}
+
+		if err := refreshMovieRating(ctx, tx, survivorID); err != nil {
+			return err
+		}
+		if err := refreshMovieRating(ctx, tx, duplicateID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if _, err := tx.ExecContext(ctx, `UPDATE movies SET updated_at = $1, version = version + 1 WHERE id = $2`, now, survivorID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE movies SET deleted_at = $1, version = version + 1 WHERE id = $2`, now, duplicateID); err != nil {
+			return err
+		}
+
+		merged, err := getMovieForUpdate(ctx, tx, survivorID)
+		if err != nil {
+			return err
+		}
+
+		if err := recordRevision(ctx, tx, models.RevisionActionMerge, survivor, merged); err != nil {
+			return err
+		}
+		if err := recordRevision(ctx, tx, models.RevisionActionDelete, duplicate, duplicate); err != nil {
+			return err
+		}
+
+		result.Movie = merged
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.invalidate(ctx, survivorID, duplicateID)
+	return result, nil
+}
+
+// getMoviesByID loads movies with their relations, keyed by ID, including
+// trashed ones
+func getMoviesByID(ctx context.Context, q queryer, ids []int64) (map[int64]*models.Movie, error) {
+	byID := map[int64]*models.Movie{}
+	if len(ids) == 0 {
+		return byID, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT `+movieColumns+` FROM movies WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := []*models.Movie{}
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+		byID[movie.ID] = movie
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadMovieRelations(ctx, q, movies); err != nil {
+		return nil, err
+	}
+
+	return byID, nil
+}
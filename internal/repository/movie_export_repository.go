@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/models"
+)
+
+// exportFetchSize is the number of rows fetched from the export cursor at a
+// time, which bounds the memory an export holds
+const exportFetchSize = 500
+
+// Export calls fn for every live movie matching the query's filters, in the
+// query's sort order, with genres and credits loaded. Pagination fields are
+// ignored. Rows are read through a server-side cursor in a read-only
+// transaction, so the whole result is never held in memory and every row
+// comes from the same snapshot. Export stops at the first error fn returns,
+// or when ctx is done.
+func (r *MovieRepository) Export(ctx context.Context, query *models.MovieQuery, fn func(*models.Movie) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	whereClause, args := buildMovieFilters(query, []interface{}{})
+	declare := `DECLARE movie_export NO SCROLL CURSOR FOR SELECT ` + movieColumns +
+		` FROM movies WHERE deleted_at IS NULL` + whereClause + movieOrderBy(query.SortBy, query.Order, nil)
+
+	if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf(`FETCH FORWARD %d FROM movie_export`, exportFetchSize)
+	for {
+		movies, err := fetchMovies(ctx, tx, fetch)
+		if err != nil {
+			return err
+		}
+
+		if len(movies) == 0 {
+			return nil
+		}
+
+		if err := loadMovieRelations(ctx, tx, movies); err != nil {
+			return err
+		}
+
+		for _, movie := range movies {
+			// Stop promptly once the client is gone or the request timed out
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(movie); err != nil {
+				return err
+			}
+		}
+
+		if len(movies) < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+func fetchMovies(ctx context.Context, tx *sql.Tx, fetch string) ([]*models.Movie, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := []*models.Movie{}
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+
+	return movies, rows.Err()
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrExternalIDExists = errors.New("external ID is already linked to a movie")
+)
+
+// GetByExternalID returns the live movie linked to an external identifier
+func (r *MovieRepository) GetByExternalID(ctx context.Context, source, externalID string) (*models.Movie, error) {
+	query := `
+		SELECT ` + qualifiedMovieColumns() + `
+		FROM movie_external_ids e
+		JOIN movies ON movies.id = e.movie_id
+		WHERE e.source = $1 AND e.external_id = $2 AND movies.deleted_at IS NULL`
+
+	movie, err := scanMovie(r.db.QueryRowContext(ctx, query, source, externalID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	if err := loadMovieRelations(ctx, r.db, []*models.Movie{movie}); err != nil {
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+// UpsertByExternalID replaces the movie linked to an external identifier, or
+// creates and links a new movie when there is none, in one transaction.
+// ifMatch only applies when a movie already exists and works as in Update.
+func (r *MovieRepository) UpsertByExternalID(ctx context.Context, source, externalID string, input *models.ReplaceMovieInput, ifMatch []int) (*models.Movie, bool, error) {
+	var movie *models.Movie
+	var created bool
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		movie, created, err = upsertByExternalID(ctx, tx, source, externalID, input, ifMatch, models.MovieStatusDraft)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	r.cache.invalidate(ctx, movie.ID)
+	return movie, created, nil
+}
+
+// upsertByExternalID takes a transaction-scoped advisory lock on the external
+// identifier first, so concurrent upserts of the same new identifier queue up
+// instead of both creating a movie. A new movie starts in status.
+func upsertByExternalID(ctx context.Context, tx *sql.Tx, source, externalID string, input *models.ReplaceMovieInput, ifMatch []int, status string) (*models.Movie, bool, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, source+":"+externalID); err != nil {
+		return nil, false, err
+	}
+
+	var movieID int64
+	err := tx.QueryRowContext(ctx, `
+		SELECT movie_id FROM movie_external_ids WHERE source = $1 AND external_id = $2
+	`, source, externalID).Scan(&movieID)
+	if err == nil {
+		movie, err := updateMovie(ctx, tx, movieID, input.UpdateInput(), ifMatch, models.RevisionActionUpdate)
+		return movie, false, err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	movie, err := createMovie(ctx, tx, input.CreateInput(), status)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := linkExternalID(ctx, tx, movie.ID, source, externalID); err != nil {
+		return nil, false, err
+	}
+
+	movie.ExternalIDs = append(movie.ExternalIDs, &models.ExternalID{Source: source, ID: externalID})
+
+	return movie, true, nil
+}
+
+// linkExternalID records an external identifier for a movie
+func linkExternalID(ctx context.Context, q queryer, movieID int64, source, externalID string) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO movie_external_ids (source, external_id, movie_id) VALUES ($1, $2, $3)
+	`, source, externalID, movieID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrExternalIDExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// loadMovieExternalIDs attaches external identifiers to the given movies
+// with a single query
+func loadMovieExternalIDs(ctx context.Context, q queryer, movies []*models.Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Movie, len(movies))
+	ids := make([]int64, 0, len(movies))
+	for _, movie := range movies {
+		movie.ExternalIDs = []*models.ExternalID{}
+		byID[movie.ID] = movie
+		ids = append(ids, movie.ID)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT movie_id, source, external_id
+		FROM movie_external_ids
+		WHERE movie_id = ANY($1)
+		ORDER BY source, external_id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID int64
+		externalID := &models.ExternalID{}
+		if err := rows.Scan(&movieID, &externalID.Source, &externalID.ID); err != nil {
+			return err
+		}
+		if movie, ok := byID[movieID]; ok {
+			movie.ExternalIDs = append(movie.ExternalIDs, externalID)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrImageNotFound = errors.New("image not found")
+)
+
+// storedThumbnail is the JSON stored for each thumbnail. Unlike the API
+// representation it keeps the blob key.
+type storedThumbnail struct {
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+func scanMovieImage(row rowScanner, extra ...interface{}) (*models.MovieImage, error) {
+	image := &models.MovieImage{}
+	var thumbnails []byte
+
+	dest := append(extra, &image.Key, &image.URL, &image.ContentType, &image.Width, &image.Height, &thumbnails)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	var stored []storedThumbnail
+	if err := json.Unmarshal(thumbnails, &stored); err != nil {
+		return nil, err
+	}
+
+	image.Thumbnails = make([]*models.ImageThumbnail, 0, len(stored))
+	for _, t := range stored {
+		image.Thumbnails = append(image.Thumbnails, &models.ImageThumbnail{
+			Key: t.Key, URL: t.URL, Width: t.Width, Height: t.Height,
+		})
+	}
+
+	return image, nil
+}
+
+// SetImage attaches a poster or backdrop to a live movie, replacing the
+// previous one. It returns the updated movie and the replaced image, if any,
+// so the caller can delete its blobs.
+func (r *MovieRepository) SetImage(ctx context.Context, movieID int64, kind string, image *models.MovieImage) (*models.Movie, *models.MovieImage, error) {
+	stored := make([]storedThumbnail, 0, len(image.Thumbnails))
+	for _, t := range image.Thumbnails {
+		stored = append(stored, storedThumbnail{Key: t.Key, URL: t.URL, Width: t.Width, Height: t.Height})
+	}
+
+	thumbnails, err := json.Marshal(stored)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var movie, previousMovie *models.Movie
+	err = withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		if previousMovie, err = getMovieForUpdate(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO movie_images (movie_id, kind, storage_key, url, content_type, width, height, thumbnails, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (movie_id, kind) DO UPDATE SET
+				storage_key = EXCLUDED.storage_key,
+				url = EXCLUDED.url,
+				content_type = EXCLUDED.content_type,
+				width = EXCLUDED.width,
+				height = EXCLUDED.height,
+				thumbnails = EXCLUDED.thumbnails,
+				updated_at = EXCLUDED.updated_at
+		`, movieID, kind, image.Key, image.URL, image.ContentType, image.Width, image.Height, thumbnails, now)
+		if err != nil {
+			return err
+		}
+
+		movie, err = touchMovie(ctx, tx, movieID, now)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.cache.invalidate(ctx, movieID)
+	return movie, previousMovie.Images[kind], nil
+}
+
+// RemoveImage detaches a poster or backdrop from a live movie and returns
+// it so the caller can delete its blobs
+func (r *MovieRepository) RemoveImage(ctx context.Context, movieID int64, kind string) (*models.Movie, *models.MovieImage, error) {
+	var movie *models.Movie
+	var removed *models.MovieImage
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		previousMovie, err := getMovieForUpdate(ctx, tx, movieID)
+		if err != nil {
+			return err
+		}
+
+		if removed = previousMovie.Images[kind]; removed == nil {
+			return ErrImageNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM movie_images WHERE movie_id = $1 AND kind = $2`, movieID, kind)
+		if err != nil {
+			return err
+		}
+
+		movie, err = touchMovie(ctx, tx, movieID, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.cache.invalidate(ctx, movieID)
+	return movie, removed, nil
+}
+
+// touchMovie bumps a movie's version and update time after a change to its
+// related rows and returns it reloaded
+func touchMovie(ctx context.Context, tx *sql.Tx, movieID int64, now time.Time) (*models.Movie, error) {
+	_, err := tx.ExecContext(ctx, `UPDATE movies SET version = version + 1, updated_at = $1 WHERE id = $2`, now, movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	return getMovieForUpdate(ctx, tx, movieID)
+}
+
+// loadMovieImages attaches posters and backdrops to the given movies with a
+// single query
+func loadMovieImages(ctx context.Context, q queryer, movies []*models.Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Movie, len(movies))
+	ids := make([]int64, 0, len(movies))
+	for _, movie := range movies {
+		movie.Images = map[string]*models.MovieImage{}
+		byID[movie.ID] = movie
+		ids = append(ids, movie.ID)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT movie_id, kind, storage_key, url, content_type, width, height, thumbnails
+		FROM movie_images
+		WHERE movie_id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID int64
+		var kind string
+		image, err := scanMovieImage(rows, &movieID, &kind)
+		if err != nil {
+			return err
+		}
+		if movie, ok := byID[movieID]; ok {
+			movie.Images[kind] = image
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/models"
+)
+
+// MovieImport is a transaction that imported rows are upserted into. Each row
+// runs under its own savepoint, so a failing row is undone without aborting
+// the rows before it.
+type MovieImport struct {
+	tx     *sql.Tx
+	repo   *MovieRepository
+	status string
+}
+
+// BeginImport starts a transaction for importing movies. Movies the import
+// creates start in status; movies it updates keep theirs. The caller must
+// finish it with Commit or Rollback.
+func (r *MovieRepository) BeginImport(ctx context.Context, status string) (*MovieImport, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MovieImport{tx: tx, repo: r, status: status}, nil
+}
+
+// Upsert imports a single row. A row whose source and external ID are
+// already linked to a movie replaces that movie, any other row creates a new
+// movie. rowErr reports why the row was rejected; err is only set when the
+// transaction itself can no longer be used.
+func (i *MovieImport) Upsert(ctx context.Context, row *models.MovieImportRow) (created bool, rowErr error, err error) {
+	if _, err := i.tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+		return false, nil, err
+	}
+
+	created, rowErr = upsertImportRow(ctx, i.tx, row, i.status)
+	if rowErr != nil {
+		if _, err := i.tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`); err != nil {
+			return false, rowErr, fmt.Errorf("rolling back row %d: %w", row.Line, err)
+		}
+		return false, rowErr, nil
+	}
+
+	if _, err := i.tx.ExecContext(ctx, `RELEASE SAVEPOINT import_row`); err != nil {
+		return false, nil, err
+	}
+
+	return created, nil, nil
+}
+
+func (i *MovieImport) Commit() error {
+	if err := i.tx.Commit(); err != nil {
+		return err
+	}
+
+	// An import can touch any number of movies
+	i.repo.cache.invalidateAll(context.Background())
+	return nil
+}
+
+func (i *MovieImport) Rollback() error {
+	return i.tx.Rollback()
+}
+
+func upsertImportRow(ctx context.Context, tx *sql.Tx, row *models.MovieImportRow, status string) (bool, error) {
+	if row.ExternalID != "" {
+		_, created, err := upsertByExternalID(ctx, tx, row.Source, row.ExternalID, &row.ReplaceMovieInput, nil, status)
+		return created, err
+	}
+
+	_, err := createMovie(ctx, tx, row.CreateInput(), status)
+	return err == nil, err
+}
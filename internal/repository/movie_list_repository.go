@@ -0,0 +1,468 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"strings"
+	"time"
+)
+
+var (
+	ErrListNotFound      = errors.New("list not found")
+	ErrListNotOwned      = errors.New("list belongs to another user")
+	ErrListItemNotFound  = errors.New("movie is not on the list")
+	ErrListItemExists    = errors.New("movie is already on the list")
+	ErrListOrderMismatch = errors.New("movie IDs do not match the list items")
+)
+
+var movieListSelect = `
+	SELECT l.id, l.user_id, TRIM(u.first_name || ' ' || u.last_name), l.title, l.description, l.visibility,
+		(
+			SELECT COUNT(*) FROM list_items li
+			JOIN movies m ON m.id = li.movie_id
+			WHERE li.list_id = l.id AND m.deleted_at IS NULL AND ` + publishedCondition("m") + `
+		),
+		l.view_count, l.created_at, l.updated_at
+	FROM lists l
+	JOIN users u ON u.id = l.user_id
+`
+
+// MovieListRepository handles database operations related to user-curated movie lists
+type MovieListRepository struct {
+	db *database.PostgresDB
+}
+
+// NewMovieListRepository creates a new MovieListRepository
+func NewMovieListRepository(db *database.PostgresDB) *MovieListRepository {
+	return &MovieListRepository{
+		db: db,
+	}
+}
+
+func scanMovieList(row rowScanner) (*models.MovieList, error) {
+	list := &models.MovieList{}
+	err := row.Scan(
+		&list.ID, &list.UserID, &list.Owner, &list.Title, &list.Description, &list.Visibility,
+		&list.ItemCount, &list.ViewCount, &list.CreatedAt, &list.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *MovieListRepository) Create(ctx context.Context, userID int64, input *models.CreateMovieListInput) (*models.MovieList, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO lists (user_id, title, description, visibility, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING id
+	`, userID, strings.TrimSpace(input.Title), input.Description, input.Visibility, time.Now()).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.get(ctx, r.db, id)
+}
+
+// GetByID returns a list with its items. Private lists are only visible to
+// their owner; viewerID is 0 for anonymous callers. Views by anyone other than
+// the owner count towards the list's popularity.
+func (r *MovieListRepository) GetByID(ctx context.Context, id, viewerID int64) (*models.MovieList, error) {
+	list, err := r.get(ctx, r.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if list.UserID != viewerID {
+		if list.Visibility == models.ListVisibilityPrivate {
+			return nil, ErrListNotFound
+		}
+
+		if _, err := r.db.ExecContext(ctx, `UPDATE lists SET view_count = view_count + 1 WHERE id = $1`, id); err != nil {
+			return nil, err
+		}
+	}
+
+	list.Items, err = loadListItems(ctx, r.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *MovieListRepository) Update(ctx context.Context, id, userID int64, input *models.UpdateMovieListInput) (*models.MovieList, error) {
+	var list *models.MovieList
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockOwnedList(ctx, tx, id, userID); err != nil {
+			return err
+		}
+
+		updates := []string{}
+		args := []interface{}{}
+		argPosition := 1
+
+		if input.Title != nil {
+			updates = append(updates, fmt.Sprintf("title = $%d", argPosition))
+			args = append(args, strings.TrimSpace(*input.Title))
+			argPosition++
+		}
+
+		if input.Description != nil {
+			updates = append(updates, fmt.Sprintf("description = $%d", argPosition))
+			args = append(args, *input.Description)
+			argPosition++
+		}
+
+		if input.Visibility != nil {
+			updates = append(updates, fmt.Sprintf("visibility = $%d", argPosition))
+			args = append(args, *input.Visibility)
+			argPosition++
+		}
+
+		updates = append(updates, fmt.Sprintf("updated_at = $%d", argPosition))
+		args = append(args, time.Now())
+		argPosition++
+
+		args = append(args, id)
+
+		query := fmt.Sprintf(`UPDATE lists SET %s WHERE id = $%d`, strings.Join(updates, ", "), argPosition)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+
+		var err error
+		list, err = r.get(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		list.Items, err = loadListItems(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *MovieListRepository) Delete(ctx context.Context, id, userID int64) error {
+	return withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockOwnedList(ctx, tx, id, userID); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `DELETE FROM lists WHERE id = $1`, id)
+		return err
+	})
+}
+
+// AddItem puts a movie on the list at the given position, shifting later
+// items down. A zero position appends it. Positions can have gaps where a
+// purged movie's item was removed by the cascade, so appending goes after the
+// last position rather than the item count.
+func (r *MovieListRepository) AddItem(ctx context.Context, id, userID int64, input *models.AddMovieListItemInput) (*models.MovieList, error) {
+	return r.modifyItems(ctx, id, userID, func(tx *sql.Tx) error {
+		var last int
+		err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(position), 0) FROM list_items WHERE list_id = $1`, id).Scan(&last)
+		if err != nil {
+			return err
+		}
+
+		position := input.Position
+		if position <= 0 || position > last+1 {
+			position = last + 1
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE list_items SET position = position + 1 WHERE list_id = $1 AND position >= $2
+		`, id, position)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO list_items (list_id, movie_id, position, note, added_at)
+			SELECT $1, id, $3, $4, $5 FROM movies WHERE id = $2 AND deleted_at IS NULL AND `+publishedCondition("movies")+`
+		`, id, input.MovieID, position, input.Note, time.Now())
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrListItemExists
+			}
+			return err
+		}
+
+		return requireRowAffected(result, ErrMovieNotFound)
+	})
+}
+
+// UpdateItem changes the note attached to a movie on the list
+func (r *MovieListRepository) UpdateItem(ctx context.Context, id, userID, movieID int64, input *models.UpdateMovieListItemInput) (*models.MovieList, error) {
+	return r.modifyItems(ctx, id, userID, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE list_items SET note = $1 WHERE list_id = $2 AND movie_id = $3
+		`, input.Note, id, movieID)
+		if err != nil {
+			return err
+		}
+
+		return requireRowAffected(result, ErrListItemNotFound)
+	})
+}
+
+// RemoveItem takes a movie off the list and closes the gap it leaves
+func (r *MovieListRepository) RemoveItem(ctx context.Context, id, userID, movieID int64) (*models.MovieList, error) {
+	return r.modifyItems(ctx, id, userID, func(tx *sql.Tx) error {
+		var position int
+		err := tx.QueryRowContext(ctx, `
+			DELETE FROM list_items WHERE list_id = $1 AND movie_id = $2 RETURNING position
+		`, id, movieID).Scan(&position)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrListItemNotFound
+			}
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE list_items SET position = position - 1 WHERE list_id = $1 AND position > $2
+		`, id, position)
+		return err
+	})
+}
+
+// Reorder rewrites the item positions. movieIDs must contain exactly the
+// visible movies on the list; items whose movie is in the trash or not
+// published keep their relative order after them.
+func (r *MovieListRepository) Reorder(ctx context.Context, id, userID int64, movieIDs []int64) (*models.MovieList, error) {
+	return r.modifyItems(ctx, id, userID, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT li.movie_id, m.deleted_at IS NOT NULL OR NOT (`+publishedCondition("m")+`)
+			FROM list_items li
+			JOIN movies m ON m.id = li.movie_id
+			WHERE li.list_id = $1
+			ORDER BY li.position
+		`, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		visible := map[int64]bool{}
+		hidden := []int64{}
+		for rows.Next() {
+			var movieID int64
+			var hiddenMovie bool
+			if err := rows.Scan(&movieID, &hiddenMovie); err != nil {
+				return err
+			}
+			if hiddenMovie {
+				hidden = append(hidden, movieID)
+			} else {
+				visible[movieID] = true
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(movieIDs) != len(visible) {
+			return ErrListOrderMismatch
+		}
+		seen := map[int64]bool{}
+		for _, movieID := range movieIDs {
+			if !visible[movieID] || seen[movieID] {
+				return ErrListOrderMismatch
+			}
+			seen[movieID] = true
+		}
+
+		for i, movieID := range append(append([]int64{}, movieIDs...), hidden...) {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE list_items SET position = $1 WHERE list_id = $2 AND movie_id = $3
+			`, i+1, id, movieID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ListByUser returns a page of a user's lists. Unless includePrivate is set
+// only public lists are returned.
+func (r *MovieListRepository) ListByUser(ctx context.Context, userID int64, includePrivate bool, page, pageSize int) ([]*models.MovieList, int, error) {
+	where := ` WHERE l.user_id = $1`
+	if !includePrivate {
+		where += ` AND l.visibility = 'public'`
+	}
+
+	return r.page(ctx, where, ` ORDER BY l.updated_at DESC, l.id DESC`, []interface{}{userID}, page, pageSize)
+}
+
+// ListPopular returns a page of public lists ordered by view count
+func (r *MovieListRepository) ListPopular(ctx context.Context, page, pageSize int) ([]*models.MovieList, int, error) {
+	return r.page(ctx, ` WHERE l.visibility = 'public'`, ` ORDER BY l.view_count DESC, l.id DESC`, []interface{}{}, page, pageSize)
+}
+
+func (r *MovieListRepository) page(ctx context.Context, where, orderBy string, args []interface{}, page, pageSize int) ([]*models.MovieList, int, error) {
+	var totalCount int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM lists l`+where, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	argPosition := len(args) + 1
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := r.db.QueryContext(ctx, movieListSelect+where+orderBy+
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", argPosition, argPosition+1), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	lists := []*models.MovieList{}
+	for rows.Next() {
+		list, err := scanMovieList(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		lists = append(lists, list)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return lists, totalCount, nil
+}
+
+// modifyItems runs fn against a list owned by userID inside a transaction,
+// bumps the list's updated_at and returns the list with its items
+func (r *MovieListRepository) modifyItems(ctx context.Context, id, userID int64, fn func(tx *sql.Tx) error) (*models.MovieList, error) {
+	var list *models.MovieList
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockOwnedList(ctx, tx, id, userID); err != nil {
+			return err
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE lists SET updated_at = $1 WHERE id = $2`, time.Now(), id); err != nil {
+			return err
+		}
+
+		var err error
+		list, err = r.get(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		list.Items, err = loadListItems(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *MovieListRepository) get(ctx context.Context, q queryer, id int64) (*models.MovieList, error) {
+	list, err := scanMovieList(q.QueryRowContext(ctx, movieListSelect+` WHERE l.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrListNotFound
+		}
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// lockOwnedList locks the list row and checks that userID owns it. Private
+// lists owned by someone else are reported as not found.
+func lockOwnedList(ctx context.Context, q queryer, id, userID int64) error {
+	var ownerID int64
+	var visibility string
+	err := q.QueryRowContext(ctx, `SELECT user_id, visibility FROM lists WHERE id = $1 FOR UPDATE`, id).Scan(&ownerID, &visibility)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrListNotFound
+		}
+		return err
+	}
+
+	if ownerID != userID {
+		if visibility == models.ListVisibilityPrivate {
+			return ErrListNotFound
+		}
+		return ErrListNotOwned
+	}
+
+	return nil
+}
+
+func loadListItems(ctx context.Context, q queryer, listID int64) ([]*models.MovieListItem, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT `+qualifiedMovieColumns()+`, li.position, li.note, li.added_at
+		FROM list_items li
+		JOIN movies ON movies.id = li.movie_id
+		WHERE li.list_id = $1 AND movies.deleted_at IS NULL AND `+publishedCondition("movies")+`
+		ORDER BY li.position
+	`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*models.MovieListItem{}
+	movies := []*models.Movie{}
+	for rows.Next() {
+		item := &models.MovieListItem{}
+		movie, err := scanMovie(scannerFunc(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &item.Position, &item.Note, &item.AddedAt)...)
+		}))
+		if err != nil {
+			return nil, err
+		}
+		item.Movie = movie
+		items = append(items, item)
+		movies = append(movies, movie)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadMovieRelations(ctx, q, movies); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// requireRowAffected returns notFound when an UPDATE or DELETE touched no rows
+func requireRowAffected(result sql.Result, notFound error) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
@@ -12,11 +12,15 @@ import (
 )
 
 var (
-	ErrMovieNotFound = errors.New("movie not found")
+	ErrMovieNotFound        = errors.New("movie not found")
+	ErrMovieVersionMismatch = errors.New("movie has been modified by another request")
 )
 
+const movieColumns = `id, title, description, release_date, rating, average_rating, rating_count, duration, created_at, updated_at, deleted_at, version, status, publish_at`
+
 type MovieRepository struct {
-	db *database.PostgresDB
+	db    *database.PostgresDB
+	cache *movieCache // nil when caching is disabled
 }
 
 func NewMovieRepository(db *database.PostgresDB) *MovieRepository {
@@ -25,45 +29,131 @@ func NewMovieRepository(db *database.PostgresDB) *MovieRepository {
 	}
 }
 
-func (r *MovieRepository) Create(ctx context.Context, input *models.CreateMovieInput) (*models.Movie, error) {
-	query := `
-		INSERT INTO movies (title, description, release_date, rating, duration, genre, director, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
-		RETURNING id, title, description, release_date, rating, duration, genre, director, created_at, updated_at
-	`
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-	now := time.Now()
+func scanMovie(row rowScanner) (*models.Movie, error) {
 	movie := &models.Movie{}
+	var deletedAt, publishAt sql.NullTime
+	err := row.Scan(
+		&movie.ID, &movie.Title, &movie.Description, &movie.ReleaseDate,
+		&movie.Rating, &movie.AverageRating, &movie.RatingCount, &movie.Duration,
+		&movie.CreatedAt, &movie.UpdatedAt, &deletedAt, &movie.Version,
+		&movie.Status, &publishAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if deletedAt.Valid {
+		movie.DeletedAt = &deletedAt.Time
+	}
+
+	if publishAt.Valid {
+		movie.PublishAt = &publishAt.Time
+	}
+
+	return movie, nil
+}
+
+// scannerFunc adapts a function to the rowScanner interface
+type scannerFunc func(dest ...interface{}) error
+
+func (f scannerFunc) Scan(dest ...interface{}) error {
+	return f(dest...)
+}
+
+// qualifiedMovieColumns returns movieColumns prefixed with the movies table
+// name for use in joins
+func qualifiedMovieColumns() string {
+	columns := strings.Split(movieColumns, ", ")
+	for i, column := range columns {
+		columns[i] = "movies." + column
+	}
+	return strings.Join(columns, ", ")
+}
+
+// loadMovieRelations attaches genres, credits, external IDs and images to
+// the given movies
+func loadMovieRelations(ctx context.Context, q queryer, movies []*models.Movie) error {
+	if err := loadMovieGenres(ctx, q, movies); err != nil {
+		return err
+	}
+
+	if err := loadMovieCredits(ctx, q, movies); err != nil {
+		return err
+	}
+
+	if err := loadMovieExternalIDs(ctx, q, movies); err != nil {
+		return err
+	}
+
+	return loadMovieImages(ctx, q, movies)
+}
 
-	err := r.db.QueryRowContext(
+func (r *MovieRepository) Create(ctx context.Context, input *models.CreateMovieInput) (*models.Movie, error) {
+	var movie *models.Movie
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		movie, err = createMovie(ctx, tx, input, models.MovieStatusDraft)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.invalidate(ctx, movie.ID)
+	return movie, nil
+}
+
+// createMovie inserts a movie in the given workflow status with its genres
+// and credits inside a transaction and records its first revision
+func createMovie(ctx context.Context, tx *sql.Tx, input *models.CreateMovieInput, status string) (*models.Movie, error) {
+	query := `
+		INSERT INTO movies (title, description, release_date, rating, duration, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING ` + movieColumns
+
+	movie, err := scanMovie(tx.QueryRowContext(
 		ctx, query,
 		input.Title, input.Description, input.ReleaseDate, input.Rating,
-		input.Duration, input.Genre, input.Director, now,
-	).Scan(
-		&movie.ID, &movie.Title, &movie.Description, &movie.ReleaseDate,
-		&movie.Rating, &movie.Duration, &movie.Genre, &movie.Director,
-		&movie.CreatedAt, &movie.UpdatedAt,
-	)
+		input.Duration, status, time.Now(),
+	))
 	if err != nil {
 		return nil, err
 	}
 
+	if err := setMovieGenres(ctx, tx, movie.ID, input.Genres); err != nil {
+		return nil, err
+	}
+
+	if err := setMovieCredits(ctx, tx, movie.ID, input.Credits); err != nil {
+		return nil, err
+	}
+
+	if err := loadMovieRelations(ctx, tx, []*models.Movie{movie}); err != nil {
+		return nil, err
+	}
+
+	if err := recordRevision(ctx, tx, models.RevisionActionCreate, nil, movie); err != nil {
+		return nil, err
+	}
+
 	return movie, nil
 }
 
 func (r *MovieRepository) GetByID(ctx context.Context, id int64) (*models.Movie, error) {
-	query := `
-		SELECT id, title, description, release_date, rating, duration, genre, director, created_at, updated_at
-		FROM movies
-		WHERE id = $1
-	`
+	if r.cache != nil {
+		return r.cache.getByID(ctx, id, r.getByID)
+	}
+	return r.getByID(ctx, id)
+}
 
-	movie := &models.Movie{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&movie.ID, &movie.Title, &movie.Description, &movie.ReleaseDate,
-		&movie.Rating, &movie.Duration, &movie.Genre, &movie.Director,
-		&movie.CreatedAt, &movie.UpdatedAt,
-	)
+func (r *MovieRepository) getByID(ctx context.Context, id int64) (*models.Movie, error) {
+	query := `SELECT ` + movieColumns + ` FROM movies WHERE id = $1 AND deleted_at IS NULL`
+
+	movie, err := scanMovie(r.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrMovieNotFound
@@ -71,16 +161,80 @@ func (r *MovieRepository) GetByID(ctx context.Context, id int64) (*models.Movie,
 		return nil, err
 	}
 
+	if err := loadMovieRelations(ctx, r.db, []*models.Movie{movie}); err != nil {
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+// Update applies a partial update to a movie. When ifMatch is non-empty the
+// update only happens if the movie's current version is one of the listed
+// versions, otherwise ErrMovieVersionMismatch is returned.
+func (r *MovieRepository) Update(ctx context.Context, id int64, input *models.UpdateMovieInput, ifMatch []int) (*models.Movie, error) {
+	var movie *models.Movie
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		movie, err = updateMovie(ctx, tx, id, input, ifMatch, models.RevisionActionUpdate)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.invalidate(ctx, id)
 	return movie, nil
 }
 
-func (r *MovieRepository) Update(ctx context.Context, id int64, input *models.UpdateMovieInput) (*models.Movie, error) {
+// getMovieForUpdate loads a live movie with its relations and locks its row
+// until the end of the transaction
+func getMovieForUpdate(ctx context.Context, q queryer, id int64) (*models.Movie, error) {
+	query := `SELECT ` + movieColumns + ` FROM movies WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	movie, err := scanMovie(q.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMovieNotFound
+		}
+		return nil, err
+	}
+
+	if err := loadMovieRelations(ctx, q, []*models.Movie{movie}); err != nil {
+		return nil, err
+	}
+
+	return movie, nil
+}
+
+// versionMatches reports whether the movie's version is one of the accepted
+// versions. An empty list accepts any version.
+func versionMatches(movie *models.Movie, ifMatch []int) bool {
+	if len(ifMatch) == 0 {
+		return true
+	}
+
+	for _, version := range ifMatch {
+		if movie.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateMovie applies a partial update inside a transaction and records a
+// revision with the given action when anything changed
+func updateMovie(ctx context.Context, tx *sql.Tx, id int64, input *models.UpdateMovieInput, ifMatch []int, action string) (*models.Movie, error) {
 	// Get current movie data
-	movie, err := r.GetByID(ctx, id)
+	movie, err := getMovieForUpdate(ctx, tx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if !versionMatches(movie, ifMatch) {
+		return nil, ErrMovieVersionMismatch
+	}
+
 	// Build update query dynamically
 	updates := []string{}
 	args := []interface{}{}
@@ -116,19 +270,13 @@ func (r *MovieRepository) Update(ctx context.Context, id int64, input *models.Up
 		argPosition++
 	}
 
-	if input.Genre != nil {
-		updates = append(updates, fmt.Sprintf("genre = $%d", argPosition))
-		args = append(args, *input.Genre)
-		argPosition++
-	}
-
-	if input.Director != nil {
-		updates = append(updates, fmt.Sprintf("director = $%d", argPosition))
-		args = append(args, *input.Director)
-		argPosition++
+	// If no updates, return the movie
+	if len(updates) == 0 && input.Genres == nil && input.Credits == nil {
+		return movie, nil
 	}
 
-	// Add updated_at
+	// Add updated_at and bump the version
+	updates = append(updates, "version = version + 1")
 	updates = append(updates, fmt.Sprintf("updated_at = $%d", argPosition))
 	args = append(args, time.Now())
 	argPosition++
@@ -136,114 +284,183 @@ func (r *MovieRepository) Update(ctx context.Context, id int64, input *models.Up
 	// Add ID to args
 	args = append(args, id)
 
-	// If no updates, return the movie
-	if len(updates) == 1 { // Only updated_at
-		return movie, nil
-	}
-
 	// Build and execute query
 	query := fmt.Sprintf(`
 		UPDATE movies
 		SET %s
 		WHERE id = $%d
-		RETURNING id, title, description, release_date, rating, duration, genre, director, created_at, updated_at
-	`, strings.Join(updates, ", "), argPosition)
-
-	updatedMovie := &models.Movie{}
-	err = r.db.QueryRowContext(ctx, query, args...).Scan(
-		&updatedMovie.ID, &updatedMovie.Title, &updatedMovie.Description, &updatedMovie.ReleaseDate,
-		&updatedMovie.Rating, &updatedMovie.Duration, &updatedMovie.Genre, &updatedMovie.Director,
-		&updatedMovie.CreatedAt, &updatedMovie.UpdatedAt,
-	)
+		RETURNING %s
+	`, strings.Join(updates, ", "), argPosition, movieColumns)
+
+	updatedMovie, err := scanMovie(tx.QueryRowContext(ctx, query, args...))
 	if err != nil {
 		return nil, err
 	}
 
+	if input.Genres != nil {
+		if err := setMovieGenres(ctx, tx, id, *input.Genres); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.Credits != nil {
+		if err := setMovieCredits(ctx, tx, id, *input.Credits); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := loadMovieRelations(ctx, tx, []*models.Movie{updatedMovie}); err != nil {
+		return nil, err
+	}
+
+	if err := recordRevision(ctx, tx, action, movie, updatedMovie); err != nil {
+		return nil, err
+	}
+
 	return updatedMovie, nil
 }
 
-func (r *MovieRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM movies WHERE id = $1`
+// Delete moves a movie to the trash. It stays hidden from reads until it is
+// restored or purged. ifMatch works as in Update.
+func (r *MovieRepository) Delete(ctx context.Context, id int64, ifMatch []int) error {
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		movie, err := getMovieForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
 
-	result, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return err
-	}
+		if !versionMatches(movie, ifMatch) {
+			return ErrMovieVersionMismatch
+		}
 
-	rowsAffected, err := result.RowsAffected()
+		if _, err := tx.ExecContext(ctx, `UPDATE movies SET deleted_at = $1, version = version + 1 WHERE id = $2`, time.Now(), id); err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, tx, models.RevisionActionDelete, movie, movie)
+	})
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return ErrMovieNotFound
+	r.cache.invalidate(ctx, id)
+	return nil
+}
+
+// Restore takes a movie out of the trash
+func (r *MovieRepository) Restore(ctx context.Context, id int64) (*models.Movie, error) {
+	query := `
+		UPDATE movies
+		SET deleted_at = NULL, updated_at = $1, version = version + 1
+		WHERE id = $2 AND deleted_at IS NOT NULL
+		RETURNING ` + movieColumns
+
+	var movie *models.Movie
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		movie, err = scanMovie(tx.QueryRowContext(ctx, query, time.Now(), id))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrMovieNotFound
+			}
+			return err
+		}
+
+		if err := loadMovieRelations(ctx, tx, []*models.Movie{movie}); err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, tx, models.RevisionActionRestore, movie, movie)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	r.cache.invalidate(ctx, id)
+	return movie, nil
 }
 
-func (r *MovieRepository) List(ctx context.Context, query *models.MovieQuery) ([]*models.Movie, int, error) {
-	// Build the query
-	countQuery := `SELECT COUNT(*) FROM movies WHERE 1=1`
-	selectQuery := `
-		SELECT id, title, description, release_date, rating, duration, genre, director, created_at, updated_at
+// ListDeleted returns a page of trashed movies, most recently deleted first
+func (r *MovieRepository) ListDeleted(ctx context.Context, page, pageSize int) ([]*models.Movie, int, error) {
+	var totalCount int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM movies WHERE deleted_at IS NOT NULL`).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+movieColumns+`
 		FROM movies
-		WHERE 1=1
-	`
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+		LIMIT $1 OFFSET $2
+	`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
 
-	// Add filters
-	args := []interface{}{}
-	argPosition := 1
-	whereClause := ""
+	movies := []*models.Movie{}
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		movies = append(movies, movie)
+	}
 
-	if query.Title != "" {
-		whereClause += fmt.Sprintf(" AND title ILIKE $%d", argPosition)
-		args = append(args, "%"+query.Title+"%")
-		argPosition++
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
 	}
 
-	if query.Genre != "" {
-		whereClause += fmt.Sprintf(" AND genre ILIKE $%d", argPosition)
-		args = append(args, "%"+query.Genre+"%")
-		argPosition++
+	if err := loadMovieRelations(ctx, r.db, movies); err != nil {
+		return nil, 0, err
 	}
 
-	if query.Director != "" {
-		whereClause += fmt.Sprintf(" AND director ILIKE $%d", argPosition)
-		args = append(args, "%"+query.Director+"%")
-		argPosition++
+	return movies, totalCount, nil
+}
+
+// PurgeDeleted permanently removes movies that were trashed before the
+// cutoff, returning how many were removed. Their revisions are kept.
+func (r *MovieRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM movies WHERE deleted_at IS NOT NULL AND deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
 	}
 
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// The purged IDs are not known here, so every cached read is dropped
+	if purged > 0 {
+		r.cache.invalidateAll(ctx)
+	}
+	return purged, nil
+}
+
+func (r *MovieRepository) List(ctx context.Context, query *models.MovieQuery) ([]*models.Movie, int, error) {
+	if r.cache != nil {
+		return r.cache.list(ctx, query, r.list)
+	}
+	return r.list(ctx, query)
+}
+
+func (r *MovieRepository) list(ctx context.Context, query *models.MovieQuery) ([]*models.Movie, int, error) {
+	// Build the query
+	countQuery := `SELECT COUNT(*) FROM movies WHERE deleted_at IS NULL`
+	selectQuery := `SELECT ` + movieColumns + ` FROM movies WHERE deleted_at IS NULL`
+
+	// Add filters
+	whereClause, args := buildMovieFilters(query, []interface{}{})
+	argPosition := len(args) + 1
+
 	countQuery += whereClause
 	selectQuery += whereClause
 
 	// Add sorting
-	if query.SortBy != "" {
-		orderDir := "ASC"
-		if strings.ToUpper(query.Order) == "DESC" {
-			orderDir = "DESC"
-		}
-
-		// Validate sort column to prevent SQL injection
-		allowedColumns := map[string]bool{
-			"id":           true,
-			"title":        true,
-			"release_date": true,
-			"rating":       true,
-			"duration":     true,
-			"genre":        true,
-			"director":     true,
-			"created_at":   true,
-		}
-
-		if allowedColumns[query.SortBy] {
-			selectQuery += fmt.Sprintf(" ORDER BY %s %s", query.SortBy, orderDir)
-		} else {
-			selectQuery += " ORDER BY created_at DESC"
-		}
-	} else {
-		selectQuery += " ORDER BY created_at DESC"
-	}
+	selectQuery += movieOrderBy(query.SortBy, query.Order, nil)
 
 	// Add pagination
 	if query.Page <= 0 {
@@ -274,12 +491,7 @@ func (r *MovieRepository) List(ctx context.Context, query *models.MovieQuery) ([
 
 	movies := []*models.Movie{}
 	for rows.Next() {
-		movie := &models.Movie{}
-		err := rows.Scan(
-			&movie.ID, &movie.Title, &movie.Description, &movie.ReleaseDate,
-			&movie.Rating, &movie.Duration, &movie.Genre, &movie.Director,
-			&movie.CreatedAt, &movie.UpdatedAt,
-		)
+		movie, err := scanMovie(rows)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -290,5 +502,94 @@ func (r *MovieRepository) List(ctx context.Context, query *models.MovieQuery) ([
 		return nil, 0, err
 	}
 
+	if err := loadMovieRelations(ctx, r.db, movies); err != nil {
+		return nil, 0, err
+	}
+
 	return movies, totalCount, nil
 }
+
+// publishedCondition returns the SQL condition for the movie under alias
+// being published and past its publish time. It matches Movie.IsPublic apart
+// from the trash check, which callers already make.
+func publishedCondition(alias string) string {
+	return fmt.Sprintf("%[1]s.status = 'published' AND (%[1]s.publish_at IS NULL OR %[1]s.publish_at <= NOW())", alias)
+}
+
+// buildMovieFilters turns the filter fields of a MovieQuery into a WHERE
+// fragment against the movies table, appending its parameters to args
+func buildMovieFilters(query *models.MovieQuery, args []interface{}) (string, []interface{}) {
+	whereClause := ""
+
+	if !query.IncludeUnpublished {
+		whereClause += " AND " + publishedCondition("movies")
+	}
+
+	if query.Status != "" {
+		args = append(args, query.Status)
+		whereClause += fmt.Sprintf(" AND movies.status = $%d", len(args))
+	}
+
+	if query.Title != "" {
+		args = append(args, "%"+query.Title+"%")
+		whereClause += fmt.Sprintf(" AND movies.title ILIKE $%d", len(args))
+	}
+
+	if query.Genre != "" {
+		args = append(args, models.Slugify(query.Genre))
+		whereClause += fmt.Sprintf(`
+			AND EXISTS (
+				SELECT 1 FROM movie_genres mg
+				JOIN genres g ON g.id = mg.genre_id
+				WHERE mg.movie_id = movies.id AND g.slug = $%d
+			)`, len(args))
+	}
+
+	if query.Director != "" {
+		args = append(args, "%"+query.Director+"%")
+		whereClause += fmt.Sprintf(`
+			AND EXISTS (
+				SELECT 1 FROM movie_credits mc
+				JOIN people p ON p.id = mc.person_id
+				WHERE mc.movie_id = movies.id AND mc.role = 'director' AND p.name ILIKE $%d
+			)`, len(args))
+	}
+
+	return whereClause, args
+}
+
+// movieSortColumns maps the sort keys of a movie list to their columns
+var movieSortColumns = map[string]string{
+	"id":             "movies.id",
+	"title":          "movies.title",
+	"release_date":   "movies.release_date",
+	"rating":         "movies.rating",
+	"average_rating": "movies.average_rating",
+	"rating_count":   "movies.rating_count",
+	"duration":       "movies.duration",
+	"created_at":     "movies.created_at",
+}
+
+// movieOrderBy returns a validated ORDER BY clause for the movies table.
+// extraColumns maps additional sort keys to the SQL expression they sort by.
+func movieOrderBy(sortBy, order string, extraColumns map[string]string) string {
+	orderDir := "ASC"
+	if strings.ToUpper(order) == "DESC" {
+		orderDir = "DESC"
+	}
+
+	// Validate sort column to prevent SQL injection
+	allowedColumns := make(map[string]string, len(movieSortColumns)+len(extraColumns))
+	for key, column := range movieSortColumns {
+		allowedColumns[key] = column
+	}
+	for key, column := range extraColumns {
+		allowedColumns[key] = column
+	}
+
+	if column, ok := allowedColumns[sortBy]; ok {
+		return fmt.Sprintf(" ORDER BY %s %s, movies.id", column, orderDir)
+	}
+
+	return " ORDER BY movies.created_at DESC, movies.id"
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"time"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+const revisionColumns = `id, movie_id, revision, action, editor_id, changes, created_at`
+
+func scanRevision(row rowScanner, extra ...interface{}) (*models.MovieRevision, error) {
+	revision := &models.MovieRevision{}
+	var editorID sql.NullInt64
+	var changes []byte
+
+	dest := append([]interface{}{
+		&revision.ID, &revision.MovieID, &revision.Revision, &revision.Action,
+		&editorID, &changes, &revision.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if editorID.Valid {
+		revision.EditorID = &editorID.Int64
+	}
+
+	if err := json.Unmarshal(changes, &revision.Changes); err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// ListRevisions returns a page of a movie's revisions, newest first. Revisions
+// of trashed and purged movies stay readable so a bad delete can be
+// investigated.
+func (r *MovieRepository) ListRevisions(ctx context.Context, movieID int64, page, pageSize int) ([]*models.MovieRevision, int, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM movies WHERE id = $1)
+			OR EXISTS(SELECT 1 FROM movie_revisions WHERE movie_id = $1)
+	`, movieID).Scan(&exists)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !exists {
+		return nil, 0, ErrMovieNotFound
+	}
+
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM movie_revisions WHERE movie_id = $1`, movieID).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+revisionColumns+`
+		FROM movie_revisions
+		WHERE movie_id = $1
+		ORDER BY revision DESC
+		LIMIT $2 OFFSET $3
+	`, movieID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.MovieRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return revisions, totalCount, nil
+}
+
+// GetRevision returns a single revision of a movie including its snapshot
+func (r *MovieRepository) GetRevision(ctx context.Context, movieID int64, revision int) (*models.MovieRevision, error) {
+	return getRevision(ctx, r.db, movieID, revision)
+}
+
+// Revert restores a live movie's fields to the snapshot taken at the given
+// revision. The revert is itself recorded as a new revision.
+func (r *MovieRepository) Revert(ctx context.Context, movieID int64, revision int) (*models.Movie, error) {
+	var movie *models.Movie
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		target, err := getRevision(ctx, tx, movieID, revision)
+		if err != nil {
+			return err
+		}
+
+		movie, err = updateMovie(ctx, tx, movieID, target.Snapshot.UpdateInput(), nil, models.RevisionActionRevert)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.invalidate(ctx, movieID)
+	return movie, nil
+}
+
+func getRevision(ctx context.Context, q queryer, movieID int64, revision int) (*models.MovieRevision, error) {
+	var snapshot []byte
+	row := q.QueryRowContext(ctx, `
+		SELECT `+revisionColumns+`, snapshot
+		FROM movie_revisions
+		WHERE movie_id = $1 AND revision = $2
+	`, movieID, revision)
+
+	rev, err := scanRevision(row, &snapshot)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+
+	rev.Snapshot = &models.MovieSnapshot{}
+	if err := json.Unmarshal(snapshot, rev.Snapshot); err != nil {
+		return nil, err
+	}
+
+	return rev, nil
+}
+
+// recordRevision appends a revision for a movie write. before is nil when the
+// movie was just created. Updates that changed nothing are not recorded. The
+// editor is taken from the authenticated user on the context, if any.
+func recordRevision(ctx context.Context, q queryer, action string, before, after *models.Movie) error {
+	snapshot := models.NewMovieSnapshot(after)
+
+	var previous *models.MovieSnapshot
+	if before != nil {
+		previous = models.NewMovieSnapshot(before)
+	}
+
+	changes, err := models.DiffMovieSnapshots(previous, snapshot)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 && (action == models.RevisionActionUpdate || action == models.RevisionActionRevert) {
+		return nil
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	var editorID sql.NullInt64
+	if userID, ok := models.EditorFromContext(ctx); ok {
+		editorID = sql.NullInt64{Int64: userID, Valid: true}
+	}
+
+	// Callers hold the movie's row lock, so revision numbers cannot race
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO movie_revisions (movie_id, revision, action, editor_id, snapshot, changes, created_at)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6
+		FROM movie_revisions
+		WHERE movie_id = $1
+	`, after.ID, action, editorID, snapshotJSON, changesJSON, time.Now())
+
+	return err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"time"
+)
+
+var (
+	ErrInvalidTransition = errors.New("movie status does not allow this transition")
+)
+
+// Transition moves a movie along the editorial workflow. The movie must be
+// in the transition's from status, otherwise ErrInvalidTransition is
+// returned. Publishing sets publish_at to publishAt, or to now when it is
+// nil; sending a movie back to draft clears it.
+func (r *MovieRepository) Transition(ctx context.Context, id int64, transition models.MovieTransition, publishAt *time.Time, ifMatch []int) (*models.Movie, error) {
+	var movie *models.Movie
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		before, err := getMovieForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !versionMatches(before, ifMatch) {
+			return ErrMovieVersionMismatch
+		}
+
+		if before.Status != transition.From {
+			return ErrInvalidTransition
+		}
+
+		now := time.Now()
+		var publishAtValue interface{}
+		switch transition.To {
+		case models.MovieStatusPublished:
+			if publishAt != nil {
+				publishAtValue = *publishAt
+			} else {
+				publishAtValue = now
+			}
+		case models.MovieStatusDraft:
+			publishAtValue = nil
+		default:
+			publishAtValue = before.PublishAt
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE movies
+			SET status = $1, publish_at = $2, updated_at = $3, version = version + 1
+			WHERE id = $4
+		`, transition.To, publishAtValue, now, id)
+		if err != nil {
+			return err
+		}
+
+		if movie, err = getMovieForUpdate(ctx, tx, id); err != nil {
+			return err
+		}
+
+		return recordRevision(ctx, tx, models.RevisionActionTransition, before, movie)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.invalidate(ctx, id)
+	return movie, nil
+}
@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPersonNotFound = errors.New("person not found")
+)
+
+const personColumns = `id, name, biography, birth_date, created_at, updated_at`
+
+// PersonRepository handles database operations related to people credited on movies
+type PersonRepository struct {
+	db         *database.PostgresDB
+	movieCache *movieCache // nil unless the movie repository caches reads
+}
+
+// NewPersonRepository creates a new PersonRepository
+func NewPersonRepository(db *database.PostgresDB) *PersonRepository {
+	return &PersonRepository{
+		db: db,
+	}
+}
+
+// UseMovieCache makes renames and deletes invalidate the cached reads of
+// movies, which embed their credits
+func (r *PersonRepository) UseMovieCache(movies *MovieRepository) {
+	r.movieCache = movies.cache
+}
+
+func scanPerson(row rowScanner) (*models.Person, error) {
+	person := &models.Person{}
+	var birthDate sql.NullTime
+	err := row.Scan(
+		&person.ID, &person.Name, &person.Biography, &birthDate, &person.CreatedAt, &person.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if birthDate.Valid {
+		person.BirthDate = &birthDate.Time
+	}
+
+	return person, nil
+}
+
+func (r *PersonRepository) Create(ctx context.Context, input *models.CreatePersonInput) (*models.Person, error) {
+	query := `
+		INSERT INTO people (name, biography, birth_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		RETURNING ` + personColumns
+
+	return scanPerson(r.db.QueryRowContext(
+		ctx, query, strings.TrimSpace(input.Name), input.Biography, input.BirthDate, time.Now(),
+	))
+}
+
+func (r *PersonRepository) GetByID(ctx context.Context, id int64) (*models.Person, error) {
+	query := `SELECT ` + personColumns + ` FROM people WHERE id = $1`
+
+	person, err := scanPerson(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPersonNotFound
+		}
+		return nil, err
+	}
+
+	return person, nil
+}
+
+func (r *PersonRepository) Update(ctx context.Context, id int64, input *models.UpdatePersonInput) (*models.Person, error) {
+	updates := []string{}
+	args := []interface{}{}
+	argPosition := 1
+
+	if input.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argPosition))
+		args = append(args, strings.TrimSpace(*input.Name))
+		argPosition++
+	}
+
+	if input.Biography != nil {
+		updates = append(updates, fmt.Sprintf("biography = $%d", argPosition))
+		args = append(args, *input.Biography)
+		argPosition++
+	}
+
+	if input.BirthDate != nil {
+		updates = append(updates, fmt.Sprintf("birth_date = $%d", argPosition))
+		args = append(args, *input.BirthDate)
+		argPosition++
+	}
+
+	if len(updates) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", argPosition))
+	args = append(args, time.Now())
+	argPosition++
+
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE people
+		SET %s
+		WHERE id = $%d
+		RETURNING %s
+	`, strings.Join(updates, ", "), argPosition, personColumns)
+
+	var person *models.Person
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		if person, err = scanPerson(tx.QueryRowContext(ctx, query, args...)); err != nil {
+			return err
+		}
+
+		// Credits embed the person's name, so the movies' versions move with it
+		if input.Name == nil {
+			return nil
+		}
+		return bumpPersonMovieVersions(ctx, tx, id)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPersonNotFound
+		}
+		return nil, err
+	}
+
+	if input.Name != nil {
+		r.movieCache.invalidateAll(ctx)
+	}
+	return person, nil
+}
+
+func (r *PersonRepository) Delete(ctx context.Context, id int64) error {
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		// The versions are bumped first, while the credits still exist
+		if err := bumpPersonMovieVersions(ctx, tx, id); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, `DELETE FROM people WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return ErrPersonNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.movieCache.invalidateAll(ctx)
+	return nil
+}
+
+// bumpPersonMovieVersions bumps the version of every movie crediting the person
+func bumpPersonMovieVersions(ctx context.Context, q queryer, personID int64) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE movies SET version = version + 1
+		WHERE id IN (SELECT movie_id FROM movie_credits WHERE person_id = $1)
+	`, personID)
+	return err
+}
+
+func (r *PersonRepository) List(ctx context.Context, query *models.PersonQuery) ([]*models.Person, int, error) {
+	countQuery := `SELECT COUNT(*) FROM people WHERE 1=1`
+	selectQuery := `SELECT ` + personColumns + ` FROM people WHERE 1=1`
+
+	args := []interface{}{}
+	argPosition := 1
+	whereClause := ""
+
+	if query.Name != "" {
+		whereClause += fmt.Sprintf(" AND name ILIKE $%d", argPosition)
+		args = append(args, "%"+query.Name+"%")
+		argPosition++
+	}
+
+	countQuery += whereClause
+	selectQuery += whereClause + " ORDER BY name, id"
+
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	if query.PageSize <= 0 {
+		query.PageSize = 10
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	selectQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argPosition, argPosition+1)
+	args = append(args, query.PageSize, offset)
+
+	var totalCount int
+	err := r.db.QueryRowContext(ctx, countQuery, args[:argPosition-1]...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	people := []*models.Person{}
+	for rows.Next() {
+		person, err := scanPerson(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		people = append(people, person)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return people, totalCount, nil
+}
+
+// Filmography returns every movie the person is credited on, newest first
+func (r *PersonRepository) Filmography(ctx context.Context, id int64) ([]*models.FilmographyEntry, error) {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT m.id, m.title, m.release_date, mc.role, mc.character_name, mc.billing_order
+		FROM movie_credits mc
+		JOIN movies m ON m.id = mc.movie_id
+		WHERE mc.person_id = $1 AND m.deleted_at IS NULL AND `+publishedCondition("m")+`
+		ORDER BY m.release_date DESC, m.id, mc.billing_order
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*models.FilmographyEntry{}
+	for rows.Next() {
+		entry := &models.FilmographyEntry{}
+		err := rows.Scan(
+			&entry.MovieID, &entry.Title, &entry.ReleaseDate, &entry.Role, &entry.Character, &entry.BillingOrder,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// setMovieCredits replaces the credits attached to a movie
+func setMovieCredits(ctx context.Context, q queryer, movieID int64, credits []models.CreditInput) error {
+	if _, err := q.ExecContext(ctx, `DELETE FROM movie_credits WHERE movie_id = $1`, movieID); err != nil {
+		return err
+	}
+
+	for _, credit := range credits {
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO movie_credits (movie_id, person_id, role, character_name, billing_order)
+			VALUES ($1, $2, $3, $4, $5)
+		`, movieID, credit.PersonID, credit.Role, credit.Character, credit.BillingOrder)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return ErrPersonNotFound
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadMovieCredits attaches credits to the given movies with a single query
+func loadMovieCredits(ctx context.Context, q queryer, movies []*models.Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Movie, len(movies))
+	ids := make([]int64, 0, len(movies))
+	for _, movie := range movies {
+		movie.Credits = []*models.Credit{}
+		byID[movie.ID] = movie
+		ids = append(ids, movie.ID)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT mc.movie_id, p.id, p.name, mc.role, mc.character_name, mc.billing_order
+		FROM movie_credits mc
+		JOIN people p ON p.id = mc.person_id
+		WHERE mc.movie_id = ANY($1)
+		ORDER BY mc.billing_order, mc.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID int64
+		credit := &models.Credit{}
+		err := rows.Scan(&movieID, &credit.PersonID, &credit.Name, &credit.Role, &credit.Character, &credit.BillingOrder)
+		if err != nil {
+			return err
+		}
+		if movie, ok := byID[movieID]; ok {
+			movie.Credits = append(movie.Credits, credit)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"time"
+)
+
+var (
+	ErrReviewNotFound = errors.New("review not found")
+	ErrReviewExists   = errors.New("user has already reviewed this movie")
+	ErrReviewNotOwned = errors.New("review belongs to another user")
+)
+
+const reviewSelect = `
+	SELECT r.id, r.movie_id, r.user_id, TRIM(u.first_name || ' ' || u.last_name), r.rating, r.body, r.created_at, r.updated_at
+	FROM reviews r
+	JOIN users u ON u.id = r.user_id
+`
+
+// ReviewRepository handles database operations related to user reviews.
+// Every write recalculates the movie's average rating and rating count in the
+// same transaction.
+type ReviewRepository struct {
+	db         *database.PostgresDB
+	movieCache *movieCache // nil unless the movie repository caches reads
+}
+
+// NewReviewRepository creates a new ReviewRepository
+func NewReviewRepository(db *database.PostgresDB) *ReviewRepository {
+	return &ReviewRepository{
+		db: db,
+	}
+}
+
+// UseMovieCache makes review writes invalidate the cached reads of movies,
+// whose average rating they change
+func (r *ReviewRepository) UseMovieCache(movies *MovieRepository) {
+	r.movieCache = movies.cache
+}
+
+func scanReview(row rowScanner) (*models.Review, error) {
+	review := &models.Review{}
+	err := row.Scan(
+		&review.ID, &review.MovieID, &review.UserID, &review.Author,
+		&review.Rating, &review.Body, &review.CreatedAt, &review.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// Create adds a user's review of a movie. A user can review a movie only once.
+func (r *ReviewRepository) Create(ctx context.Context, movieID, userID int64, input *models.CreateReviewInput) (*models.Review, error) {
+	var review *models.Review
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockMovie(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		var id int64
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO reviews (movie_id, user_id, rating, body, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5)
+			RETURNING id
+		`, movieID, userID, input.Rating, input.Body, time.Now()).Scan(&id)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return ErrReviewExists
+			}
+			return err
+		}
+
+		if err := refreshMovieRating(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		review, err = getReview(ctx, tx, movieID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.movieCache.invalidate(ctx, movieID)
+	return review, nil
+}
+
+func (r *ReviewRepository) GetByID(ctx context.Context, movieID, id int64) (*models.Review, error) {
+	return getReview(ctx, r.db, movieID, id)
+}
+
+// Update changes a review owned by userID
+func (r *ReviewRepository) Update(ctx context.Context, movieID, id, userID int64, input *models.UpdateReviewInput) (*models.Review, error) {
+	var review *models.Review
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockMovie(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		existing, err := getReview(ctx, tx, movieID, id)
+		if err != nil {
+			return err
+		}
+		if existing.UserID != userID {
+			return ErrReviewNotOwned
+		}
+
+		rating, body := existing.Rating, existing.Body
+		if input.Rating != nil {
+			rating = *input.Rating
+		}
+		if input.Body != nil {
+			body = *input.Body
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE reviews SET rating = $1, body = $2, updated_at = $3 WHERE id = $4
+		`, rating, body, time.Now(), id)
+		if err != nil {
+			return err
+		}
+
+		if err := refreshMovieRating(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		review, err = getReview(ctx, tx, movieID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.movieCache.invalidate(ctx, movieID)
+	return review, nil
+}
+
+// Delete removes a review owned by userID
+func (r *ReviewRepository) Delete(ctx context.Context, movieID, id, userID int64) error {
+	err := withTx(ctx, r.db, func(tx *sql.Tx) error {
+		if err := lockMovie(ctx, tx, movieID); err != nil {
+			return err
+		}
+
+		existing, err := getReview(ctx, tx, movieID, id)
+		if err != nil {
+			return err
+		}
+		if existing.UserID != userID {
+			return ErrReviewNotOwned
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM reviews WHERE id = $1`, id); err != nil {
+			return err
+		}
+
+		return refreshMovieRating(ctx, tx, movieID)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.movieCache.invalidate(ctx, movieID)
+	return nil
+}
+
+// ListByMovie returns a page of a movie's reviews, newest first
+func (r *ReviewRepository) ListByMovie(ctx context.Context, movieID int64, page, pageSize int) ([]*models.Review, int, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM movies WHERE id = $1 AND deleted_at IS NULL AND `+publishedCondition("movies")+`)`, movieID).Scan(&exists)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !exists {
+		return nil, 0, ErrMovieNotFound
+	}
+
+	var totalCount int
+	err = r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reviews WHERE movie_id = $1`, movieID).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, reviewSelect+`
+		WHERE r.movie_id = $1
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT $2 OFFSET $3
+	`, movieID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reviews := []*models.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, totalCount, nil
+}
+
+func getReview(ctx context.Context, q queryer, movieID, id int64) (*models.Review, error) {
+	review, err := scanReview(q.QueryRowContext(ctx, reviewSelect+` WHERE r.id = $1 AND r.movie_id = $2`, id, movieID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// lockMovie takes a row lock on the movie so concurrent review writes
+// serialize their rating recalculation. Only published movies can be
+// reviewed, the same ones users can read.
+func lockMovie(ctx context.Context, q queryer, movieID int64) error {
+	var id int64
+	err := q.QueryRowContext(ctx, `
+		SELECT id FROM movies WHERE id = $1 AND deleted_at IS NULL AND `+publishedCondition("movies")+`
+		FOR UPDATE
+	`, movieID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMovieNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// refreshMovieRating recalculates the stored rating aggregates for a movie.
+// The version is left alone: it guards the editorial fields, and reviews
+// must not make an editor's If-Match fail. Reads tag the aggregates
+// separately.
+func refreshMovieRating(ctx context.Context, q queryer, movieID int64) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE movies m
+		SET average_rating = agg.average, rating_count = agg.count
+		FROM (
+			SELECT COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count
+			FROM reviews
+			WHERE movie_id = $1
+		) agg
+		WHERE m.id = $1
+	`, movieID)
+
+	return err
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"time"
+)
+
+var (
+	ErrSavedMovieNotFound = errors.New("movie is not on the list")
+	ErrSavedMovieExists   = errors.New("movie is already on the list")
+)
+
+// SavedMovieRepository handles a user's personal movie lists (watchlist and favorites)
+type SavedMovieRepository struct {
+	db *database.PostgresDB
+}
+
+// NewSavedMovieRepository creates a new SavedMovieRepository
+func NewSavedMovieRepository(db *database.PostgresDB) *SavedMovieRepository {
+	return &SavedMovieRepository{
+		db: db,
+	}
+}
+
+// Add puts a movie on one of the user's lists
+func (r *SavedMovieRepository) Add(ctx context.Context, userID int64, kind string, movieID int64) (*models.SavedMovie, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_movies (user_id, movie_id, kind, added_at)
+		SELECT $1, id, $3, $4 FROM movies WHERE id = $2 AND deleted_at IS NULL AND `+publishedCondition("movies")+`
+	`, userID, movieID, kind, time.Now())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrSavedMovieExists
+		}
+		return nil, err
+	}
+
+	if err := requireRowAffected(result, ErrMovieNotFound); err != nil {
+		return nil, err
+	}
+
+	return r.get(ctx, userID, kind, movieID)
+}
+
+// Remove takes a movie off one of the user's lists
+func (r *SavedMovieRepository) Remove(ctx context.Context, userID int64, kind string, movieID int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM user_movies WHERE user_id = $1 AND kind = $2 AND movie_id = $3
+	`, userID, kind, movieID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrSavedMovieNotFound
+	}
+
+	return nil
+}
+
+// SetWatched records when the user watched a movie on their list; a nil
+// watchedAt clears it
+func (r *SavedMovieRepository) SetWatched(ctx context.Context, userID int64, kind string, movieID int64, watchedAt *time.Time) (*models.SavedMovie, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_movies SET watched_at = $1 WHERE user_id = $2 AND kind = $3 AND movie_id = $4
+	`, watchedAt, userID, kind, movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if rowsAffected == 0 {
+		return nil, ErrSavedMovieNotFound
+	}
+
+	return r.get(ctx, userID, kind, movieID)
+}
+
+// List returns a page of the user's list, filtered like MovieRepository.List
+func (r *SavedMovieRepository) List(ctx context.Context, userID int64, kind string, query *models.SavedMovieQuery) ([]*models.SavedMovie, int, error) {
+	from := `
+		FROM user_movies um
+		JOIN movies ON movies.id = um.movie_id
+		WHERE um.user_id = $1 AND um.kind = $2 AND movies.deleted_at IS NULL
+	`
+
+	whereClause, args := buildMovieFilters(&query.MovieQuery, []interface{}{userID, kind})
+
+	if query.Watched != nil {
+		if *query.Watched {
+			whereClause += " AND um.watched_at IS NOT NULL"
+		} else {
+			whereClause += " AND um.watched_at IS NULL"
+		}
+	}
+
+	sortBy, order := query.SortBy, query.Order
+	if sortBy == "" {
+		sortBy, order = "added_at", "DESC"
+	}
+	orderBy := movieOrderBy(sortBy, order, map[string]string{
+		"added_at":   "um.added_at",
+		"watched_at": "um.watched_at",
+	})
+
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+
+	if query.PageSize <= 0 {
+		query.PageSize = 10
+	}
+
+	var totalCount int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) `+from+whereClause, args...).Scan(&totalCount)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	argPosition := len(args) + 1
+	args = append(args, query.PageSize, (query.Page-1)*query.PageSize)
+
+	selectQuery := `SELECT ` + qualifiedMovieColumns() + `, um.added_at, um.watched_at ` + from + whereClause + orderBy +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", argPosition, argPosition+1)
+
+	rows, err := r.db.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	items := []*models.SavedMovie{}
+	movies := []*models.Movie{}
+	for rows.Next() {
+		item, err := scanSavedMovie(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+		movies = append(movies, item.Movie)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := loadMovieRelations(ctx, r.db, movies); err != nil {
+		return nil, 0, err
+	}
+
+	return items, totalCount, nil
+}
+
+// Status reports which of the user's lists contain the movie
+func (r *SavedMovieRepository) Status(ctx context.Context, userID, movieID int64) (*models.MovieUserStatus, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT kind, watched_at FROM user_movies WHERE user_id = $1 AND movie_id = $2
+	`, userID, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	status := &models.MovieUserStatus{}
+	for rows.Next() {
+		var kind string
+		var watchedAt sql.NullTime
+		if err := rows.Scan(&kind, &watchedAt); err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case models.SavedMovieWatchlist:
+			status.InWatchlist = true
+		case models.SavedMovieFavorites:
+			status.InFavorites = true
+		}
+
+		if watchedAt.Valid && (status.WatchedAt == nil || watchedAt.Time.After(*status.WatchedAt)) {
+			t := watchedAt.Time
+			status.WatchedAt = &t
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+func (r *SavedMovieRepository) get(ctx context.Context, userID int64, kind string, movieID int64) (*models.SavedMovie, error) {
+	item, err := scanSavedMovie(r.db.QueryRowContext(ctx, `
+		SELECT `+qualifiedMovieColumns()+`, um.added_at, um.watched_at
+		FROM user_movies um
+		JOIN movies ON movies.id = um.movie_id
+		WHERE um.user_id = $1 AND um.kind = $2 AND um.movie_id = $3
+	`, userID, kind, movieID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSavedMovieNotFound
+		}
+		return nil, err
+	}
+
+	if err := loadMovieRelations(ctx, r.db, []*models.Movie{item.Movie}); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func scanSavedMovie(row rowScanner) (*models.SavedMovie, error) {
+	var addedAt time.Time
+	var watchedAt sql.NullTime
+
+	movie, err := scanMovie(scannerFunc(func(dest ...interface{}) error {
+		return row.Scan(append(dest, &addedAt, &watchedAt)...)
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	item := &models.SavedMovie{Movie: movie, AddedAt: addedAt}
+	if watchedAt.Valid {
+		item.WatchedAt = &watchedAt.Time
+	}
+
+	return item, nil
+}
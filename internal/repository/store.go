@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"time"
+)
+
+//go:generate go run github.com/golang/mock/mockgen -source=store.go -destination=mocks/store.go -package=mocks
+
+// UserStore keeps user accounts. UserRepository implements it on Postgres and
+// memory.UserStore in memory.
+type UserStore interface {
+	// Create adds a user, failing with ErrEmailExists if the email is taken
+	Create(ctx context.Context, input *models.CreateUserInput, passwordHash string) (*models.User, error)
+	GetByID(ctx context.Context, id int64) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// Authenticate returns the user with the given credentials, or
+	// ErrInvalidCredentials
+	Authenticate(ctx context.Context, email, password string) (*models.User, error)
+	UpdatePassword(ctx context.Context, id int64, passwordHash string) error
+	SetRole(ctx context.Context, id int64, role string) (*models.User, error)
+}
+
+// MovieStore keeps movies with their genres, credits, external IDs, images
+// and revision history. MovieRepository implements it on Postgres and
+// memory.MovieStore in memory. Methods taking ifMatch only write when the
+// movie's version is one of the listed ones, otherwise they fail with
+// ErrMovieVersionMismatch; an empty list accepts any version.
+type MovieStore interface {
+	Create(ctx context.Context, input *models.CreateMovieInput) (*models.Movie, error)
+	GetByID(ctx context.Context, id int64) (*models.Movie, error)
+	List(ctx context.Context, query *models.MovieQuery) ([]*models.Movie, int, error)
+	Update(ctx context.Context, id int64, input *models.UpdateMovieInput, ifMatch []int) (*models.Movie, error)
+	Delete(ctx context.Context, id int64, ifMatch []int) error
+
+	// Export calls fn for every live movie matching the query, ignoring
+	// pagination, and stops at the first error fn returns
+	Export(ctx context.Context, query *models.MovieQuery, fn func(*models.Movie) error) error
+
+	ListDeleted(ctx context.Context, page, pageSize int) ([]*models.Movie, int, error)
+	Restore(ctx context.Context, id int64) (*models.Movie, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+
+	GetByExternalID(ctx context.Context, source, externalID string) (*models.Movie, error)
+	// UpsertByExternalID reports whether the movie was created
+	UpsertByExternalID(ctx context.Context, source, externalID string, input *models.ReplaceMovieInput, ifMatch []int) (*models.Movie, bool, error)
+
+	// SetImage and RemoveImage return the image they replaced or removed so
+	// the caller can delete its blobs
+	SetImage(ctx context.Context, movieID int64, kind string, image *models.MovieImage) (*models.Movie, *models.MovieImage, error)
+	RemoveImage(ctx context.Context, movieID int64, kind string) (*models.Movie, *models.MovieImage, error)
+
+	ListRevisions(ctx context.Context, movieID int64, page, pageSize int) ([]*models.MovieRevision, int, error)
+	GetRevision(ctx context.Context, movieID int64, revision int) (*models.MovieRevision, error)
+	Revert(ctx context.Context, movieID int64, revision int) (*models.Movie, error)
+
+	FindDuplicates(ctx context.Context, minSimilarity float64, page, pageSize int) ([]*models.DuplicateCandidate, int, error)
+	Merge(ctx context.Context, survivorID, duplicateID int64) (*models.MovieMergeResult, error)
+
+	Transition(ctx context.Context, id int64, transition models.MovieTransition, publishAt *time.Time, ifMatch []int) (*models.Movie, error)
+}
+
+var (
+	_ UserStore  = (*UserRepository)(nil)
+	_ MovieStore = (*MovieRepository)(nil)
+	_ UserStore  = (*TracedUserStore)(nil)
+	_ MovieStore = (*TracedMovieStore)(nil)
+)
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/tracing"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startQuerySpan starts a client span around a Postgres repository call
+func startQuerySpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+}
+
+// TracedUserStore wraps a UserRepository in a span per call
+type TracedUserStore struct {
+	next UserStore
+}
+
+// NewTracedUserStore traces the calls made to next
+func NewTracedUserStore(next UserStore) *TracedUserStore {
+	return &TracedUserStore{next: next}
+}
+
+func (s *TracedUserStore) Create(ctx context.Context, input *models.CreateUserInput, passwordHash string) (*models.User, error) {
+	ctx, span := startQuerySpan(ctx, "UserRepository.Create")
+	user, err := s.next.Create(ctx, input, passwordHash)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (s *TracedUserStore) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	ctx, span := startQuerySpan(ctx, "UserRepository.GetByID")
+	user, err := s.next.GetByID(ctx, id)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (s *TracedUserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, span := startQuerySpan(ctx, "UserRepository.GetByEmail")
+	user, err := s.next.GetByEmail(ctx, email)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (s *TracedUserStore) Authenticate(ctx context.Context, email, password string) (*models.User, error) {
+	ctx, span := startQuerySpan(ctx, "UserRepository.Authenticate")
+	user, err := s.next.Authenticate(ctx, email, password)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (s *TracedUserStore) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	ctx, span := startQuerySpan(ctx, "UserRepository.UpdatePassword")
+	err := s.next.UpdatePassword(ctx, id, passwordHash)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *TracedUserStore) SetRole(ctx context.Context, id int64, role string) (*models.User, error) {
+	ctx, span := startQuerySpan(ctx, "UserRepository.SetRole")
+	user, err := s.next.SetRole(ctx, id, role)
+	tracing.End(span, err)
+	return user, err
+}
+
+// TracedMovieStore wraps a MovieRepository in a span per call
+type TracedMovieStore struct {
+	next MovieStore
+}
+
+// NewTracedMovieStore traces the calls made to next
+func NewTracedMovieStore(next MovieStore) *TracedMovieStore {
+	return &TracedMovieStore{next: next}
+}
+
+func (s *TracedMovieStore) Create(ctx context.Context, input *models.CreateMovieInput) (*models.Movie, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.Create")
+	movie, err := s.next.Create(ctx, input)
+	tracing.End(span, err)
+	return movie, err
+}
+
+func (s *TracedMovieStore) GetByID(ctx context.Context, id int64) (*models.Movie, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.GetByID")
+	movie, err := s.next.GetByID(ctx, id)
+	tracing.End(span, err)
+	return movie, err
+}
+
+func (s *TracedMovieStore) List(ctx context.Context, query *models.MovieQuery) ([]*models.Movie, int, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.List")
+	movies, total, err := s.next.List(ctx, query)
+	tracing.End(span, err)
+	return movies, total, err
+}
+
+func (s *TracedMovieStore) Update(ctx context.Context, id int64, input *models.UpdateMovieInput, ifMatch []int) (*models.Movie, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.Update")
+	movie, err := s.next.Update(ctx, id, input, ifMatch)
+	tracing.End(span, err)
+	return movie, err
+}
+
+func (s *TracedMovieStore) Delete(ctx context.Context, id int64, ifMatch []int) error {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.Delete")
+	err := s.next.Delete(ctx, id, ifMatch)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *TracedMovieStore) Export(ctx context.Context, query *models.MovieQuery, fn func(*models.Movie) error) error {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.Export")
+	err := s.next.Export(ctx, query, fn)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *TracedMovieStore) ListDeleted(ctx context.Context, page, pageSize int) ([]*models.Movie, int, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.ListDeleted")
+	movies, total, err := s.next.ListDeleted(ctx, page, pageSize)
+	tracing.End(span, err)
+	return movies, total, err
+}
+
+func (s *TracedMovieStore) Restore(ctx context.Context, id int64) (*models.Movie, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.Restore")
+	movie, err := s.next.Restore(ctx, id)
+	tracing.End(span, err)
+	return movie, err
+}
+
+func (s *TracedMovieStore) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.PurgeDeleted")
+	purged, err := s.next.PurgeDeleted(ctx, before)
+	tracing.End(span, err)
+	return purged, err
+}
+
+func (s *TracedMovieStore) GetByExternalID(ctx context.Context, source, externalID string) (*models.Movie, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.GetByExternalID")
+	movie, err := s.next.GetByExternalID(ctx, source, externalID)
+	tracing.End(span, err)
+	return movie, err
+}
+
+func (s *TracedMovieStore) UpsertByExternalID(ctx context.Context, source, externalID string, input *models.ReplaceMovieInput, ifMatch []int) (*models.Movie, bool, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.UpsertByExternalID")
+	movie, created, err := s.next.UpsertByExternalID(ctx, source, externalID, input, ifMatch)
+	tracing.End(span, err)
+	return movie, created, err
+}
+
+func (s *TracedMovieStore) SetImage(ctx context.Context, movieID int64, kind string, image *models.MovieImage) (*models.Movie, *models.MovieImage, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.SetImage")
+	movie, replaced, err := s.next.SetImage(ctx, movieID, kind, image)
+	tracing.End(span, err)
+	return movie, replaced, err
+}
+
+func (s *TracedMovieStore) RemoveImage(ctx context.Context, movieID int64, kind string) (*models.Movie, *models.MovieImage, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.RemoveImage")
+	movie, removed, err := s.next.RemoveImage(ctx, movieID, kind)
+	tracing.End(span, err)
+	return movie, removed, err
+}
+
+func (s *TracedMovieStore) ListRevisions(ctx context.Context, movieID int64, page, pageSize int) ([]*models.MovieRevision, int, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.ListRevisions")
+	revisions, total, err := s.next.ListRevisions(ctx, movieID, page, pageSize)
+	tracing.End(span, err)
+	return revisions, total, err
+}
+
+func (s *TracedMovieStore) GetRevision(ctx context.Context, movieID int64, revision int) (*models.MovieRevision, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.GetRevision")
+	rev, err := s.next.GetRevision(ctx, movieID, revision)
+	tracing.End(span, err)
+	return rev, err
+}
+
+func (s *TracedMovieStore) Revert(ctx context.Context, movieID int64, revision int) (*models.Movie, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.Revert")
+	movie, err := s.next.Revert(ctx, movieID, revision)
+	tracing.End(span, err)
+	return movie, err
+}
+
+func (s *TracedMovieStore) FindDuplicates(ctx context.Context, minSimilarity float64, page, pageSize int) ([]*models.DuplicateCandidate, int, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.FindDuplicates")
+	candidates, total, err := s.next.FindDuplicates(ctx, minSimilarity, page, pageSize)
+	tracing.End(span, err)
+	return candidates, total, err
+}
+
+func (s *TracedMovieStore) Merge(ctx context.Context, survivorID, duplicateID int64) (*models.MovieMergeResult, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.Merge")
+	result, err := s.next.Merge(ctx, survivorID, duplicateID)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (s *TracedMovieStore) Transition(ctx context.Context, id int64, transition models.MovieTransition, publishAt *time.Time, ifMatch []int) (*models.Movie, error) {
+	ctx, span := startQuerySpan(ctx, "MovieRepository.Transition")
+	movie, err := s.next.Transition(ctx, id, transition, publishAt, ifMatch)
+	tracing.End(span, err)
+	return movie, err
+}
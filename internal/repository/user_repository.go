@@ -40,20 +40,25 @@ func (r *UserRepository) Create(ctx context.Context, input *models.CreateUserInp
 		return nil, ErrEmailExists
 	}
 
+	role := input.Role
+	if role == "" {
+		role = models.UserRoleUser
+	}
+
 	// Create the user
 	query := `
-        INSERT INTO users (email, password_hash, first_name, last_name, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $5)
-        RETURNING id, email, password_hash, first_name, last_name, created_at, updated_at
+        INSERT INTO users (email, password_hash, first_name, last_name, role, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)
+        RETURNING id, email, password_hash, first_name, last_name, role, created_at, updated_at
     `
 
 	now := time.Now()
 	user := &models.User{}
 
 	err = r.db.QueryRowContext(
-		ctx, query, input.Email, passwordHash, input.FirstName, input.LastName, now,
+		ctx, query, input.Email, passwordHash, input.FirstName, input.LastName, role, now,
 	).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.Role, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -65,14 +70,14 @@ func (r *UserRepository) Create(ctx context.Context, input *models.CreateUserInp
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
 	query := `
-        SELECT id, email, password_hash, first_name, last_name, created_at, updated_at
+        SELECT id, email, password_hash, first_name, last_name, role, created_at, updated_at
         FROM users
         WHERE id = $1
     `
 
 	user := &models.User{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.Role, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -87,14 +92,14 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-        SELECT id, email, password_hash, first_name, last_name, created_at, updated_at
+        SELECT id, email, password_hash, first_name, last_name, role, created_at, updated_at
         FROM users
         WHERE email = $1
     `
 
 	user := &models.User{}
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.CreatedAt, &user.UpdatedAt,
+		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.Role, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -123,3 +128,36 @@ func (r *UserRepository) Authenticate(ctx context.Context, email, password strin
 
 	return user, nil
 }
+
+// UpdatePassword replaces a user's password hash
+func (r *UserRepository) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3
+    `, passwordHash, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return requireRowAffected(result, ErrUserNotFound)
+}
+
+// SetRole changes a user's role
+func (r *UserRepository) SetRole(ctx context.Context, id int64, role string) (*models.User, error) {
+	query := `
+        UPDATE users SET role = $1, updated_at = $2 WHERE id = $3
+        RETURNING id, email, password_hash, first_name, last_name, role, created_at, updated_at
+    `
+
+	user := &models.User{}
+	err := r.db.QueryRowContext(ctx, query, role, time.Now(), id).Scan(
+		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -54,3 +54,18 @@ func ValidationErrorResponse(w http.ResponseWriter, code int, message string, er
 	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(response)
 }
+
+// ErrorDataResponse returns an error response that still carries data, such
+// as the details of a failed check
+func ErrorDataResponse(w http.ResponseWriter, code int, message string, data interface{}) {
+	response := Response{
+		Status:  "error",
+		Code:    code,
+		Message: message,
+		Data:    data,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
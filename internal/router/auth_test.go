@@ -0,0 +1,135 @@
+package router_test
+
+import (
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestAuthFlow(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		sess := s.register(t)
+		if sess.token == "" {
+			t.Fatal("register returned no token")
+		}
+		if sess.user.Email != sess.email || sess.user.Role != models.UserRoleUser {
+			t.Errorf("registered user = %+v", sess.user)
+		}
+
+		res := s.do(t, request{method: http.MethodGet, path: "/users/me", token: sess.token})
+		res.expect(t, http.StatusOK)
+		var me models.UserResponse
+		res.decode(t, &me)
+		if me.ID != sess.user.ID {
+			t.Errorf("me = %+v, want user %d", me, sess.user.ID)
+		}
+
+		s.login(t, sess)
+		s.do(t, request{method: http.MethodGet, path: "/users/me", token: sess.token}).expect(t, http.StatusOK)
+
+		s.do(t, request{method: http.MethodPost, path: "/auth/logout", token: sess.token}).expect(t, http.StatusOK)
+		s.do(t, request{method: http.MethodGet, path: "/users/me", token: sess.token}).expect(t, http.StatusForbidden)
+		s.do(t, request{method: http.MethodPost, path: "/auth/logout", token: sess.token}).expect(t, http.StatusForbidden)
+
+		// Registering the same email again conflicts
+		res = s.do(t, request{method: http.MethodPost, path: "/auth/register", body: models.CreateUserInput{
+			Email: sess.email, Password: "another password",
+		}})
+		res.expect(t, http.StatusConflict)
+		if string(res.Body.Data) != "null" {
+			t.Errorf("error data = %s, want null", res.Body.Data)
+		}
+	})
+}
+
+func TestLoginFailures(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		sess := s.register(t)
+
+		tests := []struct {
+			name  string
+			input models.LoginInput
+		}{
+			{"wrong password", models.LoginInput{Email: sess.email, Password: "wrong"}},
+			{"unknown email", models.LoginInput{Email: uniqueEmail(), Password: sess.password}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				res := s.do(t, request{method: http.MethodPost, path: "/auth/login", body: tt.input})
+				res.expect(t, http.StatusUnauthorized)
+				if res.Body.Message != "Invalid credentials" {
+					t.Errorf("message = %q", res.Body.Message)
+				}
+			})
+		}
+	})
+}
+
+func TestAuthFailures(t *testing.T) {
+	s := newMemoryServer(t)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		code    int
+		message string
+	}{
+		{"missing header", nil, http.StatusUnauthorized, "Authorization header required"},
+		{"wrong scheme", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, http.StatusUnauthorized, "Invalid authorization header format"},
+		{"malformed token", map[string]string{"Authorization": "Bearer not-a-jwt"}, http.StatusForbidden, "Invalid token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, req := range []request{
+				{method: http.MethodGet, path: "/users/me", headers: tt.headers},
+				{method: http.MethodPost, path: "/movies", headers: tt.headers, body: map[string]string{"title": "Nope"}},
+				{method: http.MethodPost, path: "/auth/logout", headers: tt.headers},
+			} {
+				res := s.do(t, req)
+				res.expect(t, tt.code)
+				if res.Body.Message != tt.message {
+					t.Errorf("%s %s: message = %q, want %q", req.method, req.path, res.Body.Message, tt.message)
+				}
+			}
+		})
+	}
+
+	t.Run("role required", func(t *testing.T) {
+		sess := s.register(t)
+		for _, path := range []string{"/movies/trash", "/movies/export"} {
+			s.do(t, request{method: http.MethodGet, path: path, token: sess.token}).expect(t, http.StatusForbidden)
+		}
+	})
+
+	t.Run("invalid request body", func(t *testing.T) {
+		res := s.do(t, request{method: http.MethodPost, path: "/auth/login"})
+		res.expect(t, http.StatusBadRequest)
+		if res.Body.Message != "Invalid request body" {
+			t.Errorf("message = %q", res.Body.Message)
+		}
+	})
+}
+
+func TestEnvelopeFormat(t *testing.T) {
+	s := newMemoryServer(t)
+
+	res := s.do(t, request{method: http.MethodGet, path: "/health"})
+	res.expect(t, http.StatusOK)
+	if string(res.Body.Data) != "null" {
+		t.Errorf("health data = %s, want null", res.Body.Data)
+	}
+
+	res = s.do(t, request{method: http.MethodGet, path: "/no-such-route"})
+	res.expect(t, http.StatusNotFound)
+	if res.Body.Message != "Resource not found" {
+		t.Errorf("not found message = %q", res.Body.Message)
+	}
+
+	res = s.do(t, request{method: http.MethodDelete, path: "/auth/login"})
+	res.expect(t, http.StatusMethodNotAllowed)
+
+	// Features the memory backend lacks are not routed at all
+	s.do(t, request{method: http.MethodGet, path: "/genres"}).expect(t, http.StatusNotFound)
+}
@@ -0,0 +1,37 @@
+package router_test
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestGenreWritesRequireEditor(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	user := s.register(t)
+
+	res := s.do(t, request{method: http.MethodPost, path: "/genres", token: editor.token,
+		body: models.CreateGenreInput{Name: "Western", Slug: models.Slugify(uniqueTitle(t))},
+	})
+	res.expect(t, http.StatusCreated)
+	var genre models.Genre
+	res.decode(t, &genre)
+
+	// Renames and deletes reach every movie in the genre
+	path := fmt.Sprintf("/genres/%d", genre.ID)
+	name := "Spaghetti western"
+	for _, req := range []request{
+		{method: http.MethodPost, path: "/genres", body: models.CreateGenreInput{Name: "Horror", Slug: models.Slugify(uniqueTitle(t))}},
+		{method: http.MethodPut, path: path, body: models.UpdateGenreInput{Name: &name}},
+		{method: http.MethodDelete, path: path},
+	} {
+		req.token = user.token
+		if res := s.do(t, req); res.StatusCode != http.StatusForbidden {
+			t.Errorf("%s %s as a user: status = %d, want 403", req.method, req.path, res.StatusCode)
+		}
+	}
+
+	s.do(t, request{method: http.MethodGet, path: path}).expect(t, http.StatusOK)
+}
@@ -0,0 +1,94 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"github.com/marchelhutagalung/go-service/internal/health"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLiveness(t *testing.T) {
+	s := newMemoryServer(t)
+	s.checker.Register("broken", func(ctx context.Context) error { return errors.New("down") }, true)
+
+	// Liveness ignores dependencies
+	s.do(t, request{method: http.MethodGet, path: "/livez"}).expect(t, http.StatusOK)
+}
+
+func TestReadiness(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	type check struct {
+		name     string
+		fn       health.CheckFunc
+		critical bool
+	}
+
+	tests := []struct {
+		name       string
+		checks     []check
+		code       int
+		status     string
+		downChecks []string
+	}{
+		{"no checks", nil, http.StatusOK, health.StatusUp, nil},
+		{"all up", []check{{"db", up, true}, {"cache", up, false}}, http.StatusOK, health.StatusUp, nil},
+		{"non-critical down", []check{{"db", up, true}, {"cache", down, false}}, http.StatusOK, health.StatusDegraded, []string{"cache"}},
+		{"critical down", []check{{"db", down, true}, {"cache", up, false}}, http.StatusServiceUnavailable, health.StatusDown, []string{"db"}},
+		{"critical times out", []check{{"db", hang, true}}, http.StatusServiceUnavailable, health.StatusDown, []string{"db"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newMemoryServer(t)
+			for _, c := range tt.checks {
+				s.checker.Register(c.name, c.fn, c.critical)
+			}
+
+			start := time.Now()
+			res := s.do(t, request{method: http.MethodGet, path: "/readyz"})
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("readiness took %v, want it bounded by the check timeout", elapsed)
+			}
+			res.expect(t, tt.code)
+
+			var report health.Report
+			res.decode(t, &report)
+			if report.Status != tt.status {
+				t.Errorf("status = %q, want %q", report.Status, tt.status)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("checks = %v, want %d", report.Checks, len(tt.checks))
+			}
+			for _, name := range tt.downChecks {
+				if result := report.Checks[name]; result.Status != health.StatusDown || result.Error == "" {
+					t.Errorf("check %s = %+v, want down with an error", name, result)
+				}
+			}
+		})
+	}
+
+	t.Run("shutting down", func(t *testing.T) {
+		s := newMemoryServer(t)
+		s.checker.Register("db", up, true)
+		s.checker.SetShuttingDown()
+
+		res := s.do(t, request{method: http.MethodGet, path: "/readyz"})
+		res.expect(t, http.StatusServiceUnavailable)
+
+		var report health.Report
+		res.decode(t, &report)
+		if !report.ShuttingDown || report.Status != health.StatusDown {
+			t.Errorf("report = %+v, want down and shutting down", report)
+		}
+
+		s.do(t, request{method: http.MethodGet, path: "/livez"}).expect(t, http.StatusOK)
+	})
+}
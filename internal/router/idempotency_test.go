@@ -0,0 +1,167 @@
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		sess := s.registerWithRole(t, models.UserRoleEditor)
+		title := uniqueTitle(t)
+		input := models.CreateMovieInput{Title: title, ReleaseDate: *releaseDate(2001), Duration: 100}
+		create := request{method: http.MethodPost, path: "/movies", token: sess.token, body: input,
+			headers: map[string]string{"Idempotency-Key": "create-" + title}}
+
+		first := s.do(t, create)
+		first.expect(t, http.StatusCreated)
+		if first.Header.Get("Idempotent-Replayed") != "" {
+			t.Error("first response is marked as replayed")
+		}
+
+		second := s.do(t, create)
+		second.expect(t, http.StatusCreated)
+		if second.Header.Get("Idempotent-Replayed") != "true" {
+			t.Error("repeat is not marked as replayed")
+		}
+		if !bytes.Equal(first.Body.Data, second.Body.Data) {
+			t.Errorf("replayed data = %s, want %s", second.Body.Data, first.Body.Data)
+		}
+
+		// The same key with a different body
+		changed := create
+		changed.body = models.CreateMovieInput{Title: title + " 2", ReleaseDate: *releaseDate(2001), Duration: 100}
+		s.do(t, changed).expect(t, http.StatusConflict)
+
+		// Keys belong to the user who sent them
+		other := s.registerWithRole(t, models.UserRoleEditor)
+		otherCreate := create
+		otherCreate.token = other.token
+		res := s.do(t, otherCreate)
+		res.expect(t, http.StatusCreated)
+		if res.Header.Get("Idempotent-Replayed") != "" {
+			t.Error("another user's key was replayed")
+		}
+
+		// Without a key every request is handled
+		plain := create
+		plain.headers = nil
+		s.do(t, plain).expect(t, http.StatusCreated)
+
+		page := s.listMovies(t, sess.token, url.Values{"title": {title}})
+		if page.TotalCount != 3 {
+			t.Errorf("created %d movies, want 3", page.TotalCount)
+		}
+	})
+}
+
+func TestIdempotencyKeyRegister(t *testing.T) {
+	s := newMemoryServer(t)
+
+	register := request{method: http.MethodPost, path: "/auth/register",
+		body:    models.CreateUserInput{Email: uniqueEmail(), Password: "correct horse battery"},
+		headers: map[string]string{"Idempotency-Key": "register-1"},
+	}
+
+	var users []handlers.RegisterResponse
+	for i := 0; i < 2; i++ {
+		res := s.do(t, register)
+		res.expect(t, http.StatusCreated)
+
+		var data handlers.RegisterResponse
+		res.decode(t, &data)
+		users = append(users, data)
+	}
+
+	if users[0].User.ID != users[1].User.ID {
+		t.Errorf("registered users %d and %d, want one", users[0].User.ID, users[1].User.ID)
+	}
+
+	// The token is only handed out once; whoever replays the key must log in
+	if users[0].Token == "" {
+		t.Error("first response has no token")
+	}
+	if users[1].Token != "" {
+		t.Error("replayed response has a token")
+	}
+
+	// A retry without the key hits the duplicate email
+	register.headers = nil
+	s.do(t, register).expect(t, http.StatusConflict)
+}
+
+func TestIdempotencyKeyBodyTooLarge(t *testing.T) {
+	s := newMemoryServer(t)
+	sess := s.registerWithRole(t, models.UserRoleEditor)
+
+	input := models.CreateMovieInput{Title: uniqueTitle(t), Description: strings.Repeat("x", 64<<10),
+		ReleaseDate: *releaseDate(2003), Duration: 90}
+	s.do(t, request{method: http.MethodPost, path: "/movies", token: sess.token, body: input,
+		headers: map[string]string{"Idempotency-Key": "too-large"}}).expect(t, http.StatusRequestEntityTooLarge)
+}
+
+func TestIdempotencyKeyConcurrent(t *testing.T) {
+	s := newMemoryServer(t)
+	sess := s.registerWithRole(t, models.UserRoleEditor)
+
+	body, err := json.Marshal(models.CreateMovieInput{Title: uniqueTitle(t), ReleaseDate: *releaseDate(2002), Duration: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const clients = 8
+	ids := make(chan string, clients)
+	errs := make(chan error, clients)
+
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req, _ := http.NewRequest(http.MethodPost, s.URL+"/api/v1/movies", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+sess.token)
+			req.Header.Set("Idempotency-Key", "concurrent")
+
+			res, err := s.Client().Do(req)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer res.Body.Close()
+
+			var movie struct {
+				Data models.Movie `json:"data"`
+			}
+			if err := json.NewDecoder(res.Body).Decode(&movie); err != nil || res.StatusCode != http.StatusCreated {
+				errs <- fmt.Errorf("status %d, decode error %v", res.StatusCode, err)
+				return
+			}
+			ids <- fmt.Sprint(movie.Data.ID)
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	seen := map[string]bool{}
+	for id := range ids {
+		seen[id] = true
+	}
+	if len(seen) != 1 {
+		t.Errorf("created movies %v, want one", seen)
+	}
+}
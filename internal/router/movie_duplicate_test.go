@@ -0,0 +1,82 @@
+package router_test
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestMovieMerge(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		editor := s.registerWithRole(t, models.UserRoleEditor)
+		admin := s.registerWithRole(t, models.UserRoleAdmin)
+		title := uniqueTitle(t)
+
+		survivor := s.createMovie(t, editor.token, models.CreateMovieInput{
+			Title: title, ReleaseDate: *releaseDate(1931), Duration: 100,
+		})
+		duplicate := s.createMovie(t, editor.token, models.CreateMovieInput{
+			Title: title, ReleaseDate: *releaseDate(1931), Duration: 101,
+		})
+
+		s.do(t, request{method: http.MethodGet, path: "/movies/duplicates", token: editor.token}).expect(t, http.StatusForbidden)
+		s.do(t, request{method: http.MethodGet, path: "/movies/duplicates?min_similarity=2", token: admin.token}).expect(t, http.StatusBadRequest)
+
+		// Other runs may have left identical titles behind, so page until the
+		// pair turns up
+		found := false
+		for page := 1; !found; page++ {
+			res := s.do(t, request{method: http.MethodGet, path: fmt.Sprintf("/movies/duplicates?min_similarity=1&page_size=100&page=%d", page), token: admin.token})
+			res.expect(t, http.StatusOK)
+			var candidates handlers.PaginatedDuplicateResponse
+			res.decode(t, &candidates)
+
+			for _, candidate := range candidates.Duplicates {
+				if candidate.Movie.ID == survivor.ID && candidate.Duplicate.ID == duplicate.ID {
+					found = true
+				}
+			}
+			if page >= candidates.TotalPages {
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("duplicates lack movies %d and %d", survivor.ID, duplicate.ID)
+		}
+
+		path := fmt.Sprintf("/movies/%d/merge", survivor.ID)
+		s.do(t, request{method: http.MethodPost, path: path, token: editor.token,
+			body: models.MergeMovieInput{DuplicateID: duplicate.ID},
+		}).expect(t, http.StatusForbidden)
+		s.do(t, request{method: http.MethodPost, path: path, token: admin.token,
+			body: models.MergeMovieInput{DuplicateID: survivor.ID},
+		}).expect(t, http.StatusBadRequest)
+
+		res := s.do(t, request{method: http.MethodPost, path: path, token: admin.token,
+			body: models.MergeMovieInput{DuplicateID: duplicate.ID},
+		})
+		res.expect(t, http.StatusOK)
+		var result models.MovieMergeResult
+		res.decode(t, &result)
+		if result.DuplicateID != duplicate.ID || result.Movie == nil || result.Movie.ID != survivor.ID {
+			t.Errorf("merge result = %+v", result)
+		}
+
+		// The duplicate goes to the trash and can no longer be merged
+		s.do(t, request{method: http.MethodGet, path: fmt.Sprintf("/movies/%d", duplicate.ID), token: editor.token}).
+			expect(t, http.StatusNotFound)
+		s.do(t, request{method: http.MethodPost, path: path, token: admin.token,
+			body: models.MergeMovieInput{DuplicateID: duplicate.ID},
+		}).expect(t, http.StatusNotFound)
+
+		res = s.do(t, request{method: http.MethodGet, path: fmt.Sprintf("/movies/%d/revisions", survivor.ID), token: editor.token})
+		res.expect(t, http.StatusOK)
+		var revisions handlers.PaginatedRevisionResponse
+		res.decode(t, &revisions)
+		if len(revisions.Revisions) == 0 || revisions.Revisions[0].Action != models.RevisionActionMerge {
+			t.Errorf("survivor revisions = %+v", revisions.Revisions)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package router_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestMovieExport(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		editor := s.registerWithRole(t, models.UserRoleEditor)
+		user := s.register(t)
+		title := uniqueTitle(t)
+
+		for _, suffix := range []string{"A", "B"} {
+			s.createMovie(t, editor.token, models.CreateMovieInput{
+				Title: title + " " + suffix, ReleaseDate: *releaseDate(2019), Duration: 100,
+			})
+		}
+
+		export := func(format string, headers map[string]string) *http.Response {
+			t.Helper()
+
+			params := url.Values{"title": {title}, "sort_by": {"title"}, "format": {format}}
+			res := s.send(t, request{method: http.MethodGet, path: "/movies/export?" + params.Encode(), token: editor.token, headers: headers})
+			t.Cleanup(func() { res.Body.Close() })
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("export %s status = %d", format, res.StatusCode)
+			}
+			return res
+		}
+
+		s.do(t, request{method: http.MethodGet, path: "/movies/export", token: user.token}).expect(t, http.StatusForbidden)
+		s.do(t, request{method: http.MethodGet, path: "/movies/export?format=xml", token: editor.token}).expect(t, http.StatusBadRequest)
+
+		// JSON is the default and holds drafts too
+		var movies []models.Movie
+		if err := json.NewDecoder(export("", nil).Body).Decode(&movies); err != nil {
+			t.Fatalf("decoding JSON export: %v", err)
+		}
+		if len(movies) != 2 || movies[0].Title != title+" A" || movies[1].Status != models.MovieStatusDraft {
+			t.Errorf("JSON export = %+v", movies)
+		}
+
+		res := export("ndjson", nil)
+		if contentType := res.Header.Get("Content-Type"); contentType != "application/x-ndjson" {
+			t.Errorf("NDJSON Content-Type = %q", contentType)
+		}
+		lines := 0
+		for scanner := bufio.NewScanner(res.Body); scanner.Scan(); lines++ {
+			var movie models.Movie
+			if err := json.Unmarshal(scanner.Bytes(), &movie); err != nil {
+				t.Fatalf("decoding NDJSON line %q: %v", scanner.Text(), err)
+			}
+		}
+		if lines != 2 {
+			t.Errorf("NDJSON export has %d lines, want 2", lines)
+		}
+
+		// Asking for gzip explicitly leaves the body compressed
+		res = export("csv", map[string]string{"Accept-Encoding": "gzip"})
+		if encoding := res.Header.Get("Content-Encoding"); encoding != "gzip" {
+			t.Fatalf("CSV Content-Encoding = %q, want gzip", encoding)
+		}
+		gz, err := gzip.NewReader(res.Body)
+		if err != nil {
+			t.Fatalf("opening gzip body: %v", err)
+		}
+		records, err := csv.NewReader(gz).ReadAll()
+		if err != nil {
+			t.Fatalf("reading CSV export: %v", err)
+		}
+		if len(records) != 3 || records[0][1] != "title" || records[2][1] != title+" B" {
+			t.Errorf("CSV export = %v", records)
+		}
+	})
+}
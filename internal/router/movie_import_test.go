@@ -0,0 +1,81 @@
+package router_test
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMovieImportStatus(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	csv := map[string]string{"Content-Type": "text/csv"}
+
+	for _, tc := range []struct {
+		query  string
+		status string
+		public int
+	}{
+		{"", models.MovieStatusPublished, 2},
+		{"?status=draft", models.MovieStatusDraft, 0},
+	} {
+		title := uniqueTitle(t)
+		raw := fmt.Sprintf("title,release_date,duration\n%[1]s A,2001-06-01,100\n%[1]s B,2002-06-01,110\n", title)
+		res := s.do(t, request{method: http.MethodPost, path: "/movies/import" + tc.query, token: editor.token, raw: raw, headers: csv})
+		res.expect(t, http.StatusOK)
+		var result models.MovieImportResult
+		res.decode(t, &result)
+		if !result.Committed || result.Created != 2 {
+			t.Fatalf("import%s result = %+v", tc.query, result)
+		}
+
+		page := s.listMovies(t, editor.token, url.Values{"title": {title}})
+		if page.TotalCount != 2 || page.Movies[0].Status != tc.status || page.Movies[1].Status != tc.status {
+			t.Errorf("import%s created %d movies, want 2 %s", tc.query, page.TotalCount, tc.status)
+		}
+		if anonymous := s.listMovies(t, "", url.Values{"title": {title}}); anonymous.TotalCount != tc.public {
+			t.Errorf("import%s: anonymous readers see %d movies, want %d", tc.query, anonymous.TotalCount, tc.public)
+		}
+	}
+}
+
+func TestMovieImportErrors(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+
+	csv := map[string]string{"Content-Type": "text/csv"}
+	for _, tc := range []struct {
+		name    string
+		path    string
+		raw     string
+		message string
+	}{
+		{"unknown mode", "/movies/import?mode=sometimes", "title\nHeat\n", "Validation failed"},
+		{"unknown status", "/movies/import?status=hidden", "title\nHeat\n", "Validation failed"},
+		{"empty file", "/movies/import", "", "Import failed: CSV file is empty"},
+		{"unknown column", "/movies/import", "title,budget\nHeat,60000000\n", `Import failed: unknown CSV column "budget"`},
+		{"no title column", "/movies/import", "description\nA heist\n", "Import failed: CSV header must include a title column"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res := s.do(t, request{method: http.MethodPost, path: tc.path, token: editor.token, raw: tc.raw, headers: csv})
+			res.expect(t, http.StatusBadRequest)
+			if res.Body.Message != tc.message {
+				t.Errorf("message = %q, want %q", res.Body.Message, tc.message)
+			}
+		})
+	}
+
+	// Bad rows are reported in the result, not as a failed request
+	res := s.do(t, request{method: http.MethodPost, path: "/movies/import?format=ndjson", token: editor.token,
+		raw: `{"title": "` + uniqueTitle(t) + `", "duration": "long"}` + "\n",
+	})
+	res.expect(t, http.StatusOK)
+	var result models.MovieImportResult
+	res.decode(t, &result)
+	if result.Committed || result.Failed != 1 || len(result.Errors) != 1 || !strings.HasPrefix(result.Errors[0].Message, "Invalid JSON") {
+		t.Errorf("import result = %+v", result)
+	}
+}
@@ -0,0 +1,197 @@
+package router_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// createList creates a list owned by sess
+func (s *testServer) createList(t *testing.T, sess *session, input models.CreateMovieListInput) *models.MovieList {
+	t.Helper()
+
+	res := s.do(t, request{method: http.MethodPost, path: "/lists", token: sess.token, body: input})
+	res.expect(t, http.StatusCreated)
+
+	var list models.MovieList
+	res.decode(t, &list)
+	return &list
+}
+
+// listItemIDs returns the movie IDs on a list in order
+func listItemIDs(list *models.MovieList) []int64 {
+	ids := make([]int64, 0, len(list.Items))
+	for _, item := range list.Items {
+		ids = append(ids, item.Movie.ID)
+	}
+	return ids
+}
+
+func TestMovieListCRUD(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	admin := s.registerWithRole(t, models.UserRoleAdmin)
+	owner := s.register(t)
+	other := s.register(t)
+
+	movies := make([]*models.Movie, 3)
+	for i := range movies {
+		movies[i] = s.createPublishedMovie(t, editor, admin, models.CreateMovieInput{
+			Title: fmt.Sprintf("%s %d", uniqueTitle(t), i), ReleaseDate: *releaseDate(2015), Duration: 100,
+		})
+	}
+
+	s.do(t, request{method: http.MethodPost, path: "/lists", token: owner.token, body: models.CreateMovieListInput{}}).
+		expect(t, http.StatusBadRequest)
+
+	list := s.createList(t, owner, models.CreateMovieListInput{Title: "Favourites of the decade"})
+	if list.Visibility != models.ListVisibilityPrivate || list.UserID != owner.user.ID {
+		t.Errorf("created list = %+v", list)
+	}
+	path := fmt.Sprintf("/lists/%d", list.ID)
+
+	// Items are appended unless a position is given
+	for _, input := range []models.AddMovieListItemInput{
+		{MovieID: movies[0].ID},
+		{MovieID: movies[1].ID},
+		{MovieID: movies[2].ID, Position: 1, Note: "Start here"},
+	} {
+		s.do(t, request{method: http.MethodPost, path: path + "/items", token: owner.token, body: input}).
+			expect(t, http.StatusCreated)
+	}
+	s.do(t, request{method: http.MethodPost, path: path + "/items", token: owner.token,
+		body: models.AddMovieListItemInput{MovieID: movies[0].ID},
+	}).expect(t, http.StatusConflict)
+
+	res := s.do(t, request{method: http.MethodGet, path: path, token: owner.token})
+	res.expect(t, http.StatusOK)
+	var read models.MovieList
+	res.decode(t, &read)
+	want := []int64{movies[2].ID, movies[0].ID, movies[1].ID}
+	if got := listItemIDs(&read); fmt.Sprint(got) != fmt.Sprint(want) || read.Items[0].Note != "Start here" {
+		t.Errorf("list items = %v, want %v", got, want)
+	}
+
+	s.do(t, request{method: http.MethodPut, path: path + "/items/order", token: owner.token,
+		body: models.ReorderMovieListInput{MovieIDs: []int64{movies[0].ID, movies[1].ID}},
+	}).expect(t, http.StatusBadRequest)
+	res = s.do(t, request{method: http.MethodPut, path: path + "/items/order", token: owner.token,
+		body: models.ReorderMovieListInput{MovieIDs: []int64{movies[0].ID, movies[1].ID, movies[2].ID}},
+	})
+	res.expect(t, http.StatusOK)
+	res.decode(t, &read)
+	want = []int64{movies[0].ID, movies[1].ID, movies[2].ID}
+	if got := listItemIDs(&read); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("reordered items = %v, want %v", got, want)
+	}
+
+	res = s.do(t, request{method: http.MethodPut, path: fmt.Sprintf("%s/items/%d", path, movies[1].ID), token: owner.token,
+		body: models.UpdateMovieListItemInput{Note: "Rewatch"},
+	})
+	res.expect(t, http.StatusOK)
+	res.decode(t, &read)
+	if read.Items[1].Note != "Rewatch" {
+		t.Errorf("updated item = %+v", read.Items[1])
+	}
+
+	// Private lists are hidden from everyone else, shared ones are read-only
+	s.do(t, request{method: http.MethodGet, path: path, token: other.token}).expect(t, http.StatusNotFound)
+	s.do(t, request{method: http.MethodDelete, path: path, token: other.token}).expect(t, http.StatusNotFound)
+
+	visibility := models.ListVisibilityUnlisted
+	s.do(t, request{method: http.MethodPut, path: path, token: owner.token,
+		body: models.UpdateMovieListInput{Visibility: &visibility},
+	}).expect(t, http.StatusOK)
+	s.do(t, request{method: http.MethodGet, path: path}).expect(t, http.StatusOK)
+	s.do(t, request{method: http.MethodDelete, path: path, token: other.token}).expect(t, http.StatusForbidden)
+
+	userLists := func(path, token string) []int64 {
+		t.Helper()
+
+		res := s.do(t, request{method: http.MethodGet, path: path, token: token})
+		res.expect(t, http.StatusOK)
+		var page handlers.PaginatedMovieListResponse
+		res.decode(t, &page)
+
+		ids := []int64{}
+		for _, l := range page.Lists {
+			ids = append(ids, l.ID)
+		}
+		return ids
+	}
+
+	profile := fmt.Sprintf("/users/%d/lists", owner.user.ID)
+	if ids := userLists(profile, ""); len(ids) != 0 {
+		t.Errorf("profile lists an unlisted list: %v", ids)
+	}
+	visibility = models.ListVisibilityPublic
+	s.do(t, request{method: http.MethodPut, path: path, token: owner.token,
+		body: models.UpdateMovieListInput{Visibility: &visibility},
+	}).expect(t, http.StatusOK)
+	if ids := userLists(profile, ""); fmt.Sprint(ids) != fmt.Sprint([]int64{list.ID}) {
+		t.Errorf("profile lists = %v, want %d", ids, list.ID)
+	}
+	if ids := userLists("/users/me/lists", owner.token); fmt.Sprint(ids) != fmt.Sprint([]int64{list.ID}) {
+		t.Errorf("own lists = %v, want %d", ids, list.ID)
+	}
+
+	s.do(t, request{method: http.MethodDelete, path: fmt.Sprintf("%s/items/%d", path, movies[0].ID), token: owner.token}).
+		expect(t, http.StatusOK)
+	s.do(t, request{method: http.MethodDelete, path: fmt.Sprintf("%s/items/%d", path, movies[0].ID), token: owner.token}).
+		expect(t, http.StatusNotFound)
+
+	s.do(t, request{method: http.MethodDelete, path: path, token: owner.token}).expect(t, http.StatusOK)
+	s.do(t, request{method: http.MethodGet, path: path, token: owner.token}).expect(t, http.StatusNotFound)
+}
+
+func TestMovieListAddAfterRemoval(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	admin := s.registerWithRole(t, models.UserRoleAdmin)
+	user := s.register(t)
+
+	movies := make([]*models.Movie, 4)
+	for i := range movies {
+		movies[i] = s.createPublishedMovie(t, editor, admin, models.CreateMovieInput{
+			Title: fmt.Sprintf("%s %d", uniqueTitle(t), i), ReleaseDate: *releaseDate(2015), Duration: 100,
+		})
+	}
+
+	list := s.createList(t, user, models.CreateMovieListInput{Title: "Weekend"})
+	items := fmt.Sprintf("/lists/%d/items", list.ID)
+	for _, movie := range movies[:3] {
+		s.do(t, request{method: http.MethodPost, path: items, token: user.token,
+			body: models.AddMovieListItemInput{MovieID: movie.ID},
+		}).expect(t, http.StatusCreated)
+	}
+
+	// Removing through the API closes the gap
+	s.do(t, request{method: http.MethodDelete, path: fmt.Sprintf("%s/%d", items, movies[1].ID), token: user.token}).
+		expect(t, http.StatusOK)
+	s.do(t, request{method: http.MethodPost, path: items, token: user.token,
+		body: models.AddMovieListItemInput{MovieID: movies[1].ID},
+	}).expect(t, http.StatusCreated)
+
+	// Purging a movie leaves one behind
+	s.do(t, request{method: http.MethodDelete, path: fmt.Sprintf("/movies/%d", movies[0].ID), token: editor.token}).
+		expect(t, http.StatusOK)
+	if _, err := s.movies.PurgeDeleted(context.Background(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("purging trash: %v", err)
+	}
+
+	res := s.do(t, request{method: http.MethodPost, path: items, token: user.token,
+		body: models.AddMovieListItemInput{MovieID: movies[3].ID},
+	})
+	res.expect(t, http.StatusCreated)
+	var updated models.MovieList
+	res.decode(t, &updated)
+
+	want := []int64{movies[2].ID, movies[1].ID, movies[3].ID}
+	if got := listItemIDs(&updated); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("list items = %v, want %v", got, want)
+	}
+}
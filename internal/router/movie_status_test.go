@@ -0,0 +1,74 @@
+package router_test
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMovieWorkflow(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		editor := s.registerWithRole(t, models.UserRoleEditor)
+		admin := s.registerWithRole(t, models.UserRoleAdmin)
+		user := s.register(t)
+
+		movie := s.createMovie(t, editor.token, models.CreateMovieInput{
+			Title: uniqueTitle(t), ReleaseDate: *releaseDate(2017), Duration: 100,
+		})
+		path := fmt.Sprintf("/movies/%d", movie.ID)
+
+		transition := func(name string, sess *session, body interface{}, code int) *models.Movie {
+			t.Helper()
+
+			res := s.do(t, request{method: http.MethodPost, path: path + "/" + name, token: sess.token, body: body})
+			res.expect(t, code)
+			if code != http.StatusOK {
+				return nil
+			}
+
+			var moved models.Movie
+			res.decode(t, &moved)
+			return &moved
+		}
+
+		// Only the transition's role may take it
+		transition("submit", user, nil, http.StatusForbidden)
+		transition("publish", editor, nil, http.StatusForbidden)
+
+		// Transitions only start from their own status
+		transition("publish", admin, nil, http.StatusConflict)
+
+		if moved := transition("submit", editor, nil, http.StatusOK); moved.Status != models.MovieStatusInReview {
+			t.Errorf("status after submit = %q", moved.Status)
+		}
+		if moved := transition("reject", admin, nil, http.StatusOK); moved.Status != models.MovieStatusDraft {
+			t.Errorf("status after reject = %q", moved.Status)
+		}
+		transition("submit", editor, nil, http.StatusOK)
+
+		// Only publishing takes a time; a scheduled movie stays hidden until it
+		transition("reject", admin, models.MovieTransitionInput{PublishAt: releaseDate(2017)}, http.StatusBadRequest)
+
+		publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		moved := transition("publish", admin, models.MovieTransitionInput{PublishAt: &publishAt}, http.StatusOK)
+		if moved.Status != models.MovieStatusPublished || moved.PublishAt == nil || !moved.PublishAt.Equal(publishAt) {
+			t.Errorf("scheduled movie = status %q, publish_at %v", moved.Status, moved.PublishAt)
+		}
+		s.do(t, request{method: http.MethodGet, path: path, token: user.token}).expect(t, http.StatusNotFound)
+		s.do(t, request{method: http.MethodGet, path: path, token: editor.token}).expect(t, http.StatusOK)
+
+		transition("archive", admin, nil, http.StatusOK)
+		transition("archive", admin, nil, http.StatusConflict)
+
+		// Publishing right away shows the movie to everyone
+		other := s.createPublishedMovie(t, editor, admin, models.CreateMovieInput{
+			Title: uniqueTitle(t), ReleaseDate: *releaseDate(2017), Duration: 100,
+		})
+		if other.Status != models.MovieStatusPublished {
+			t.Errorf("published movie status = %q", other.Status)
+		}
+		s.do(t, request{method: http.MethodGet, path: fmt.Sprintf("/movies/%d", other.ID)}).expect(t, http.StatusOK)
+	})
+}
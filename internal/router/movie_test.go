@@ -0,0 +1,322 @@
+package router_test
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// uniqueTitle returns a title prefix no other run uses, so list tests can
+// filter down to their own movies on a shared database
+func uniqueTitle(t *testing.T) string {
+	return fmt.Sprintf("e2e %s %d", t.Name(), time.Now().UnixNano())
+}
+
+func releaseDate(year int) *time.Time {
+	date := time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC)
+	return &date
+}
+
+// createMovie creates a movie through the API and returns it
+func (s *testServer) createMovie(t *testing.T, token string, input models.CreateMovieInput) *models.Movie {
+	t.Helper()
+
+	res := s.do(t, request{method: http.MethodPost, path: "/movies", token: token, body: input})
+	res.expect(t, http.StatusCreated)
+
+	var movie models.Movie
+	res.decode(t, &movie)
+	return &movie
+}
+
+// createPublishedMovie creates a movie as editor and has admin publish it, so
+// that every user can see it
+func (s *testServer) createPublishedMovie(t *testing.T, editor, admin *session, input models.CreateMovieInput) *models.Movie {
+	t.Helper()
+
+	movie := s.createMovie(t, editor.token, input)
+	path := fmt.Sprintf("/movies/%d", movie.ID)
+	s.do(t, request{method: http.MethodPost, path: path + "/submit", token: editor.token}).expect(t, http.StatusOK)
+
+	res := s.do(t, request{method: http.MethodPost, path: path + "/publish", token: admin.token})
+	res.expect(t, http.StatusOK)
+	res.decode(t, movie)
+	return movie
+}
+
+// listMovies lists movies with the given query parameters
+func (s *testServer) listMovies(t *testing.T, token string, params url.Values) *handlers.PaginatedMovieResponse {
+	t.Helper()
+
+	res := s.do(t, request{method: http.MethodGet, path: "/movies?" + params.Encode(), token: token})
+	res.expect(t, http.StatusOK)
+
+	var page handlers.PaginatedMovieResponse
+	res.decode(t, &page)
+	return &page
+}
+
+func TestMovieCRUD(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		editor := s.registerWithRole(t, models.UserRoleEditor)
+		title := uniqueTitle(t)
+
+		// Create
+		movie := s.createMovie(t, editor.token, models.CreateMovieInput{
+			Title: title, Description: "First cut", ReleaseDate: *releaseDate(2010), Rating: 7.5, Duration: 120,
+		})
+		if movie.ID == 0 || movie.Title != title || movie.Version != 1 || movie.Status != models.MovieStatusDraft {
+			t.Fatalf("created movie = %+v", movie)
+		}
+		path := fmt.Sprintf("/movies/%d", movie.ID)
+
+		// Read: drafts are only visible to editors
+		res := s.do(t, request{method: http.MethodGet, path: path, token: editor.token})
+		res.expect(t, http.StatusOK)
+		etag := res.Header.Get("ETag")
+		if etag != `"1"` && !strings.HasPrefix(etag, `"1.`) {
+			t.Errorf("ETag = %q, want version 1", etag)
+		}
+		s.do(t, request{method: http.MethodGet, path: path}).expect(t, http.StatusNotFound)
+
+		notModified := s.do(t, request{method: http.MethodGet, path: path, token: editor.token, headers: map[string]string{"If-None-Match": etag}})
+		if notModified.StatusCode != http.StatusNotModified {
+			t.Errorf("conditional GET status = %d, want 304", notModified.StatusCode)
+		}
+
+		// Replace, guarded by the version
+		replacement := models.ReplaceMovieInput{
+			Title: &title, ReleaseDate: releaseDate(2011), Duration: intPtr(130),
+		}
+		res = s.do(t, request{method: http.MethodPut, path: path, token: editor.token, body: replacement, headers: map[string]string{"If-Match": `"1"`}})
+		res.expect(t, http.StatusOK)
+		var replaced models.Movie
+		res.decode(t, &replaced)
+		if replaced.Version != 2 || replaced.Duration != 130 || replaced.Description != "" || replaced.ReleaseDate.Year() != 2011 {
+			t.Errorf("replaced movie = %+v", replaced)
+		}
+
+		res = s.do(t, request{method: http.MethodPut, path: path, token: editor.token, body: replacement, headers: map[string]string{"If-Match": `"1"`}})
+		res.expect(t, http.StatusPreconditionFailed)
+
+		res = s.do(t, request{method: http.MethodPut, path: path, token: editor.token, body: models.ReplaceMovieInput{}})
+		res.expect(t, http.StatusBadRequest)
+		var problems map[string]string
+		res.decode(t, &problems)
+		for _, field := range []string{"title", "release_date", "duration"} {
+			if problems[field] == "" {
+				t.Errorf("validation errors %v lack %s", problems, field)
+			}
+		}
+
+		// Patch
+		res = s.do(t, request{method: http.MethodPatch, path: path, token: editor.token,
+			body:    map[string]interface{}{"description": "Director's cut"},
+			headers: map[string]string{"Content-Type": "application/merge-patch+json"},
+		})
+		res.expect(t, http.StatusOK)
+		var patched models.Movie
+		res.decode(t, &patched)
+		if patched.Description != "Director's cut" || patched.Duration != 130 || patched.Version != 3 {
+			t.Errorf("patched movie = %+v", patched)
+		}
+
+		// Delete
+		s.do(t, request{method: http.MethodDelete, path: path, token: editor.token, headers: map[string]string{"If-Match": `"2"`}}).
+			expect(t, http.StatusPreconditionFailed)
+		s.do(t, request{method: http.MethodDelete, path: path, token: editor.token, headers: map[string]string{"If-Match": `"3"`}}).
+			expect(t, http.StatusOK)
+		s.do(t, request{method: http.MethodGet, path: path, token: editor.token}).expect(t, http.StatusNotFound)
+		s.do(t, request{method: http.MethodDelete, path: path, token: editor.token}).expect(t, http.StatusNotFound)
+	})
+}
+
+func TestMovieETagTracksUserStatus(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	admin := s.registerWithRole(t, models.UserRoleAdmin)
+	user := s.register(t)
+
+	movie := s.createPublishedMovie(t, editor, admin, models.CreateMovieInput{
+		Title: uniqueTitle(t), ReleaseDate: *releaseDate(2012), Duration: 100,
+	})
+	path := fmt.Sprintf("/movies/%d", movie.ID)
+
+	res := s.do(t, request{method: http.MethodGet, path: path, token: user.token})
+	res.expect(t, http.StatusOK)
+	before := res.Header.Get("ETag")
+
+	s.do(t, request{method: http.MethodPost, path: "/users/me/watchlist", token: user.token,
+		body: models.AddSavedMovieInput{MovieID: movie.ID},
+	}).expect(t, http.StatusCreated)
+
+	// The cached copy lacks the watchlist entry, so it must not be revalidated
+	res = s.do(t, request{method: http.MethodGet, path: path, token: user.token, headers: map[string]string{"If-None-Match": before}})
+	res.expect(t, http.StatusOK)
+	var read models.Movie
+	res.decode(t, &read)
+	if read.UserStatus == nil || !read.UserStatus.InWatchlist {
+		t.Errorf("user status = %+v, want in watchlist", read.UserStatus)
+	}
+	if after := res.Header.Get("ETag"); after == before {
+		t.Errorf("ETag %q did not change with the user status", after)
+	}
+}
+
+func TestMovieVersionTracksGenreRename(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+
+	slug := models.Slugify(uniqueTitle(t))
+	res := s.do(t, request{method: http.MethodPost, path: "/genres", token: editor.token, body: models.CreateGenreInput{Name: "Noir", Slug: slug}})
+	res.expect(t, http.StatusCreated)
+	var genre models.Genre
+	res.decode(t, &genre)
+
+	movie := s.createMovie(t, editor.token, models.CreateMovieInput{
+		Title: uniqueTitle(t), ReleaseDate: *releaseDate(2013), Duration: 100, Genres: []string{slug},
+	})
+
+	name := "Neo-noir"
+	s.do(t, request{method: http.MethodPut, path: fmt.Sprintf("/genres/%d", genre.ID), token: editor.token,
+		body: models.UpdateGenreInput{Name: &name},
+	}).expect(t, http.StatusOK)
+
+	res = s.do(t, request{method: http.MethodGet, path: fmt.Sprintf("/movies/%d", movie.ID), token: editor.token})
+	res.expect(t, http.StatusOK)
+	var read models.Movie
+	res.decode(t, &read)
+	if read.Version != movie.Version+1 || len(read.Genres) != 1 || read.Genres[0].Name != name {
+		t.Errorf("movie after genre rename = version %d, genres %+v", read.Version, read.Genres)
+	}
+}
+
+func TestMovieRequestErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		sess := s.registerWithRole(t, models.UserRoleEditor)
+
+		s.do(t, request{method: http.MethodGet, path: "/movies/abc"}).expect(t, http.StatusBadRequest)
+		s.do(t, request{method: http.MethodGet, path: "/movies/999999999"}).expect(t, http.StatusNotFound)
+
+		res := s.do(t, request{method: http.MethodPost, path: "/movies", token: sess.token, body: models.CreateMovieInput{
+			Title: uniqueTitle(t), ReleaseDate: *releaseDate(2000), Duration: 90, Genres: []string{"no-such-genre"},
+		}})
+		res.expect(t, http.StatusBadRequest)
+		if res.Body.Message != "Unknown genre" {
+			t.Errorf("message = %q", res.Body.Message)
+		}
+
+		res = s.do(t, request{method: http.MethodPatch, path: "/movies/1", token: sess.token, body: map[string]string{}})
+		res.expect(t, http.StatusUnsupportedMediaType)
+
+		// Only editors write movies, including drafts they created
+		user := s.register(t)
+		movie := s.createMovie(t, sess.token, models.CreateMovieInput{Title: uniqueTitle(t), ReleaseDate: *releaseDate(2000), Duration: 90})
+		path := fmt.Sprintf("/movies/%d", movie.ID)
+		for _, req := range []request{
+			{method: http.MethodPost, path: "/movies", body: models.CreateMovieInput{Title: uniqueTitle(t), ReleaseDate: *releaseDate(2000), Duration: 90}},
+			{method: http.MethodPut, path: path, body: models.ReplaceMovieInput{}},
+			{method: http.MethodDelete, path: path},
+			{method: http.MethodPost, path: path + "/submit"},
+		} {
+			req.token = user.token
+			if res := s.do(t, req); res.StatusCode != http.StatusForbidden {
+				t.Errorf("%s %s as a user: status = %d, want 403", req.method, req.path, res.StatusCode)
+			}
+		}
+
+		res = s.do(t, request{method: http.MethodGet, path: "/movies?status=bogus"})
+		res.expect(t, http.StatusBadRequest)
+		var problems map[string]string
+		res.decode(t, &problems)
+		if problems["status"] == "" {
+			t.Errorf("validation errors = %v, want status", problems)
+		}
+	})
+}
+
+func TestMovieListPaginationAndSorting(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		admin := s.registerWithRole(t, models.UserRoleAdmin)
+		prefix := uniqueTitle(t)
+
+		durations := []int{95, 150, 80, 120, 110}
+		ids := make([]int64, len(durations))
+		for i, duration := range durations {
+			movie := s.createMovie(t, admin.token, models.CreateMovieInput{
+				Title:       fmt.Sprintf("%s %c", prefix, 'A'+i),
+				ReleaseDate: *releaseDate(2000 + i),
+				Duration:    duration,
+			})
+			ids[i] = movie.ID
+		}
+
+		titlesOf := func(page *handlers.PaginatedMovieResponse) []string {
+			titles := []string{}
+			for _, movie := range page.Movies {
+				titles = append(titles, movie.Title[len(prefix)+1:])
+			}
+			return titles
+		}
+
+		tests := []struct {
+			name       string
+			params     url.Values
+			want       []string
+			totalPages int
+		}{
+			{"first page by duration", url.Values{"sort_by": {"duration"}, "page_size": {"2"}}, []string{"C", "A"}, 3},
+			{"second page by duration", url.Values{"sort_by": {"duration"}, "page_size": {"2"}, "page": {"2"}}, []string{"E", "D"}, 3},
+			{"last page by duration", url.Values{"sort_by": {"duration"}, "page_size": {"2"}, "page": {"3"}}, []string{"B"}, 3},
+			{"past the last page", url.Values{"sort_by": {"duration"}, "page_size": {"2"}, "page": {"4"}}, []string{}, 3},
+			{"duration descending", url.Values{"sort_by": {"duration"}, "order": {"desc"}}, []string{"B", "D", "E", "A", "C"}, 1},
+			{"title descending", url.Values{"sort_by": {"title"}, "order": {"DESC"}, "page_size": {"3"}}, []string{"E", "D", "C"}, 2},
+			{"release date", url.Values{"sort_by": {"release_date"}}, []string{"A", "B", "C", "D", "E"}, 1},
+			{"default is newest first", url.Values{}, []string{"E", "D", "C", "B", "A"}, 1},
+			{"unknown sort key", url.Values{"sort_by": {"password_hash"}}, []string{"E", "D", "C", "B", "A"}, 1},
+			{"invalid page falls back", url.Values{"page": {"-1"}, "page_size": {"zero"}, "sort_by": {"id"}}, []string{"A", "B", "C", "D", "E"}, 1},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.params.Set("title", prefix)
+				page := s.listMovies(t, admin.token, tt.params)
+
+				if got := titlesOf(page); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("titles = %v, want %v", got, tt.want)
+				}
+				if page.TotalCount != len(durations) || page.TotalPages != tt.totalPages {
+					t.Errorf("total_count = %d, total_pages = %d, want %d and %d", page.TotalCount, page.TotalPages, len(durations), tt.totalPages)
+				}
+				if page.Movies == nil {
+					t.Error("movies is null, want an array")
+				}
+			})
+		}
+
+		// Anonymous readers only see published movies
+		if page := s.listMovies(t, "", url.Values{"title": {prefix}}); page.TotalCount != 0 {
+			t.Fatalf("anonymous list has %d drafts", page.TotalCount)
+		}
+
+		for _, action := range []string{"submit", "publish"} {
+			res := s.do(t, request{method: http.MethodPost, path: fmt.Sprintf("/movies/%d/%s", ids[0], action), token: admin.token})
+			res.expect(t, http.StatusOK)
+		}
+
+		page := s.listMovies(t, "", url.Values{"title": {prefix}})
+		if got := titlesOf(page); fmt.Sprint(got) != "[A]" {
+			t.Errorf("anonymous list = %v, want [A]", got)
+		}
+		s.do(t, request{method: http.MethodGet, path: fmt.Sprintf("/movies/%d", ids[0])}).expect(t, http.StatusOK)
+	})
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,37 @@
+package router_test
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestPersonWritesRequireEditor(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	user := s.register(t)
+
+	res := s.do(t, request{method: http.MethodPost, path: "/people", token: editor.token,
+		body: models.CreatePersonInput{Name: "Sergio Leone"},
+	})
+	res.expect(t, http.StatusCreated)
+	var person models.Person
+	res.decode(t, &person)
+
+	// Renames and deletes reach every movie the person is credited on
+	path := fmt.Sprintf("/people/%d", person.ID)
+	name := "Bob Robertson"
+	for _, req := range []request{
+		{method: http.MethodPost, path: "/people", body: models.CreatePersonInput{Name: "Ennio Morricone"}},
+		{method: http.MethodPut, path: path, body: models.UpdatePersonInput{Name: &name}},
+		{method: http.MethodDelete, path: path},
+	} {
+		req.token = user.token
+		if res := s.do(t, req); res.StatusCode != http.StatusForbidden {
+			t.Errorf("%s %s as a user: status = %d, want 403", req.method, req.path, res.StatusCode)
+		}
+	}
+
+	s.do(t, request{method: http.MethodGet, path: path}).expect(t, http.StatusOK)
+}
@@ -0,0 +1,82 @@
+package router_test
+
+import (
+	"context"
+	"database/sql"
+	"github.com/marchelhutagalung/go-service/internal/database"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/importer"
+	"github.com/marchelhutagalung/go-service/internal/migrate"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/migrations"
+	"os"
+	"sync"
+	"testing"
+)
+
+// testDatabaseURLEnv names the variable holding the connection URL of a
+// disposable Postgres database. Postgres tests are skipped when it is unset.
+// The database is migrated to the latest version; tests only add rows and
+// use unique emails and titles, so it can be reused between runs.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+var (
+	testDBOnce sync.Once
+	testDB     *database.PostgresDB
+	testDBErr  error
+)
+
+// openTestDB connects to and migrates the test database once per run
+func openTestDB(t *testing.T) *database.PostgresDB {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skip(testDatabaseURLEnv + " is not set")
+	}
+
+	testDBOnce.Do(func() {
+		db, err := sql.Open("postgres", url)
+		if err != nil {
+			testDBErr = err
+			return
+		}
+
+		migrator, err := migrate.New(db, migrations.FS)
+		if err != nil {
+			testDBErr = err
+			return
+		}
+
+		if _, err := migrator.Up(context.Background(), 0); err != nil {
+			testDBErr = err
+			return
+		}
+
+		testDB = &database.PostgresDB{DB: db}
+	})
+
+	if testDBErr != nil {
+		t.Fatalf("setting up test database: %v", testDBErr)
+	}
+
+	return testDB
+}
+
+// newPostgresServer serves the router on the Postgres repositories, with
+// every feature enabled. Tokens are kept in memory instead of Redis.
+func newPostgresServer(t *testing.T) *testServer {
+	db := openTestDB(t)
+
+	movieRepo := repository.NewMovieRepository(db)
+	savedMovieRepo := repository.NewSavedMovieRepository(db)
+
+	return newTestServer(t, repository.NewUserRepository(db), movieRepo, savedMovieRepo, serverOptions{
+		genres:      handlers.NewGenreHandler(repository.NewGenreRepository(db)),
+		people:      handlers.NewPersonHandler(repository.NewPersonRepository(db)),
+		reviews:     handlers.NewReviewHandler(repository.NewReviewRepository(db)),
+		savedMovies: handlers.NewSavedMovieHandler(savedMovieRepo),
+		movieLists:  handlers.NewMovieListHandler(repository.NewMovieListRepository(db)),
+		movieImport: handlers.NewMovieImportHandler(importer.NewImporter(movieRepo)),
+	})
+}
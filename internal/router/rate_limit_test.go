@@ -0,0 +1,115 @@
+package router_test
+
+import (
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/repository/memory"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	generous := config.RateLimit{Requests: 1000, Period: time.Minute}
+	s := newTestServer(t, memory.NewUserStore(), memory.NewMovieStore(), nil, serverOptions{
+		rateLimits: &config.RateLimitConfig{
+			APIKeys: []string{"known-key"},
+			// Long periods so no quota refills while bcrypt runs
+			Auth:   config.RateLimit{Requests: 3, Period: time.Hour},
+			Users:  generous,
+			Movies: config.RateLimit{Requests: 2, Period: time.Hour},
+			Genres: generous,
+			Lists:  generous,
+			People: generous,
+		},
+	})
+
+	// Uses one of the three /auth requests
+	sess := s.register(t)
+
+	t.Run("auth group", func(t *testing.T) {
+		login := request{method: http.MethodPost, path: "/auth/login", body: models.LoginInput{Email: sess.email, Password: "wrong"}}
+
+		for remaining := 1; remaining >= 0; remaining-- {
+			res := s.do(t, login)
+			res.expect(t, http.StatusUnauthorized)
+			if got := res.Header.Get("RateLimit-Remaining"); got != strconv.Itoa(remaining) {
+				t.Errorf("RateLimit-Remaining = %q, want %d", got, remaining)
+			}
+		}
+
+		res := s.do(t, login)
+		res.expect(t, http.StatusTooManyRequests)
+		if got := res.Header.Get("RateLimit-Policy"); got != "3;w=3600" {
+			t.Errorf("RateLimit-Policy = %q", got)
+		}
+		if got := res.Header.Get("RateLimit-Limit"); got != "3" {
+			t.Errorf("RateLimit-Limit = %q", got)
+		}
+		if got := res.Header.Get("RateLimit-Remaining"); got != "0" {
+			t.Errorf("RateLimit-Remaining = %q", got)
+		}
+		retryAfter, err := strconv.Atoi(res.Header.Get("Retry-After"))
+		if err != nil || retryAfter < 1 || retryAfter > 1200 {
+			t.Errorf("Retry-After = %q, want the seconds until one request refills", res.Header.Get("Retry-After"))
+		}
+
+		// Other groups count separately
+		s.do(t, request{method: http.MethodGet, path: "/users/me", token: sess.token}).expect(t, http.StatusOK)
+	})
+
+	t.Run("clients count separately", func(t *testing.T) {
+		list := request{method: http.MethodGet, path: "/movies"}
+		s.do(t, list).expect(t, http.StatusOK)
+		s.do(t, list).expect(t, http.StatusOK)
+		s.do(t, list).expect(t, http.StatusTooManyRequests)
+
+		// Forwarded headers from an untrusted peer are ignored
+		spoofed := list
+		spoofed.headers = map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Real-IP": "203.0.113.8"}
+		s.do(t, spoofed).expect(t, http.StatusTooManyRequests)
+		spoofed.headers = map[string]string{"X-Forwarded-For": "198.51.100.9"}
+		s.do(t, spoofed).expect(t, http.StatusTooManyRequests)
+
+		unknownKey := list
+		unknownKey.headers = map[string]string{"X-API-Key": "made-up"}
+		s.do(t, unknownKey).expect(t, http.StatusTooManyRequests)
+
+		signedIn := list
+		signedIn.token = sess.token
+		s.do(t, signedIn).expect(t, http.StatusOK)
+
+		knownKey := list
+		knownKey.headers = map[string]string{"X-API-Key": "known-key"}
+		res := s.do(t, knownKey)
+		res.expect(t, http.StatusOK)
+		if got := res.Header.Get("RateLimit-Remaining"); got != "1" {
+			t.Errorf("RateLimit-Remaining = %q, want 1", got)
+		}
+	})
+}
+
+func TestRateLimitTrustedProxy(t *testing.T) {
+	generous := config.RateLimit{Requests: 1000, Period: time.Minute}
+	s := newTestServer(t, memory.NewUserStore(), memory.NewMovieStore(), nil, serverOptions{
+		rateLimits: &config.RateLimitConfig{
+			Auth: generous, Users: generous, Genres: generous, Lists: generous, People: generous,
+			Movies: config.RateLimit{Requests: 1, Period: time.Hour},
+		},
+		trustedProxies: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+	})
+
+	// Behind a trusted proxy each forwarded client has its own quota
+	from := func(forwardedFor string) request {
+		return request{method: http.MethodGet, path: "/movies", headers: map[string]string{"X-Forwarded-For": forwardedFor}}
+	}
+	s.do(t, from("203.0.113.7")).expect(t, http.StatusOK)
+	s.do(t, from("203.0.113.7")).expect(t, http.StatusTooManyRequests)
+	s.do(t, from("198.51.100.9")).expect(t, http.StatusOK)
+
+	// The client is the nearest untrusted hop, not whatever it claims to be
+	s.do(t, from("192.0.2.1, 203.0.113.7")).expect(t, http.StatusTooManyRequests)
+	s.do(t, from("192.0.2.1, 203.0.113.7, 127.0.0.2")).expect(t, http.StatusTooManyRequests)
+}
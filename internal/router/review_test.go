@@ -0,0 +1,126 @@
+package router_test
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestReviewCRUD(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	admin := s.registerWithRole(t, models.UserRoleAdmin)
+	author := s.register(t)
+	other := s.register(t)
+
+	movie := s.createPublishedMovie(t, editor, admin, models.CreateMovieInput{
+		Title: uniqueTitle(t), ReleaseDate: *releaseDate(2016), Duration: 100,
+	})
+	reviews := fmt.Sprintf("/movies/%d/reviews", movie.ID)
+
+	s.do(t, request{method: http.MethodPost, path: reviews, body: models.CreateReviewInput{Rating: 7}}).
+		expect(t, http.StatusUnauthorized)
+	s.do(t, request{method: http.MethodPost, path: reviews, token: author.token, body: models.CreateReviewInput{Rating: 11}}).
+		expect(t, http.StatusBadRequest)
+
+	res := s.do(t, request{method: http.MethodPost, path: reviews, token: author.token,
+		body: models.CreateReviewInput{Rating: 6, Body: "Slow start"},
+	})
+	res.expect(t, http.StatusCreated)
+	var review models.Review
+	res.decode(t, &review)
+	if review.MovieID != movie.ID || review.UserID != author.user.ID || review.Rating != 6 {
+		t.Errorf("created review = %+v", review)
+	}
+	path := fmt.Sprintf("%s/%d", reviews, review.ID)
+
+	s.do(t, request{method: http.MethodPost, path: reviews, token: author.token, body: models.CreateReviewInput{Rating: 7}}).
+		expect(t, http.StatusConflict)
+	s.do(t, request{method: http.MethodPost, path: reviews, token: other.token, body: models.CreateReviewInput{Rating: 10}}).
+		expect(t, http.StatusCreated)
+
+	// Only the author may change or delete a review
+	rating := 8
+	s.do(t, request{method: http.MethodPut, path: path, token: other.token, body: models.UpdateReviewInput{Rating: &rating}}).
+		expect(t, http.StatusForbidden)
+	s.do(t, request{method: http.MethodDelete, path: path, token: other.token}).expect(t, http.StatusForbidden)
+
+	res = s.do(t, request{method: http.MethodPut, path: path, token: author.token, body: models.UpdateReviewInput{Rating: &rating}})
+	res.expect(t, http.StatusOK)
+	var updated models.Review
+	res.decode(t, &updated)
+	if updated.Rating != 8 || updated.Body != "Slow start" {
+		t.Errorf("updated review = %+v", updated)
+	}
+
+	// Anyone can read the reviews and the rating they add up to
+	res = s.do(t, request{method: http.MethodGet, path: reviews})
+	res.expect(t, http.StatusOK)
+	var page handlers.PaginatedReviewResponse
+	res.decode(t, &page)
+	if page.TotalCount != 2 || page.Reviews[1].ID != review.ID {
+		t.Errorf("reviews = %d, oldest %+v", page.TotalCount, page.Reviews)
+	}
+
+	res = s.do(t, request{method: http.MethodGet, path: fmt.Sprintf("/movies/%d", movie.ID)})
+	res.expect(t, http.StatusOK)
+	var read models.Movie
+	res.decode(t, &read)
+	if read.RatingCount != 2 || read.AverageRating != 9 {
+		t.Errorf("movie rating = %v from %d, want 9 from 2", read.AverageRating, read.RatingCount)
+	}
+
+	s.do(t, request{method: http.MethodDelete, path: path, token: author.token}).expect(t, http.StatusOK)
+	s.do(t, request{method: http.MethodDelete, path: path, token: author.token}).expect(t, http.StatusNotFound)
+}
+
+func TestReviewsOnlyOnPublishedMovies(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	user := s.register(t)
+
+	draft := s.createMovie(t, editor.token, models.CreateMovieInput{
+		Title: uniqueTitle(t), ReleaseDate: *releaseDate(2016), Duration: 100,
+	})
+	s.do(t, request{method: http.MethodPost, path: fmt.Sprintf("/movies/%d/reviews", draft.ID), token: user.token,
+		body: models.CreateReviewInput{Rating: 8, Body: "Seen it early"},
+	}).expect(t, http.StatusNotFound)
+}
+
+func TestReviewsKeepMovieVersion(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	admin := s.registerWithRole(t, models.UserRoleAdmin)
+	user := s.register(t)
+
+	movie := s.createPublishedMovie(t, editor, admin, models.CreateMovieInput{
+		Title: uniqueTitle(t), ReleaseDate: *releaseDate(2016), Duration: 100,
+	})
+	path := fmt.Sprintf("/movies/%d", movie.ID)
+
+	res := s.do(t, request{method: http.MethodGet, path: path})
+	res.expect(t, http.StatusOK)
+	before := res.Header.Get("ETag")
+
+	s.do(t, request{method: http.MethodPost, path: path + "/reviews", token: user.token,
+		body: models.CreateReviewInput{Rating: 9, Body: "Great"},
+	}).expect(t, http.StatusCreated)
+
+	// Readers see the new rating under a new tag
+	res = s.do(t, request{method: http.MethodGet, path: path, headers: map[string]string{"If-None-Match": before}})
+	res.expect(t, http.StatusOK)
+	var read models.Movie
+	res.decode(t, &read)
+	if read.RatingCount != 1 || read.AverageRating != 9 || read.Version != movie.Version {
+		t.Errorf("movie after review = version %d, rating %v from %d", read.Version, read.AverageRating, read.RatingCount)
+	}
+
+	// The editor's precondition from before the review still holds
+	description := "Revised"
+	s.do(t, request{method: http.MethodPatch, path: path, token: editor.token,
+		body:    map[string]interface{}{"description": description},
+		headers: map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": before},
+	}).expect(t, http.StatusOK)
+}
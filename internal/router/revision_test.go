@@ -0,0 +1,98 @@
+package router_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMovieRevisionsSurvivePurge(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		editor := s.registerWithRole(t, models.UserRoleEditor)
+
+		movie := s.createMovie(t, editor.token, models.CreateMovieInput{
+			Title: uniqueTitle(t), ReleaseDate: *releaseDate(2014), Duration: 100,
+		})
+		path := fmt.Sprintf("/movies/%d", movie.ID)
+		s.do(t, request{method: http.MethodDelete, path: path, token: editor.token}).expect(t, http.StatusOK)
+
+		if _, err := s.movies.PurgeDeleted(context.Background(), time.Now().Add(time.Minute)); err != nil {
+			t.Fatalf("purging trash: %v", err)
+		}
+		s.do(t, request{method: http.MethodGet, path: path, token: editor.token}).expect(t, http.StatusNotFound)
+
+		res := s.do(t, request{method: http.MethodGet, path: path + "/revisions", token: editor.token})
+		res.expect(t, http.StatusOK)
+		var page handlers.PaginatedRevisionResponse
+		res.decode(t, &page)
+		if page.TotalCount != 2 || page.Revisions[0].Action != models.RevisionActionDelete {
+			t.Errorf("revisions after purge = %d, newest %+v", page.TotalCount, page.Revisions[0])
+		}
+
+		res = s.do(t, request{method: http.MethodGet, path: path + "/revisions/1", token: editor.token})
+		res.expect(t, http.StatusOK)
+		var created models.MovieRevision
+		res.decode(t, &created)
+		if created.Snapshot == nil || created.Snapshot.Title != movie.Title {
+			t.Errorf("first revision snapshot = %+v", created.Snapshot)
+		}
+	})
+}
+
+func TestMovieRevert(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s *testServer) {
+		editor := s.registerWithRole(t, models.UserRoleEditor)
+		user := s.register(t)
+		title := uniqueTitle(t)
+
+		movie := s.createMovie(t, editor.token, models.CreateMovieInput{
+			Title: title, Description: "Original", ReleaseDate: *releaseDate(2018), Duration: 100,
+		})
+		path := fmt.Sprintf("/movies/%d", movie.ID)
+
+		s.do(t, request{method: http.MethodPatch, path: path, token: editor.token,
+			body:    map[string]interface{}{"title": title + " (recut)", "duration": 110},
+			headers: map[string]string{"Content-Type": "application/merge-patch+json"},
+		}).expect(t, http.StatusOK)
+
+		s.do(t, request{method: http.MethodGet, path: path + "/revisions", token: user.token}).expect(t, http.StatusForbidden)
+
+		res := s.do(t, request{method: http.MethodGet, path: path + "/revisions", token: editor.token})
+		res.expect(t, http.StatusOK)
+		var page handlers.PaginatedRevisionResponse
+		res.decode(t, &page)
+		if page.TotalCount != 2 {
+			t.Fatalf("revisions = %d, want 2", page.TotalCount)
+		}
+		update := page.Revisions[0]
+		if update.Action != models.RevisionActionUpdate || update.Changes["title"].New == nil || update.Changes["duration"].New == nil {
+			t.Errorf("update revision = %+v", update)
+		}
+		if _, ok := update.Changes["description"]; ok {
+			t.Errorf("update revision lists unchanged description: %+v", update.Changes)
+		}
+
+		res = s.do(t, request{method: http.MethodPost, path: path + "/revisions/1/revert", token: editor.token})
+		res.expect(t, http.StatusOK)
+		var reverted models.Movie
+		res.decode(t, &reverted)
+		if reverted.Title != title || reverted.Duration != 100 || reverted.Description != "Original" || reverted.Version != 3 {
+			t.Errorf("reverted movie = %+v", reverted)
+		}
+
+		res = s.do(t, request{method: http.MethodGet, path: path + "/revisions/3", token: editor.token})
+		res.expect(t, http.StatusOK)
+		var revert models.MovieRevision
+		res.decode(t, &revert)
+		if revert.Action != models.RevisionActionRevert || revert.Snapshot == nil || revert.Snapshot.Title != title {
+			t.Errorf("revert revision = %+v", revert)
+		}
+
+		s.do(t, request{method: http.MethodPost, path: path + "/revisions/9/revert", token: editor.token}).expect(t, http.StatusNotFound)
+		s.do(t, request{method: http.MethodGet, path: path + "/revisions/0", token: editor.token}).expect(t, http.StatusBadRequest)
+	})
+}
@@ -1,9 +1,12 @@
 package router
 
 import (
+	"github.com/marchelhutagalung/go-service/internal/config"
 	"github.com/marchelhutagalung/go-service/internal/handlers"
 	customMiddleware "github.com/marchelhutagalung/go-service/internal/middleware"
+	"github.com/marchelhutagalung/go-service/internal/models"
 	"github.com/marchelhutagalung/go-service/internal/response"
+	"github.com/marchelhutagalung/go-service/internal/storage"
 	"net/http"
 	"time"
 
@@ -12,32 +15,55 @@ import (
 	"github.com/go-chi/cors"
 )
 
+// SetupRouter mounts the API. The genre, person, review, saved movie, movie
+// list and import handlers may be nil when the storage backend does not
+// support them, in which case their routes are left out. A nil rateLimiter
+// leaves requests unlimited and a nil idempotency ignores Idempotency-Key.
 func SetupRouter(
+	serverConfig *config.ServerConfig,
 	authHandler *handlers.AuthHandler,
 	userHandler *handlers.UserHandler,
 	movieHandler *handlers.MovieHandler,
+	genreHandler *handlers.GenreHandler,
+	personHandler *handlers.PersonHandler,
+	reviewHandler *handlers.ReviewHandler,
+	savedMovieHandler *handlers.SavedMovieHandler,
+	movieListHandler *handlers.MovieListHandler,
+	movieImportHandler *handlers.MovieImportHandler,
+	movieImageHandler *handlers.MovieImageHandler,
+	healthHandler *handlers.HealthHandler,
+	mediaHandler http.Handler,
 	authMiddleware *customMiddleware.Middleware,
+	rateLimiter *customMiddleware.RateLimiter,
+	idempotency *customMiddleware.Idempotency,
 ) *chi.Mux {
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(customMiddleware.RealIP(serverConfig.TrustedProxies))
+	r.Use(customMiddleware.Tracing)
 	r.Use(customMiddleware.RequestLogger)
+	r.Use(customMiddleware.Metrics(r))
 	r.Use(customMiddleware.Recovery)
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(customMiddleware.Timeout(r, serverConfig.RequestTimeout, map[string]time.Duration{
+		"/api/v1/movies/import": serverConfig.BulkRequestTimeout,
+		"/api/v1/movies/export": serverConfig.BulkRequestTimeout,
+	}))
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", "If-None-Match", "traceparent", "tracestate", customMiddleware.APIKeyHeader, customMiddleware.IdempotencyKeyHeader},
+		ExposedHeaders:   []string{"Link", "ETag", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", customMiddleware.IdempotentReplayedHeader},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/register", authHandler.Register)
+			r.Use(rateLimiter.Limit("auth"))
+
+			r.With(idempotency.Redact("token").Handle).Post("/register", authHandler.Register)
 			r.Post("/login", authHandler.Login)
 
 			r.Group(func(r chi.Router) {
@@ -47,26 +73,156 @@ func SetupRouter(
 		})
 
 		r.Route("/users", func(r chi.Router) {
-			r.Use(authMiddleware.RequireAuth)
-			r.Get("/me", userHandler.GetCurrentUser)
+			r.Use(rateLimiter.Limit("users"))
+
+			if movieListHandler != nil {
+				r.Get("/{userID}/lists", movieListHandler.ListUserLists)
+			}
+
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.RequireAuth)
+				r.Get("/me", userHandler.GetCurrentUser)
+
+				if savedMovieHandler != nil {
+					r.Get("/me/watchlist", savedMovieHandler.ListWatchlist)
+					r.Post("/me/watchlist", savedMovieHandler.AddToWatchlist)
+					r.Delete("/me/watchlist/{movieID}", savedMovieHandler.RemoveFromWatchlist)
+					r.Put("/me/watchlist/{movieID}/watched", savedMovieHandler.MarkWatchlistWatched)
+					r.Delete("/me/watchlist/{movieID}/watched", savedMovieHandler.UnmarkWatchlistWatched)
+
+					r.Get("/me/favorites", savedMovieHandler.ListFavorites)
+					r.Post("/me/favorites", savedMovieHandler.AddToFavorites)
+					r.Delete("/me/favorites/{movieID}", savedMovieHandler.RemoveFromFavorites)
+					r.Put("/me/favorites/{movieID}/watched", savedMovieHandler.MarkFavoriteWatched)
+					r.Delete("/me/favorites/{movieID}/watched", savedMovieHandler.UnmarkFavoriteWatched)
+				}
+
+				if movieListHandler != nil {
+					r.Get("/me/lists", movieListHandler.ListMyLists)
+				}
+			})
 		})
 
 		// Movie routes
 		r.Route("/movies", func(r chi.Router) {
-			r.Get("/{id}", movieHandler.GetMovie)
-			r.Get("/", movieHandler.ListMovies)
+			r.Use(rateLimiter.Limit("movies"))
+
+			r.With(authMiddleware.OptionalAuth).Get("/{id}", movieHandler.GetMovie)
+			r.With(authMiddleware.OptionalAuth).Get("/", movieHandler.ListMovies)
+			r.With(authMiddleware.OptionalAuth).Get("/by-external/{source}/{externalID}", movieHandler.GetMovieByExternalID)
+			if reviewHandler != nil {
+				r.Get("/{id}/reviews", reviewHandler.ListReviews)
+			}
 			r.Group(func(r chi.Router) {
 				r.Use(authMiddleware.RequireAuth)
-				r.Post("/", movieHandler.CreateMovie)
-				r.Put("/{id}", movieHandler.UpdateMovie)
-				r.Delete("/{id}", movieHandler.DeleteMovie)
+
+				r.Group(func(r chi.Router) {
+					r.Use(customMiddleware.RequireRole(models.UserRoleAdmin))
+					r.Get("/trash", movieHandler.ListTrash)
+					r.Post("/{id}/restore", movieHandler.RestoreMovie)
+					r.Get("/duplicates", movieHandler.ListDuplicates)
+					r.Post("/{id}/merge", movieHandler.MergeMovie)
+					r.Post("/{id}/reject", movieHandler.RejectMovie)
+					r.Post("/{id}/publish", movieHandler.PublishMovie)
+					r.Post("/{id}/archive", movieHandler.ArchiveMovie)
+				})
+
+				r.Group(func(r chi.Router) {
+					r.Use(customMiddleware.RequireRole(models.UserRoleEditor))
+					r.With(idempotency.Handle).Post("/", movieHandler.CreateMovie)
+					r.Put("/{id}", movieHandler.UpdateMovie)
+					r.Patch("/{id}", movieHandler.PatchMovie)
+					r.Put("/by-external/{source}/{externalID}", movieHandler.UpsertMovieByExternalID)
+					r.Delete("/{id}", movieHandler.DeleteMovie)
+					r.Post("/{id}/poster", movieImageHandler.UploadPoster)
+					r.Delete("/{id}/poster", movieImageHandler.DeletePoster)
+					r.Post("/{id}/backdrop", movieImageHandler.UploadBackdrop)
+					r.Delete("/{id}/backdrop", movieImageHandler.DeleteBackdrop)
+					if movieImportHandler != nil {
+						r.Post("/import", movieImportHandler.ImportMovies)
+					}
+					r.Get("/export", movieHandler.ExportMovies)
+					r.Get("/{id}/revisions", movieHandler.ListRevisions)
+					r.Get("/{id}/revisions/{rev}", movieHandler.GetRevision)
+					r.Post("/{id}/revisions/{rev}/revert", movieHandler.RevertRevision)
+					r.Post("/{id}/submit", movieHandler.SubmitMovie)
+				})
+
+				if reviewHandler != nil {
+					r.Post("/{id}/reviews", reviewHandler.CreateReview)
+					r.Put("/{id}/reviews/{reviewID}", reviewHandler.UpdateReview)
+					r.Delete("/{id}/reviews/{reviewID}", reviewHandler.DeleteReview)
+				}
 			})
 		})
+
+		// Genre routes
+		if genreHandler != nil {
+			r.Route("/genres", func(r chi.Router) {
+				r.Use(rateLimiter.Limit("genres"))
+
+				r.Get("/{id}", genreHandler.GetGenre)
+				r.Get("/", genreHandler.ListGenres)
+				r.Group(func(r chi.Router) {
+					r.Use(authMiddleware.RequireAuth)
+					r.Use(customMiddleware.RequireRole(models.UserRoleEditor))
+					r.Post("/", genreHandler.CreateGenre)
+					r.Put("/{id}", genreHandler.UpdateGenre)
+					r.Delete("/{id}", genreHandler.DeleteGenre)
+				})
+			})
+		}
+
+		// User-curated list routes
+		if movieListHandler != nil {
+			r.Route("/lists", func(r chi.Router) {
+				r.Use(rateLimiter.Limit("lists"))
+
+				r.Get("/popular", movieListHandler.ListPopular)
+				r.With(authMiddleware.OptionalAuth).Get("/{id}", movieListHandler.GetList)
+				r.Group(func(r chi.Router) {
+					r.Use(authMiddleware.RequireAuth)
+					r.Post("/", movieListHandler.CreateList)
+					r.Put("/{id}", movieListHandler.UpdateList)
+					r.Delete("/{id}", movieListHandler.DeleteList)
+
+					r.Post("/{id}/items", movieListHandler.AddItem)
+					r.Put("/{id}/items/order", movieListHandler.ReorderItems)
+					r.Put("/{id}/items/{movieID}", movieListHandler.UpdateItem)
+					r.Delete("/{id}/items/{movieID}", movieListHandler.RemoveItem)
+				})
+			})
+		}
+
+		// People routes
+		if personHandler != nil {
+			r.Route("/people", func(r chi.Router) {
+				r.Use(rateLimiter.Limit("people"))
+
+				r.Get("/{id}", personHandler.GetPerson)
+				r.Get("/{id}/filmography", personHandler.GetFilmography)
+				r.Get("/", personHandler.ListPeople)
+				r.Group(func(r chi.Router) {
+					r.Use(authMiddleware.RequireAuth)
+					r.Use(customMiddleware.RequireRole(models.UserRoleEditor))
+					r.Post("/", personHandler.CreatePerson)
+					r.Put("/{id}", personHandler.UpdatePerson)
+					r.Delete("/{id}", personHandler.DeletePerson)
+				})
+			})
+		}
 	})
 
+	// Uploaded files, when they are kept on the local filesystem
+	if mediaHandler != nil {
+		r.Handle(storage.LocalMediaPath+"/*", http.StripPrefix(storage.LocalMediaPath, mediaHandler))
+	}
+
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		response.SuccessResponse(w, http.StatusOK, "Service is healthy", nil)
 	})
+	r.Get("/livez", healthHandler.Livez)
+	r.Get("/readyz", healthHandler.Readyz)
 
 	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
 		response.ErrorResponse(w, http.StatusNotFound, "Resource not found")
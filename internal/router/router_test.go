@@ -0,0 +1,300 @@
+package router_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/auth"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/health"
+	"github.com/marchelhutagalung/go-service/internal/idempotency"
+	"github.com/marchelhutagalung/go-service/internal/middleware"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"github.com/marchelhutagalung/go-service/internal/ratelimit"
+	"github.com/marchelhutagalung/go-service/internal/repository"
+	"github.com/marchelhutagalung/go-service/internal/repository/memory"
+	"github.com/marchelhutagalung/go-service/internal/router"
+	"github.com/marchelhutagalung/go-service/internal/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer is the API router served by httptest on top of a set of stores
+type testServer struct {
+	*httptest.Server
+	users   repository.UserStore
+	movies  repository.MovieStore
+	checker *health.Checker
+}
+
+// serverOptions are the handlers of features only some backends support, the
+// rate limits, generous enough for tests by default, and the trusted proxies
+type serverOptions struct {
+	rateLimits     *config.RateLimitConfig
+	trustedProxies []netip.Prefix
+
+	genres      *handlers.GenreHandler
+	people      *handlers.PersonHandler
+	reviews     *handlers.ReviewHandler
+	savedMovies *handlers.SavedMovieHandler
+	movieLists  *handlers.MovieListHandler
+	movieImport *handlers.MovieImportHandler
+}
+
+// newTestServer wires the stores into the real router the way the serve
+// command does and serves it until the test ends
+func newTestServer(t *testing.T, users repository.UserStore, movies repository.MovieStore, savedMovies *repository.SavedMovieRepository, opts serverOptions) *testServer {
+	t.Helper()
+
+	blobStore, err := storage.NewLocalStore(t.TempDir(), storage.LocalMediaPath)
+	if err != nil {
+		t.Fatalf("creating blob store: %v", err)
+	}
+
+	checker := health.NewChecker(100 * time.Millisecond)
+	jwtService := auth.NewJWTService(&config.JWTConfig{Secret: "test-secret", Expiration: time.Hour}, memory.NewTokenStore())
+	authMiddleware := middleware.NewMiddleware(jwtService)
+
+	rateLimits := opts.rateLimits
+	if rateLimits == nil {
+		generous := config.RateLimit{Requests: 1000, Period: time.Minute}
+		rateLimits = &config.RateLimitConfig{Auth: generous, Users: generous, Movies: generous, Genres: generous, Lists: generous, People: generous}
+	}
+
+	r := router.SetupRouter(
+		&config.ServerConfig{RequestTimeout: time.Minute, BulkRequestTimeout: time.Hour, TrustedProxies: opts.trustedProxies},
+		handlers.NewAuthHandler(users, jwtService),
+		handlers.NewUserHandler(users),
+		handlers.NewMovieHandler(movies, savedMovies, &config.MoviesConfig{}),
+		opts.genres,
+		opts.people,
+		opts.reviews,
+		opts.savedMovies,
+		opts.movieLists,
+		opts.movieImport,
+		handlers.NewMovieImageHandler(movies, blobStore, &config.ImageConfig{MaxUploadSize: 1 << 20}),
+		handlers.NewHealthHandler(checker),
+		blobStore,
+		authMiddleware,
+		middleware.NewRateLimiter(ratelimit.NewMemoryLimiter(), rateLimits, authMiddleware),
+		middleware.NewIdempotency(idempotency.NewMemoryStore(), &config.IdempotencyConfig{
+			TTL: time.Hour, LockTimeout: time.Minute, WaitTimeout: 5 * time.Second, MaxBodySize: 64 << 10,
+		}),
+	)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	return &testServer{Server: server, users: users, movies: movies, checker: checker}
+}
+
+// newMemoryServer serves the router on the in-memory stores
+func newMemoryServer(t *testing.T) *testServer {
+	return newTestServer(t, memory.NewUserStore(), memory.NewMovieStore(), nil, serverOptions{})
+}
+
+// forEachBackend runs fn against a fresh server on every storage backend.
+// The Postgres run is skipped unless TEST_DATABASE_URL is set.
+func forEachBackend(t *testing.T, fn func(t *testing.T, s *testServer)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, newMemoryServer(t))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		fn(t, newPostgresServer(t))
+	})
+}
+
+// envelope is the standard response body with the data left undecoded
+type envelope struct {
+	Status  string          `json:"status"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// apiResponse is a response read in full
+type apiResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       envelope
+}
+
+// request describes an API call relative to /api/v1. The body is sent as
+// JSON, raw as is with the Content-Type given in headers.
+type request struct {
+	method  string
+	path    string
+	token   string
+	body    interface{}
+	raw     string
+	headers map[string]string
+}
+
+// send sends the request and returns the response for the caller to read
+// and close
+func (s *testServer) send(t *testing.T, req request) *http.Response {
+	t.Helper()
+
+	var body io.Reader
+	if req.body != nil {
+		data, err := json.Marshal(req.body)
+		if err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+		body = bytes.NewReader(data)
+	} else if req.raw != "" {
+		body = strings.NewReader(req.raw)
+	}
+
+	path := req.path
+	if !strings.HasPrefix(path, "/health") && path != "/livez" && path != "/readyz" {
+		path = "/api/v1" + path
+	}
+
+	httpReq, err := http.NewRequest(req.method, s.URL+path, body)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	if req.body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+req.token)
+	}
+	for name, value := range req.headers {
+		httpReq.Header.Set(name, value)
+	}
+
+	res, err := s.Client().Do(httpReq)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.method, req.path, err)
+	}
+
+	return res
+}
+
+// do sends the request and decodes the response envelope, failing the test
+// if the body is not one
+func (s *testServer) do(t *testing.T, req request) *apiResponse {
+	t.Helper()
+
+	res := s.send(t, req)
+	defer res.Body.Close()
+
+	result := &apiResponse{StatusCode: res.StatusCode, Header: res.Header}
+	if res.StatusCode == http.StatusNotModified {
+		return result
+	}
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("%s %s: Content-Type = %q, want application/json", req.method, req.path, contentType)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&result.Body); err != nil {
+		t.Fatalf("%s %s: decoding envelope: %v", req.method, req.path, err)
+	}
+
+	return result
+}
+
+// expect fails the test unless the response has the given status code and
+// a well-formed envelope for it
+func (r *apiResponse) expect(t *testing.T, code int) {
+	t.Helper()
+
+	if r.StatusCode != code {
+		t.Fatalf("status = %d, want %d (message %q)", r.StatusCode, code, r.Body.Message)
+	}
+
+	wantStatus := "success"
+	if code >= 400 {
+		wantStatus = "error"
+	}
+	if r.Body.Status != wantStatus {
+		t.Errorf("envelope status = %q, want %q", r.Body.Status, wantStatus)
+	}
+	if r.Body.Code != code {
+		t.Errorf("envelope code = %d, want %d", r.Body.Code, code)
+	}
+	if r.Body.Message == "" {
+		t.Error("envelope message is empty")
+	}
+}
+
+// decode unmarshals the envelope data into v
+func (r *apiResponse) decode(t *testing.T, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(r.Body.Data, v); err != nil {
+		t.Fatalf("decoding data %s: %v", r.Body.Data, err)
+	}
+}
+
+// session is a registered user and their current token
+type session struct {
+	user     models.UserResponse
+	email    string
+	password string
+	token    string
+}
+
+var emailCounter atomic.Int64
+
+// uniqueEmail returns an address no other test uses, so tests can share a
+// database
+func uniqueEmail() string {
+	return fmt.Sprintf("user-%d-%d@example.com", time.Now().UnixNano(), emailCounter.Add(1))
+}
+
+// register creates a user through the API
+func (s *testServer) register(t *testing.T) *session {
+	t.Helper()
+
+	sess := &session{email: uniqueEmail(), password: "correct horse battery"}
+	res := s.do(t, request{method: http.MethodPost, path: "/auth/register", body: models.CreateUserInput{
+		Email: sess.email, Password: sess.password, FirstName: "Test", LastName: "User",
+	}})
+	res.expect(t, http.StatusCreated)
+
+	var data handlers.RegisterResponse
+	res.decode(t, &data)
+	sess.user, sess.token = *data.User, data.Token
+
+	return sess
+}
+
+// login replaces the session's token with a fresh one
+func (s *testServer) login(t *testing.T, sess *session) {
+	t.Helper()
+
+	res := s.do(t, request{method: http.MethodPost, path: "/auth/login", body: models.LoginInput{
+		Email: sess.email, Password: sess.password,
+	}})
+	res.expect(t, http.StatusOK)
+
+	var data handlers.LoginResponse
+	res.decode(t, &data)
+	sess.user, sess.token = *data.User, data.Token
+}
+
+// registerWithRole creates a user, gives them role directly in the store
+// and logs them in again so their token carries it
+func (s *testServer) registerWithRole(t *testing.T, role string) *session {
+	t.Helper()
+
+	sess := s.register(t)
+	if _, err := s.users.SetRole(context.Background(), sess.user.ID, role); err != nil {
+		t.Fatalf("setting role: %v", err)
+	}
+	s.login(t, sess)
+
+	return sess
+}
@@ -0,0 +1,91 @@
+package router_test
+
+import (
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/handlers"
+	"github.com/marchelhutagalung/go-service/internal/models"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSavedMovies(t *testing.T) {
+	s := newPostgresServer(t)
+	editor := s.registerWithRole(t, models.UserRoleEditor)
+	admin := s.registerWithRole(t, models.UserRoleAdmin)
+	user := s.register(t)
+
+	movie := s.createPublishedMovie(t, editor, admin, models.CreateMovieInput{
+		Title: uniqueTitle(t), ReleaseDate: *releaseDate(2020), Duration: 100,
+	})
+	draft := s.createMovie(t, editor.token, models.CreateMovieInput{
+		Title: uniqueTitle(t), ReleaseDate: *releaseDate(2020), Duration: 100,
+	})
+
+	list := func(t *testing.T, path string) *handlers.PaginatedSavedMovieResponse {
+		t.Helper()
+
+		res := s.do(t, request{method: http.MethodGet, path: path, token: user.token})
+		res.expect(t, http.StatusOK)
+		var page handlers.PaginatedSavedMovieResponse
+		res.decode(t, &page)
+		return &page
+	}
+
+	for _, kind := range []string{"watchlist", "favorites"} {
+		t.Run(kind, func(t *testing.T) {
+			saved := "/users/me/" + kind
+			path := fmt.Sprintf("%s/%d", saved, movie.ID)
+
+			s.do(t, request{method: http.MethodGet, path: saved}).expect(t, http.StatusUnauthorized)
+			s.do(t, request{method: http.MethodPost, path: saved, token: user.token, body: models.AddSavedMovieInput{}}).
+				expect(t, http.StatusBadRequest)
+			s.do(t, request{method: http.MethodPost, path: saved, token: user.token, body: models.AddSavedMovieInput{MovieID: draft.ID}}).
+				expect(t, http.StatusNotFound)
+
+			res := s.do(t, request{method: http.MethodPost, path: saved, token: user.token, body: models.AddSavedMovieInput{MovieID: movie.ID}})
+			res.expect(t, http.StatusCreated)
+			var item models.SavedMovie
+			res.decode(t, &item)
+			if item.Movie == nil || item.Movie.ID != movie.ID || item.WatchedAt != nil {
+				t.Errorf("saved movie = %+v", item)
+			}
+			s.do(t, request{method: http.MethodPost, path: saved, token: user.token, body: models.AddSavedMovieInput{MovieID: movie.ID}}).
+				expect(t, http.StatusConflict)
+
+			if page := list(t, saved); page.TotalCount != 1 || page.Items[0].Movie.ID != movie.ID {
+				t.Errorf("%s = %+v", kind, page.Items)
+			}
+			if page := list(t, saved+"?watched=true"); page.TotalCount != 0 {
+				t.Errorf("watched %s = %d movies, want 0", kind, page.TotalCount)
+			}
+
+			watchedAt := time.Date(2021, time.March, 1, 20, 0, 0, 0, time.UTC)
+			res = s.do(t, request{method: http.MethodPut, path: path + "/watched", token: user.token,
+				body: models.MarkWatchedInput{WatchedAt: &watchedAt},
+			})
+			res.expect(t, http.StatusOK)
+			res.decode(t, &item)
+			if item.WatchedAt == nil || !item.WatchedAt.Equal(watchedAt) {
+				t.Errorf("watched at = %v, want %v", item.WatchedAt, watchedAt)
+			}
+			if page := list(t, saved+"?watched=true"); page.TotalCount != 1 {
+				t.Errorf("watched %s = %d movies, want 1", kind, page.TotalCount)
+			}
+
+			res = s.do(t, request{method: http.MethodDelete, path: path + "/watched", token: user.token})
+			res.expect(t, http.StatusOK)
+			res.decode(t, &item)
+			if item.WatchedAt != nil {
+				t.Errorf("watched at after unmarking = %v", item.WatchedAt)
+			}
+
+			s.do(t, request{method: http.MethodDelete, path: path, token: user.token}).expect(t, http.StatusOK)
+			s.do(t, request{method: http.MethodDelete, path: path, token: user.token}).expect(t, http.StatusNotFound)
+			s.do(t, request{method: http.MethodPut, path: path + "/watched", token: user.token}).expect(t, http.StatusNotFound)
+			if page := list(t, saved); page.TotalCount != 0 {
+				t.Errorf("%s after removal = %+v", kind, page.Items)
+			}
+		})
+	}
+}
@@ -15,9 +15,10 @@ import (
 )
 
 type Server struct {
-	server *http.Server
-	config *config.ServerConfig
-	router chi.Router
+	server     *http.Server
+	config     *config.ServerConfig
+	router     chi.Router
+	onShutdown []func()
 }
 
 func NewServer(config *config.ServerConfig, router chi.Router) *Server {
@@ -27,6 +28,12 @@ func NewServer(config *config.ServerConfig, router chi.Router) *Server {
 	}
 }
 
+// OnShutdown registers fn to be called as soon as a shutdown signal arrives,
+// before the drain delay and before connections are closed
+func (s *Server) OnShutdown(fn func()) {
+	s.onShutdown = append(s.onShutdown, fn)
+}
+
 func (s *Server) Start() error {
 	addr := fmt.Sprintf(":%s", s.config.Port)
 
@@ -52,6 +59,17 @@ func (s *Server) Start() error {
 	case <-shutdown:
 		logger.Info("Server shutdown initiated")
 
+		for _, fn := range s.onShutdown {
+			fn()
+		}
+
+		// Keep serving while load balancers see the failing readiness probe
+		// and stop sending new requests
+		if s.config.ShutdownDelay > 0 {
+			logger.Info("Draining traffic", logger.Field("delay", s.config.ShutdownDelay.String()))
+			time.Sleep(s.config.ShutdownDelay)
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
@@ -0,0 +1,40 @@
+// Package storage holds the blob stores that uploaded files are kept in.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"io"
+)
+
+// LocalMediaPath is where the router serves a local store from when no
+// public URL is configured
+const LocalMediaPath = "/media"
+
+// BlobStore stores opaque files under slash-separated keys and knows the
+// public URL each key is served from
+type BlobStore interface {
+	// Put writes size bytes from r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete removes the blob under key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of the blob under key
+	URL(key string) string
+}
+
+// NewBlobStore creates the blob store selected by the configuration
+func NewBlobStore(ctx context.Context, cfg *config.BlobStoreConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "local":
+		publicURL := cfg.PublicURL
+		if publicURL == "" {
+			publicURL = LocalMediaPath
+		}
+		return NewLocalStore(cfg.LocalDir, publicURL)
+	case "s3":
+		return NewS3Store(ctx, cfg)
+	}
+
+	return nil, fmt.Errorf("unknown blob store driver %q", cfg.Driver)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/marchelhutagalung/go-service/internal/config"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store keeps blobs in a bucket of an S3-compatible service such as MinIO
+type S3Store struct {
+	client  *minio.Client
+	bucket  string
+	baseURL string
+}
+
+// NewS3Store connects to the configured endpoint and creates the bucket if
+// it does not exist yet
+func NewS3Store(ctx context.Context, cfg *config.BlobStoreConfig) (*S3Store, error) {
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("checking S3 bucket: %w", err)
+	}
+	if !exists {
+		err := client.MakeBucket(ctx, cfg.S3Bucket, minio.MakeBucketOptions{Region: cfg.S3Region})
+		if err != nil {
+			return nil, fmt.Errorf("creating S3 bucket: %w", err)
+		}
+	}
+
+	// Without a public URL, blobs are addressed path-style on the endpoint
+	baseURL := cfg.PublicURL
+	if baseURL == "" {
+		scheme := "http"
+		if cfg.S3UseSSL {
+			scheme = "https"
+		}
+		baseURL = fmt.Sprintf("%s://%s/%s", scheme, cfg.S3Endpoint, cfg.S3Bucket)
+	}
+
+	return &S3Store{
+		client:  client,
+		bucket:  cfg.S3Bucket,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	return err
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+	var resp minio.ErrorResponse
+	if errors.As(err, &resp) && resp.Code == "NoSuchKey" {
+		return nil
+	}
+	return err
+}
+
+func (s *S3Store) URL(key string) string {
+	return s.baseURL + "/" + key
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS movies;
+DROP TABLE IF EXISTS users;
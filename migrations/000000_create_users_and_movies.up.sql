@@ -0,0 +1,25 @@
+-- Base tables that used to be created by hand. IF NOT EXISTS makes this a
+-- no-op on databases that already have them. The legacy genre and director
+-- columns are converted and dropped by later migrations.
+CREATE TABLE IF NOT EXISTS users (
+    id            BIGSERIAL PRIMARY KEY,
+    email         VARCHAR(255) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    first_name    VARCHAR(100) NOT NULL DEFAULT '',
+    last_name     VARCHAR(100) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS movies (
+    id           BIGSERIAL PRIMARY KEY,
+    title        VARCHAR(255)  NOT NULL,
+    description  TEXT          NOT NULL DEFAULT '',
+    release_date DATE          NOT NULL,
+    rating       NUMERIC(3, 1) NOT NULL DEFAULT 0,
+    duration     INT           NOT NULL DEFAULT 0,
+    genre        VARCHAR(255)  NOT NULL DEFAULT '',
+    director     VARCHAR(255)  NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,14 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS genre VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE movies m
+SET genre = agg.names
+FROM (
+    SELECT mg.movie_id, string_agg(g.name, ', ' ORDER BY g.name) AS names
+    FROM movie_genres mg
+    JOIN genres g ON g.id = mg.genre_id
+    GROUP BY mg.movie_id
+) agg
+WHERE agg.movie_id = m.id;
+
+DROP TABLE IF EXISTS movie_genres;
+DROP TABLE IF EXISTS genres;
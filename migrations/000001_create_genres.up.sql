@@ -0,0 +1,58 @@
+CREATE TABLE IF NOT EXISTS genres (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    slug       VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS movie_genres (
+    movie_id BIGINT NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+    genre_id BIGINT NOT NULL REFERENCES genres (id) ON DELETE CASCADE,
+    PRIMARY KEY (movie_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_genres_genre_id ON movie_genres (genre_id);
+
+-- Split the legacy free-text movies.genre column ("Drama, Sci-Fi", "Action/Comedy")
+-- into individual genres. Slugs follow models.Slugify: lower-case, runs of
+-- anything other than a-z/0-9 collapsed to a single dash.
+--
+-- Databases that ran this by hand before schema_migrations existed no longer
+-- have the column, so the backfill only runs while it is there.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'movies' AND column_name = 'genre'
+    ) THEN
+        CREATE TEMPORARY TABLE legacy_movie_genres AS
+        SELECT movie_id, name, slug
+        FROM (
+            SELECT m.id AS movie_id,
+                   btrim(part) AS name,
+                   btrim(regexp_replace(lower(btrim(part)), '[^a-z0-9]+', '-', 'g'), '-') AS slug
+            FROM movies m,
+                 regexp_split_to_table(m.genre, '[,/|;&]') AS part
+            WHERE m.genre IS NOT NULL
+        ) parts
+        WHERE slug <> '';
+
+        INSERT INTO genres (name, slug)
+        SELECT DISTINCT ON (slug) name, slug
+        FROM legacy_movie_genres
+        ORDER BY slug, name
+        ON CONFLICT (slug) DO NOTHING;
+
+        INSERT INTO movie_genres (movie_id, genre_id)
+        SELECT DISTINCT l.movie_id, g.id
+        FROM legacy_movie_genres l
+        JOIN genres g ON g.slug = l.slug
+        ON CONFLICT DO NOTHING;
+
+        DROP TABLE legacy_movie_genres;
+    END IF;
+END
+$$;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS genre;
@@ -0,0 +1,15 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS director VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE movies m
+SET director = agg.names
+FROM (
+    SELECT mc.movie_id, string_agg(p.name, ', ' ORDER BY mc.billing_order) AS names
+    FROM movie_credits mc
+    JOIN people p ON p.id = mc.person_id
+    WHERE mc.role = 'director'
+    GROUP BY mc.movie_id
+) agg
+WHERE agg.movie_id = m.id;
+
+DROP TABLE IF EXISTS movie_credits;
+DROP TABLE IF EXISTS people;
@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS people (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    biography  TEXT         NOT NULL DEFAULT '',
+    birth_date DATE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_people_name ON people (name);
+
+CREATE TABLE IF NOT EXISTS movie_credits (
+    id             BIGSERIAL PRIMARY KEY,
+    movie_id       BIGINT       NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+    person_id      BIGINT       NOT NULL REFERENCES people (id) ON DELETE CASCADE,
+    role           VARCHAR(20)  NOT NULL CHECK (role IN ('director', 'actor', 'writer', 'producer')),
+    character_name VARCHAR(255) NOT NULL DEFAULT '',
+    billing_order  INT          NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_credits_movie_id ON movie_credits (movie_id);
+CREATE INDEX IF NOT EXISTS idx_movie_credits_person_id ON movie_credits (person_id, role);
+
+-- Turn the legacy free-text movies.director column into people with a
+-- director credit. Names that match case-insensitively become one person.
+--
+-- Databases that ran this by hand before schema_migrations existed no longer
+-- have the column, so the backfill only runs while it is there.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'movies' AND column_name = 'director'
+    ) THEN
+        CREATE TEMPORARY TABLE legacy_movie_directors AS
+        SELECT DISTINCT m.id AS movie_id, btrim(part) AS name, ord
+        FROM movies m,
+             regexp_split_to_table(m.director, '\s*(,|&|\s+and\s+)\s*') WITH ORDINALITY AS t(part, ord)
+        WHERE m.director IS NOT NULL AND btrim(part) <> '';
+
+        INSERT INTO people (name)
+        SELECT DISTINCT ON (lower(name)) name
+        FROM legacy_movie_directors
+        ORDER BY lower(name), name;
+
+        INSERT INTO movie_credits (movie_id, person_id, role, billing_order)
+        SELECT l.movie_id, p.id, 'director', l.ord - 1
+        FROM legacy_movie_directors l
+        JOIN people p ON lower(p.name) = lower(l.name);
+
+        DROP TABLE legacy_movie_directors;
+    END IF;
+END
+$$;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS director;
@@ -0,0 +1,5 @@
+ALTER TABLE movies
+    DROP COLUMN IF EXISTS average_rating,
+    DROP COLUMN IF EXISTS rating_count;
+
+DROP TABLE IF EXISTS reviews;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS reviews (
+    id         BIGSERIAL PRIMARY KEY,
+    movie_id   BIGINT      NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    rating     SMALLINT    NOT NULL CHECK (rating BETWEEN 1 AND 10),
+    body       TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (movie_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_movie_id_created_at ON reviews (movie_id, created_at DESC);
+
+ALTER TABLE movies
+    ADD COLUMN IF NOT EXISTS average_rating NUMERIC(4, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_count   INT           NOT NULL DEFAULT 0;
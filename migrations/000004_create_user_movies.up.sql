@@ -0,0 +1,11 @@
+-- Personal watchlist and favorites entries
+CREATE TABLE IF NOT EXISTS user_movies (
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    movie_id   BIGINT      NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+    kind       VARCHAR(20) NOT NULL CHECK (kind IN ('watchlist', 'favorites')),
+    added_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    watched_at TIMESTAMPTZ,
+    PRIMARY KEY (user_id, kind, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_movies_movie_id ON user_movies (movie_id);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS lists (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    title       VARCHAR(200) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    visibility  VARCHAR(10)  NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'unlisted', 'public')),
+    view_count  BIGINT       NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lists_user_id ON lists (user_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_lists_public_popular ON lists (view_count DESC) WHERE visibility = 'public';
+
+CREATE TABLE IF NOT EXISTS list_items (
+    list_id  BIGINT      NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    movie_id BIGINT      NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+    position INT         NOT NULL,
+    note     TEXT        NOT NULL DEFAULT '',
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (list_id, movie_id),
+    -- Deferred so positions can be shifted within a transaction
+    CONSTRAINT list_items_position_key UNIQUE (list_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_items_movie_id ON list_items (movie_id);
@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+
+DELETE FROM movies WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE movies DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,14 @@
+-- Immutable history of movie metadata changes. snapshot holds the movie as it
+-- was after the change and changes holds the per-field diff against the
+-- previous revision.
+CREATE TABLE IF NOT EXISTS movie_revisions (
+    id         BIGSERIAL PRIMARY KEY,
+    movie_id   BIGINT      NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+    revision   INT         NOT NULL,
+    action     VARCHAR(20) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore', 'revert')),
+    editor_id  BIGINT      REFERENCES users (id) ON DELETE SET NULL,
+    snapshot   JSONB       NOT NULL,
+    changes    JSONB       NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (movie_id, revision)
+);
@@ -0,0 +1,2 @@
+-- Row version for optimistic concurrency, exposed to clients as the ETag
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,10 @@
+-- Identifiers of movies in external catalogues, used to upsert imported rows
+CREATE TABLE IF NOT EXISTS movie_external_ids (
+    source      VARCHAR(50)  NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    movie_id    BIGINT       NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (source, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_external_ids_movie_id ON movie_external_ids (movie_id);
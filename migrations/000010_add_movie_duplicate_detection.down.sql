@@ -0,0 +1,7 @@
+DELETE FROM movie_revisions WHERE action = 'merge';
+
+ALTER TABLE movie_revisions DROP CONSTRAINT IF EXISTS movie_revisions_action_check;
+ALTER TABLE movie_revisions ADD CONSTRAINT movie_revisions_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'revert'));
+
+DROP INDEX IF EXISTS idx_movies_title_trgm;
@@ -0,0 +1,9 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram index for finding near-duplicate titles
+CREATE INDEX IF NOT EXISTS idx_movies_title_trgm ON movies USING gin (title gin_trgm_ops) WHERE deleted_at IS NULL;
+
+-- Record merges in the revision history
+ALTER TABLE movie_revisions DROP CONSTRAINT IF EXISTS movie_revisions_action_check;
+ALTER TABLE movie_revisions ADD CONSTRAINT movie_revisions_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'revert', 'merge'));
@@ -0,0 +1,14 @@
+-- Poster and backdrop artwork. The files live in the blob store; thumbnails
+-- holds the resized copies as a JSON array of {key, url, width, height}.
+CREATE TABLE IF NOT EXISTS movie_images (
+    movie_id     BIGINT       NOT NULL REFERENCES movies (id) ON DELETE CASCADE,
+    kind         VARCHAR(20)  NOT NULL CHECK (kind IN ('poster', 'backdrop')),
+    storage_key  VARCHAR(500) NOT NULL,
+    url          TEXT         NOT NULL,
+    content_type VARCHAR(50)  NOT NULL,
+    width        INT          NOT NULL,
+    height       INT          NOT NULL,
+    thumbnails   JSONB        NOT NULL DEFAULT '[]',
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (movie_id, kind)
+);
@@ -0,0 +1,11 @@
+DELETE FROM movie_revisions WHERE action = 'transition';
+
+ALTER TABLE movie_revisions DROP CONSTRAINT IF EXISTS movie_revisions_action_check;
+ALTER TABLE movie_revisions ADD CONSTRAINT movie_revisions_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'revert', 'merge'));
+
+DROP INDEX IF EXISTS idx_movies_status;
+
+ALTER TABLE movies
+    DROP COLUMN IF EXISTS publish_at,
+    DROP COLUMN IF EXISTS status;
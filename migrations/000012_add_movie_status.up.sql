@@ -0,0 +1,14 @@
+-- Editorial workflow. Existing movies stay public; new movies start as drafts.
+ALTER TABLE movies
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published'
+        CHECK (status IN ('draft', 'in_review', 'published', 'archived')),
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+ALTER TABLE movies ALTER COLUMN status SET DEFAULT 'draft';
+
+CREATE INDEX IF NOT EXISTS idx_movies_status ON movies (status, publish_at) WHERE deleted_at IS NULL;
+
+-- Record workflow transitions in the revision history
+ALTER TABLE movie_revisions DROP CONSTRAINT IF EXISTS movie_revisions_action_check;
+ALTER TABLE movie_revisions ADD CONSTRAINT movie_revisions_action_check
+    CHECK (action IN ('create', 'update', 'delete', 'restore', 'revert', 'merge', 'transition'));
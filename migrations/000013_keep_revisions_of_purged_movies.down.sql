@@ -0,0 +1,5 @@
+DELETE FROM movie_revisions WHERE movie_id NOT IN (SELECT id FROM movies);
+
+ALTER TABLE movie_revisions DROP CONSTRAINT IF EXISTS movie_revisions_movie_id_fkey;
+ALTER TABLE movie_revisions ADD CONSTRAINT movie_revisions_movie_id_fkey
+    FOREIGN KEY (movie_id) REFERENCES movies (id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+-- Revision history is an audit trail and must outlive the movie: purging the
+-- trash deletes movies for good, so revisions no longer cascade with them.
+ALTER TABLE movie_revisions DROP CONSTRAINT IF EXISTS movie_revisions_movie_id_fkey;
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema migrations so they ship with the binary.
+package migrations
+
+import "embed"
+
+// FS holds the numbered up and down migration files
+//
+//go:embed *.sql
+var FS embed.FS